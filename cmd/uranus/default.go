@@ -45,14 +45,16 @@ func defaultStartConfig() *StartConfig {
 
 func defaultUranusConfig() *server.UranusConfig {
 	return &server.UranusConfig{
-		Genesis:      ledger.DefaultGenesis(),
-		DBHandles:    dbHandles(),
-		DBCache:      512,
-		TrieCache:    256,
-		TrieTimeout:  60 * time.Minute,
-		StartMiner:   false,
-		MinerConfig:  defaultMinerConifg(),
-		TxPoolConfig: defaultTxPoolConfig(),
+		Genesis:           ledger.DefaultGenesis(),
+		DBHandles:         dbHandles(),
+		DBCache:           512,
+		TrieCache:         256,
+		TrieTimeout:       60 * time.Minute,
+		TrieFlushInterval: 7200,
+		StartMiner:        false,
+		SyncMode:          "full",
+		MinerConfig:       defaultMinerConifg(),
+		TxPoolConfig:      defaultTxPoolConfig(),
 	}
 }
 
@@ -66,13 +68,16 @@ func defaultMinerConifg() *miner.Config {
 
 func defaultTxPoolConfig() *txpool.Config {
 	return &txpool.Config{
-		PriceLimit:      1,
-		PriceBump:       10,
-		AccountSlots:    16,
-		GlobalSlots:     4096,
-		AccountQueue:    64,
-		GlobalQueue:     1024,
-		TimeoutDuration: 3 * time.Hour,
+		PriceLimit:          1,
+		PriceBump:           10,
+		AccountSlots:        16,
+		GlobalSlots:         4096,
+		AccountQueue:        64,
+		GlobalQueue:         1024,
+		TimeoutDuration:     3 * time.Hour,
+		Journal:             "transactions.rlp",
+		Rejournal:           time.Hour,
+		AllowUnprotectedTxs: true,
 	}
 }
 
@@ -30,6 +30,7 @@ import (
 	"github.com/UranusBlockStack/uranus/core/ledger"
 	"github.com/UranusBlockStack/uranus/debug"
 	"github.com/UranusBlockStack/uranus/node"
+	"github.com/UranusBlockStack/uranus/node/protocols"
 	"github.com/UranusBlockStack/uranus/server"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -107,6 +108,9 @@ func registerService(stack *node.Node) error {
 	var err error
 	// register uranus server
 	err = stack.Register(func(ctx *node.Context) (node.Service, error) {
+		if mode, _ := protocols.ParseSyncMode(startConfig.UranusConfig.SyncMode); mode == protocols.LightSync {
+			return server.NewLight(ctx, startConfig.UranusConfig)
+		}
 		return server.New(ctx, startConfig.UranusConfig)
 	})
 	return err
@@ -133,11 +137,23 @@ func init() {
 	flags.StringVar(&startConfig.NodeConfig.P2P.ListenAddr, "p2p_listenaddr", startConfig.NodeConfig.P2P.ListenAddr, "p2p listening url")
 	flags.IntVar(&startConfig.NodeConfig.P2P.MaxPeers, "p2p_maxpeers", startConfig.NodeConfig.P2P.MaxPeers, "maximum number of network peers")
 	flags.StringSliceVar(&startConfig.NodeConfig.P2P.BootNodeStrs, "p2p_bootnodes", startConfig.NodeConfig.P2P.BootNodeStrs, "comma separated enode URLs for P2P discovery bootstrap")
+	flags.StringSliceVar(&startConfig.NodeConfig.P2P.StaticNodeStrs, "p2p_staticnodes", startConfig.NodeConfig.P2P.StaticNodeStrs, "comma separated enode URLs of the peers always kept connected")
+	flags.StringSliceVar(&startConfig.NodeConfig.P2P.TrustedNodeStrs, "p2p_trustednodes", startConfig.NodeConfig.P2P.TrustedNodeStrs, "comma separated enode URLs of the peers accepted beyond the maximum number of peers")
+	flags.StringVar(&startConfig.NodeConfig.P2P.NATStr, "p2p_nat", startConfig.NodeConfig.P2P.NATStr, "NAT port mapping mechanism (any|none|upnp|pmp|extip:<IP>)")
+	flags.StringVar(&startConfig.NodeConfig.P2P.NetRestrictStr, "p2p_netrestrict", startConfig.NodeConfig.P2P.NetRestrictStr, "restricts network communication to the given IP networks (CIDR masks)")
 
 	// config file
 	flags.StringVarP(&startConfig.CfgFile, "config", "c", "", "YAML configuration file")
 	flags.StringVarP(&startConfig.GenesisFile, "genesis", "g", "", "YAML configuration file")
 
+	// trie
+	flags.BoolVar(&startConfig.UranusConfig.TrieArchive, "trie_archive", startConfig.UranusConfig.TrieArchive, "Write every state trie to disk, disabling the trie pruning (archive node)")
+	flags.IntVar(&startConfig.UranusConfig.TrieCache, "trie_cache", startConfig.UranusConfig.TrieCache, "Memory allowance (MB) of the in-memory tries before flushing them to disk")
+	flags.Uint64Var(&startConfig.UranusConfig.TrieFlushInterval, "trie_flushinterval", startConfig.UranusConfig.TrieFlushInterval, "Number of blocks after which the confirmed in-memory tries are flushed to disk")
+
+	// sync
+	flags.StringVar(&startConfig.UranusConfig.SyncMode, "sync_mode", startConfig.UranusConfig.SyncMode, "Blockchain sync mode (\"full\", \"fast\" or \"light\", fast downloads the state of a recent block instead of executing the whole chain, light keeps the headers only and retrieves the rest from full peers on demand)")
+
 	// TxPoolConfig
 	flags.Uint64Var(&startConfig.UranusConfig.TxPoolConfig.PriceBump, "txpool_pricebump", startConfig.UranusConfig.TxPoolConfig.PriceBump, "Price bump percentage to replace an already existing transaction")
 	flags.Uint64Var(&startConfig.UranusConfig.TxPoolConfig.PriceLimit, "txpool_pricelimit", startConfig.UranusConfig.TxPoolConfig.PriceLimit, "Minimum gas price limit to enforce for acceptance into the pool")
@@ -146,12 +162,16 @@ func init() {
 	flags.Uint64Var(&startConfig.UranusConfig.TxPoolConfig.GlobalSlots, "txpool_globalslots", startConfig.UranusConfig.TxPoolConfig.GlobalSlots, "Maximum number of executable transaction slots for all accounts")
 	flags.Uint64Var(&startConfig.UranusConfig.TxPoolConfig.GlobalQueue, "txpool_globalqueue", startConfig.UranusConfig.TxPoolConfig.GlobalQueue, "Minimum number of non-executable transaction slots for all accounts")
 	flags.DurationVar(&startConfig.UranusConfig.TxPoolConfig.TimeoutDuration, "txpool_timeout", startConfig.UranusConfig.TxPoolConfig.TimeoutDuration, "Maximum amount of time non-executable transaction are queued")
+	flags.StringVar(&startConfig.UranusConfig.TxPoolConfig.Journal, "txpool_journal", startConfig.UranusConfig.TxPoolConfig.Journal, "Disk journal for local transaction to survive node restarts")
+	flags.DurationVar(&startConfig.UranusConfig.TxPoolConfig.Rejournal, "txpool_rejournal", startConfig.UranusConfig.TxPoolConfig.Rejournal, "Time interval to regenerate the local transaction journal")
+	flags.BoolVar(&startConfig.UranusConfig.TxPoolConfig.AllowUnprotectedTxs, "txpool_allowunprotected", startConfig.UranusConfig.TxPoolConfig.AllowUnprotectedTxs, "Accept transactions signed without chain id (not replay-protected)")
 
 	// miner
 	flags.StringVar(&startConfig.UranusConfig.MinerConfig.CoinBaseAddr, "miner_conbase", "", "Public address for block mining rewards (default = first account created)")
 	flags.StringVar(&startConfig.UranusConfig.MinerConfig.ExtraData, "miner_extradata", startConfig.UranusConfig.MinerConfig.ExtraData, "Block extra data set by the miner")
 	flags.IntVar(&startConfig.UranusConfig.MinerConfig.MinerThreads, "miner_threads", startConfig.UranusConfig.MinerConfig.MinerThreads, "Number of CPU threads to use for mining")
 	flags.BoolVar(&startConfig.UranusConfig.StartMiner, "miner_start", startConfig.UranusConfig.StartMiner, "Enable mining")
+	flags.StringVar(&startConfig.UranusConfig.MinerConfig.PasswordFile, "miner_passwordfile", "", "Password file to unlock the coinbase account for signing blocks")
 
 	// debug
 	flags.BoolVar(&startConfig.DebugConfig.Pprof, "debug_pprof", startConfig.DebugConfig.Pprof, "Enable the pprof HTTP server")
@@ -161,6 +181,9 @@ func init() {
 	flags.IntVar(&startConfig.DebugConfig.Blockprofilerate, "debug_blockprofilerate", startConfig.DebugConfig.Blockprofilerate, "Turn on block profiling with the given rate")
 	flags.StringVar(&startConfig.DebugConfig.Cpuprofile, "debug_cpuprofile", startConfig.DebugConfig.Cpuprofile, "Write CPU profile to the given file")
 	flags.StringVar(&startConfig.DebugConfig.Trace, "debug_trace", startConfig.DebugConfig.Trace, "Write execution trace to the given file")
+	flags.BoolVar(&startConfig.DebugConfig.Metrics, "debug_metrics", startConfig.DebugConfig.Metrics, "Enable the Prometheus metrics HTTP server")
+	flags.IntVar(&startConfig.DebugConfig.MetricsPort, "debug_metrics_port", startConfig.DebugConfig.MetricsPort, "Metrics HTTP server listening port")
+	flags.StringVar(&startConfig.DebugConfig.MetricsAddr, "debug_metrics_addr", startConfig.DebugConfig.MetricsAddr, "Metrics HTTP server listening interface")
 
 	//----------viper config file---------------
 
@@ -182,6 +205,18 @@ func init() {
 	// node.p2p
 	viper.BindPFlag("p2p-listenaddr", flags.Lookup("p2p_listenaddr"))
 	viper.BindPFlag("p2p-maxpeers", flags.Lookup("p2p_maxpeers"))
+	viper.BindPFlag("p2p-staticnodes", flags.Lookup("p2p_staticnodes"))
+	viper.BindPFlag("p2p-trustednodes", flags.Lookup("p2p_trustednodes"))
+	viper.BindPFlag("p2p-nat", flags.Lookup("p2p_nat"))
+	viper.BindPFlag("p2p-netrestrict", flags.Lookup("p2p_netrestrict"))
+
+	// trie
+	viper.BindPFlag("trie-archive", flags.Lookup("trie_archive"))
+	viper.BindPFlag("trie-cache", flags.Lookup("trie_cache"))
+	viper.BindPFlag("trie-flushinterval", flags.Lookup("trie_flushinterval"))
+
+	// sync
+	viper.BindPFlag("sync-mode", flags.Lookup("sync_mode"))
 
 	// txpool
 	viper.BindPFlag("txpool-pricebump", flags.Lookup("txpool_pricebump"))
@@ -191,12 +226,16 @@ func init() {
 	viper.BindPFlag("txpool-globalslots", flags.Lookup("txpool_globalslots"))
 	viper.BindPFlag("txpool-globalqueue", flags.Lookup("txpool_globalqueue"))
 	viper.BindPFlag("txpool-timeout", flags.Lookup("txpool_timeout"))
+	viper.BindPFlag("txpool-journal", flags.Lookup("txpool_journal"))
+	viper.BindPFlag("txpool-rejournal", flags.Lookup("txpool_rejournal"))
+	viper.BindPFlag("txpool-allowunprotected", flags.Lookup("txpool_allowunprotected"))
 
 	// miner
 	viper.BindPFlag("miner-conbase", flags.Lookup("miner_conbase"))
 	viper.BindPFlag("miner-extradata", flags.Lookup("miner_extradata"))
 	viper.BindPFlag("miner-threads", flags.Lookup("miner_threads"))
 	viper.BindPFlag("miner-start", flags.Lookup("miner_start"))
+	viper.BindPFlag("miner-passwordfile", flags.Lookup("miner_passwordfile"))
 
 	// debug
 	viper.BindPFlag("debug-pprof", flags.Lookup("debug_pprof"))
@@ -206,6 +245,9 @@ func init() {
 	viper.BindPFlag("debug-blockprofilerate", flags.Lookup("debug_blockprofilerate"))
 	viper.BindPFlag("debug-cpuprofile", flags.Lookup("debug_cpuprofile"))
 	viper.BindPFlag("debug-trace", flags.Lookup("debug_trace"))
+	viper.BindPFlag("debug-metrics", flags.Lookup("debug_metrics"))
+	viper.BindPFlag("debug-metricsport", flags.Lookup("debug_metrics_port"))
+	viper.BindPFlag("debug-metricsaddr", flags.Lookup("debug_metrics_addr"))
 }
 
 func initConfig() {
@@ -17,8 +17,11 @@
 package main
 
 import (
+	"strconv"
+
 	"github.com/UranusBlockStack/uranus/cmd/utils"
 	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/rpcapi"
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
 )
@@ -64,6 +67,82 @@ var removePeerCmd = &cobra.Command{
 		utils.PrintJSON(result)
 	},
 }
+
+var addTrustedPeerCmd = &cobra.Command{
+	Use:   "addTrustedPeer <nodeurl>",
+	Short: "Allows a remote node to always connect, even if slots are full.",
+	Long:  `Allows a remote node to always connect, even if slots are full.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result bool
+		utils.ClientCall("Admin.AddTrustedPeer", args[0], &result)
+		utils.PrintJSON(result)
+	},
+}
+
+var removeTrustedPeerCmd = &cobra.Command{
+	Use:   "removeTrustedPeer <nodeurl>",
+	Short: "Removes a remote node from the trusted peer set.",
+	Long:  `Removes a remote node from the trusted peer set, but it does not disconnect it automatically.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result bool
+		utils.ClientCall("Admin.RemoveTrustedPeer", args[0], &result)
+		utils.PrintJSON(result)
+	},
+}
+
+var listBansCmd = &cobra.Command{
+	Use:   "listBans",
+	Short: "List all banned peers.",
+	Long:  `List all banned peers.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		result := []*p2p.BanInfo{}
+		utils.ClientCall("Admin.Bans", nil, &result)
+		if utils.OneLine {
+			for i, item := range result {
+				jww.FEEDBACK.Print(i, ":", item.ID)
+			}
+		} else {
+			utils.PrintJSONList(result)
+		}
+	},
+}
+
+var banPeerCmd = &cobra.Command{
+	Use:   "banPeer <nodeurl> [duration]",
+	Short: "Disconnects a remote node and refuses its connections for duration seconds.",
+	Long:  `Disconnects a remote node and refuses its connections for duration seconds, default 24 hours.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := rpcapi.BanPeerArgs{URL: args[0]}
+		if len(args) == 2 {
+			duration, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				jww.ERROR.Println(err)
+				return
+			}
+			req.Duration = &duration
+		}
+		var result bool
+		utils.ClientCall("Admin.BanPeer", req, &result)
+		utils.PrintJSON(result)
+	},
+}
+
+var unbanPeerCmd = &cobra.Command{
+	Use:   "unbanPeer <nodeurl>",
+	Short: "Lifts the ban of a remote node and resets its reputation.",
+	Long:  `Lifts the ban of a remote node and resets its reputation.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result bool
+		utils.ClientCall("Admin.UnbanPeer", args[0], &result)
+		utils.PrintJSON(result)
+	},
+}
+
 var nodeInfoCmd = &cobra.Command{
 	Use:   "nodeInfo ",
 	Short: "retrieves all the information we know about the host node at the protocol granularity",
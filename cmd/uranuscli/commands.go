@@ -26,11 +26,22 @@ func AddCommands() {
 	RootCmd.AddCommand(listAccountsCmd)
 	RootCmd.AddCommand(importRawKeyCmd)
 	RootCmd.AddCommand(exportRawKeyCmd)
+	RootCmd.AddCommand(unlockAccountCmd)
+	RootCmd.AddCommand(lockAccountCmd)
+	RootCmd.AddCommand(getMultisigCmd)
+	RootCmd.AddCommand(signMultisigTransactionCmd)
+	RootCmd.AddCommand(addMultisigSignatureCmd)
+	RootCmd.AddCommand(combineMultisigSignaturesCmd)
 
 	// admin command
 	RootCmd.AddCommand(listPeersCmd)
 	RootCmd.AddCommand(addPeerCmd)
 	RootCmd.AddCommand(removePeerCmd)
+	RootCmd.AddCommand(addTrustedPeerCmd)
+	RootCmd.AddCommand(removeTrustedPeerCmd)
+	RootCmd.AddCommand(listBansCmd)
+	RootCmd.AddCommand(banPeerCmd)
+	RootCmd.AddCommand(unbanPeerCmd)
 	RootCmd.AddCommand(nodeInfoCmd)
 
 	// blockchain command
@@ -56,9 +67,14 @@ func AddCommands() {
 	RootCmd.AddCommand(getUnDelegateTimeCmd)
 	RootCmd.AddCommand(getNonceCmd)
 	RootCmd.AddCommand(getCodeCmd)
+	RootCmd.AddCommand(getProofCmd)
 	RootCmd.AddCommand(sendRawTransactionCmd)
 	RootCmd.AddCommand(signAndSendTransactionCmd)
+	RootCmd.AddCommand(replaceTransactionCmd)
+	RootCmd.AddCommand(cancelTransactionCmd)
 	RootCmd.AddCommand(callCmd)
+	RootCmd.AddCommand(estimateGasCmd)
+	RootCmd.AddCommand(getLogsCmd)
 
 	// miner command
 	RootCmd.AddCommand(startMinerCmd)
@@ -77,6 +93,6 @@ func AddCommands() {
 	// debug command
 	RootCmd.AddCommand(memStatsCmd, gcStatsCmd, cpuProfileCmd,
 		goTraceCmd, blockProfileCmd, mutexProfileCmd, writeMemProfileCmd,
-		stacksCmd, freeOSMemoryCmd)
+		stacksCmd, freeOSMemoryCmd, traceTransactionCmd, traceBlockCmd)
 
 }
@@ -22,7 +22,9 @@ import (
 	rdebug "runtime/debug"
 
 	cmdutils "github.com/UranusBlockStack/uranus/cmd/utils"
+	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/debug"
+	"github.com/UranusBlockStack/uranus/rpcapi"
 	"github.com/spf13/cobra"
 )
 
@@ -141,3 +143,43 @@ var freeOSMemoryCmd = &cobra.Command{
 		cmdutils.PrintJSON(result)
 	},
 }
+
+var traceTransactionCmd = &cobra.Command{
+	Use:   "traceTransaction <hash> [tracer]",
+	Short: "Returns the structured logs or, with the callTracer, the internal calls of the transaction.",
+	Long:  `Returns the structured logs or, with the callTracer, the internal calls of the transaction.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := rpcapi.TraceTransactionArgs{TxHash: utils.HexToHash(cmdutils.IsHexHash(args[0]))}
+		if len(args) == 2 {
+			req.Tracer = args[1]
+		}
+		result := &rpcapi.ExecutionResult{}
+		cmdutils.ClientCall("Debug.TraceTransaction", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var traceBlockCmd = &cobra.Command{
+	Use:   "traceBlock [height|hash] [tracer]",
+	Short: "Returns the structured logs created during the execution of all the transactions of the block.",
+	Long:  `Returns the structured logs created during the execution of all the transactions of the block.`,
+	Args:  cobra.RangeArgs(0, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := rpcapi.TraceBlockArgs{}
+		if len(args) == 2 {
+			req.Tracer = args[1]
+		}
+		if len(args) >= 1 {
+			if utils.IsHexHash(args[0]) {
+				hash := utils.HexToHash(args[0])
+				req.BlockHash = &hash
+			} else {
+				req.BlockHeight = cmdutils.GetBlockheight(args[0])
+			}
+		}
+		var result []*rpcapi.ExecutionResult
+		cmdutils.ClientCall("Debug.TraceBlock", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
@@ -196,6 +196,28 @@ var getNonceCmd = &cobra.Command{
 		}
 	},
 }
+var getProofCmd = &cobra.Command{
+	Use:   "getProof <address> [height] [storageKey...]",
+	Short: "returns the account and storage values with their merkle proofs for the given address.",
+	Long:  `returns the account and storage values with their merkle proofs for the given address.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result := &rpcapi.AccountResult{}
+		req := &rpcapi.GetProofArgs{Address: utils.HexToAddress(cmdutils.IsHexAddr(args[0]))}
+		if len(args) > 1 {
+			req.BlockHeight = cmdutils.GetBlockheight(args[1])
+		}
+		if len(args) > 2 {
+			for _, key := range args[2:] {
+				req.StorageKeys = append(req.StorageKeys, utils.HexToHash(cmdutils.IsHexHash(key)))
+			}
+		}
+
+		cmdutils.ClientCall("Uranus.GetProof", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
 var getCodeCmd = &cobra.Command{
 	Use:   "getCode <address> [height]",
 	Short: "returns contract code for the given contract address.",
@@ -245,6 +267,38 @@ var signAndSendTransactionCmd = &cobra.Command{
 	},
 }
 
+var replaceTransactionCmd = &cobra.Command{
+	Use:   "replaceTransaction <ReplaceTxArgs json>",
+	Short: "replaces a pending transaction with a higher gas price.",
+	Long:  `replaces a pending transaction with a higher gas price, the minimum price bump is paid if no gas price given.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result := &rpcapi.ReplacedTransaction{}
+		req := &rpcapi.ReplaceTxArgs{}
+		if err := json.Unmarshal([]byte(args[0]), req); err != nil {
+			jww.ERROR.Println(err)
+		}
+		cmdutils.ClientCall("Uranus.ReplaceTransaction", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var cancelTransactionCmd = &cobra.Command{
+	Use:   "cancelTransaction <hash> [passphrase]",
+	Short: "cancels a pending transaction by replacing it with an empty transfer to the sender.",
+	Long:  `cancels a pending transaction by replacing it with an empty transfer to the sender, paying the minimum price bump.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		result := &rpcapi.ReplacedTransaction{}
+		req := rpcapi.CancelTxArgs{Hash: utils.HexToHash(cmdutils.IsHexHash(args[0]))}
+		if len(args) == 2 {
+			req.Passphrase = args[1]
+		}
+		cmdutils.ClientCall("Uranus.CancelTransaction", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
 var callCmd = &cobra.Command{
 	Use:   "call <CallArgs json>",
 	Short: "executes the given transaction on the state for the given block number..",
@@ -261,3 +315,37 @@ var callCmd = &cobra.Command{
 		cmdutils.PrintJSON(result)
 	},
 }
+
+var estimateGasCmd = &cobra.Command{
+	Use:   "estimateGas <CallArgs json>",
+	Short: "returns the lowest gas limit that allows the transaction to run successfully.",
+	Long:  `returns the lowest gas limit that allows the transaction to run successfully.`,
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		result := new(utils.Uint64)
+		req := &rpcapi.CallArgs{}
+		if err := json.Unmarshal([]byte(args[0]), req); err != nil {
+			jww.ERROR.Println(err)
+		}
+		cmdutils.ClientCall("Uranus.EstimateGas", req, result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var getLogsCmd = &cobra.Command{
+	Use:   "getLogs <FilterCriteria json>",
+	Short: "returns logs matching the given filter criteria.",
+	Long:  `returns logs matching the given filter criteria.`,
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		result := []map[string]interface{}{}
+		req := &rpcapi.FilterCriteria{}
+		if err := json.Unmarshal([]byte(args[0]), req); err != nil {
+			jww.ERROR.Println(err)
+		}
+		cmdutils.ClientCall("Uranus.GetLogs", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
@@ -17,6 +17,9 @@
 package main
 
 import (
+	"encoding/json"
+	"strconv"
+
 	cmdutils "github.com/UranusBlockStack/uranus/cmd/utils"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/rpcapi"
@@ -99,6 +102,41 @@ var importRawKeyCmd = &cobra.Command{
 	},
 }
 
+var unlockAccountCmd = &cobra.Command{
+	Use:   "unlockAccount <address> <passphrase> [duration]",
+	Short: "Unlock a account for the duration in seconds (default 300, 0 until locked).",
+	Long:  `Unlock a account for the duration in seconds (default 300, 0 until locked), the account signs transactions without passphrase.`,
+	Args:  cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := rpcapi.UnlockArgs{
+			Address:    utils.HexToAddress(cmdutils.IsHexAddr(args[0])),
+			Passphrase: args[1]}
+		if len(args) == 3 {
+			duration, err := strconv.ParseUint(args[2], 10, 64)
+			if err != nil {
+				jww.ERROR.Println(err)
+				return
+			}
+			req.Duration = &duration
+		}
+		var result bool
+		cmdutils.ClientCall("Wallet.Unlock", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var lockAccountCmd = &cobra.Command{
+	Use:   "lockAccount <address>",
+	Short: "Lock a unlocked account.",
+	Long:  `Lock a unlocked account.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result bool
+		cmdutils.ClientCall("Wallet.Lock", utils.HexToAddress(cmdutils.IsHexAddr(args[0])), &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
 var exportRawKeyCmd = &cobra.Command{
 	Use:   "exportRawKey <address> <passphrase>",
 	Short: "export raw PrivateKey as hex string.",
@@ -112,3 +150,71 @@ var exportRawKeyCmd = &cobra.Command{
 		cmdutils.PrintJSON(result)
 	},
 }
+
+var getMultisigCmd = &cobra.Command{
+	Use:   "getMultisig <address> [height]",
+	Short: "returns the owners and the threshold of the multisig account.",
+	Long:  `returns the owners and the threshold of the multisig account in the state of the given block number.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := &rpcapi.GetBalanceArgs{Address: utils.HexToAddress(cmdutils.IsHexAddr(args[0]))}
+		if len(args) == 2 {
+			req.BlockHeight = cmdutils.GetBlockheight(args[1])
+		}
+		result := map[string]interface{}{}
+		cmdutils.ClientCall("Uranus.GetMultisig", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var signMultisigTransactionCmd = &cobra.Command{
+	Use:   "signMultisigTransaction <MultisigTxArgs json>",
+	Short: "creates a transaction of the multisig account signed by one of its owners.",
+	Long:  `creates a transaction of the multisig account "From" signed by its owner "Owner", the encoded transaction collects the signatures of the other owners.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result := new(utils.Bytes)
+		req := &rpcapi.MultisigTxArgs{}
+		if err := json.Unmarshal([]byte(args[0]), req); err != nil {
+			jww.ERROR.Println(err)
+			return
+		}
+		cmdutils.ClientCall("Wallet.SignMultisigTransaction", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var addMultisigSignatureCmd = &cobra.Command{
+	Use:   "addMultisigSignature <encodedTx> <owner> [passphrase]",
+	Short: "adds the signature of an owner to the encoded multisig transaction.",
+	Long:  `adds the signature of an owner to the encoded multisig transaction.`,
+	Args:  cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		result := new(utils.Bytes)
+		req := rpcapi.AddSignatureArgs{
+			Tx:    utils.FromHex(args[0]),
+			Owner: utils.HexToAddress(cmdutils.IsHexAddr(args[1])),
+		}
+		if len(args) == 3 {
+			req.Passphrase = args[2]
+		}
+		cmdutils.ClientCall("Wallet.AddMultisigSignature", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var combineMultisigSignaturesCmd = &cobra.Command{
+	Use:   "combineMultisigSignatures <encodedTx>...",
+	Short: "merges the owner signatures of copies of the same encoded multisig transaction.",
+	Long:  `merges the owner signatures of copies of the same encoded multisig transaction.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result := new(utils.Bytes)
+		req := make([]utils.Bytes, len(args))
+		for i, arg := range args {
+			req[i] = utils.FromHex(arg)
+		}
+		cmdutils.ClientCall("Wallet.CombineMultisigSignatures", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
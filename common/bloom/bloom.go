@@ -87,3 +87,20 @@ func Bloom9(b []byte) *big.Int {
 	}
 	return r
 }
+
+type bytesBacked interface {
+	Bytes() []byte
+}
+
+// Test checks if the given topic is present in the bloom filter.
+func (b Bloom) Test(topic bytesBacked) bool {
+	return BloomLookup(b, topic)
+}
+
+// BloomLookup reports whether the given topic may be contained in the bloom.
+func BloomLookup(bin Bloom, topic bytesBacked) bool {
+	tbloom := bin.Big()
+	cmp := Bloom9(topic.Bytes()[:])
+
+	return tbloom.And(tbloom, cmp).Cmp(cmp) == 0
+}
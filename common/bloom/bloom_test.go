@@ -21,17 +21,6 @@ import (
 	"testing"
 )
 
-type bytesBacked interface {
-	Bytes() []byte
-}
-
-func BloomLookup(bin Bloom, topic bytesBacked) bool {
-	tbloom := bin.Big()
-	cmp := Bloom9(topic.Bytes()[:])
-
-	return tbloom.And(tbloom, cmp).Cmp(cmp) == 0
-}
-
 func TestBloom(t *testing.T) {
 	positive := []string{
 		"test1",
@@ -180,10 +180,11 @@ func (db *Database) Nodes() []utils.Hash {
 	return hashes
 }
 
-// Reference adds a new reference from a parent node to a child node.
+// Reference adds a new reference from a parent node to a child node. Tries
+// referenced from the empty hash are roots kept alive until dereferenced.
 func (db *Database) Reference(child utils.Hash, parent utils.Hash) {
-	db.lock.RLock()
-	defer db.lock.RUnlock()
+	db.lock.Lock()
+	defer db.lock.Unlock()
 
 	db.reference(child, parent)
 }
@@ -221,12 +222,14 @@ func (db *Database) Dereference(child utils.Hash, parent utils.Hash) {
 
 // dereference is the private locked version of Dereference.
 func (db *Database) dereference(child utils.Hash, parent utils.Hash) {
-	// Dereference the parent-child
+	// Dereference the parent-child, the reference is missing if the child
+	// was already on disk when it was referenced.
 	node := db.nodes[parent]
-
-	node.children[child]--
-	if node.children[child] == 0 {
-		delete(node.children, child)
+	if node.children[child] > 0 {
+		node.children[child]--
+		if node.children[child] == 0 {
+			delete(node.children, child)
+		}
 	}
 	// If the node does not exist, it's a previously committed node.
 	node, ok := db.nodes[child]
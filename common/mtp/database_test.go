@@ -0,0 +1,77 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package mtp
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	mdb "github.com/UranusBlockStack/uranus/common/db/memorydb"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func commitTestTrie(t *testing.T, trie *Trie, from, to int) utils.Hash {
+	for i := from; i < to; i++ {
+		trie.Update([]byte(fmt.Sprintf("key-%d", i)), bytes.Repeat([]byte{byte(i)}, 40))
+	}
+	root, err := trie.Commit(nil)
+	if err != nil {
+		t.Fatalf("commit error: %v", err)
+	}
+	return root
+}
+
+func TestDatabaseDereference(t *testing.T) {
+	diskdb := mdb.New()
+	triedb := NewDatabase(diskdb)
+
+	trie, _ := New(utils.Hash{}, triedb)
+	root1 := commitTestTrie(t, trie, 0, 32)
+	triedb.Reference(root1, utils.Hash{})
+	root2 := commitTestTrie(t, trie, 32, 33)
+	triedb.Reference(root2, utils.Hash{})
+
+	// the shared nodes must survive the garbage collection of the first root
+	triedb.Dereference(root1, utils.Hash{})
+	trie, err := New(root2, triedb)
+	assert.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0}, 40), trie.Get([]byte("key-0")))
+
+	triedb.Dereference(root2, utils.Hash{})
+	assert.Equal(t, 0, len(triedb.Nodes()))
+	assert.Equal(t, 0, diskdb.Len())
+}
+
+func TestDatabaseCommitReferenced(t *testing.T) {
+	diskdb := mdb.New()
+	triedb := NewDatabase(diskdb)
+
+	trie, _ := New(utils.Hash{}, triedb)
+	root := commitTestTrie(t, trie, 0, 32)
+	triedb.Reference(root, utils.Hash{})
+
+	assert.NoError(t, triedb.Commit(root, false))
+	assert.Equal(t, 0, len(triedb.Nodes()))
+
+	// dereferencing a flushed root must leave the persisted nodes untouched
+	triedb.Dereference(root, utils.Hash{})
+	trie, err := New(root, NewDatabase(diskdb))
+	assert.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{31}, 40), trie.Get([]byte("key-31")))
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package mtp
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+// VerifyProof checks merkle proofs. The given proof must contain the value for
+// key in a trie with the given root hash. VerifyProof returns an error if the
+// proof contains invalid trie nodes or the wrong value, a nil value without
+// error proves that the key is absent from the trie.
+//
+// The proof database holds the proof nodes keyed by their hash, as written by Prove.
+func VerifyProof(rootHash utils.Hash, key []byte, proofDb db.Reader) (value []byte, err error) {
+	if rootHash == (utils.Hash{}) || rootHash == emptyRoot {
+		return nil, nil
+	}
+	key = keybytesToHex(key)
+	wantHash := rootHash
+	for i := 0; ; i++ {
+		buf, _ := proofDb.Get(wantHash[:])
+		if buf == nil {
+			return nil, fmt.Errorf("proof node %d (hash %064x) missing", i, wantHash)
+		}
+		n, err := decodeNode(wantHash[:], buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+		}
+		keyrest, cld := get(n, key)
+		switch cld := cld.(type) {
+		case nil:
+			// The trie doesn't contain the key.
+			return nil, nil
+		case hashNode:
+			key = keyrest
+			copy(wantHash[:], cld)
+		case valueNode:
+			return cld, nil
+		}
+	}
+}
+
+// get walks the embedded nodes of tn along key and returns the node it stops
+// at, which is either a hash node to resolve from the proof, a value or nil.
+func get(tn node, key []byte) ([]byte, node) {
+	for {
+		switch n := tn.(type) {
+		case *shortNode:
+			if len(key) < len(n.Key) || !bytes.Equal(n.Key, key[:len(n.Key)]) {
+				return nil, nil
+			}
+			tn = n.Val
+			key = key[len(n.Key):]
+		case *fullNode:
+			tn = n.Children[key[0]]
+			key = key[1:]
+		case hashNode:
+			return key, n
+		case nil:
+			return key, nil
+		case valueNode:
+			return nil, n
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package mtp
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	mdb "github.com/UranusBlockStack/uranus/common/db/memorydb"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyProof(t *testing.T) {
+	trie, _ := New(utils.Hash{}, NewDatabase(mdb.New()))
+	vals := make(map[string][]byte)
+	for i := 0; i < 200; i++ {
+		key, val := []byte(fmt.Sprintf("key-%d", i)), bytes.Repeat([]byte{byte(i)}, i%40+1)
+		trie.Update(key, val)
+		vals[string(key)] = val
+	}
+	root := trie.Hash()
+
+	for key, val := range vals {
+		proof := mdb.New()
+		assert.NoError(t, trie.Prove([]byte(key), 0, proof))
+		res, err := VerifyProof(root, []byte(key), proof)
+		assert.NoError(t, err)
+		assert.Equal(t, val, res)
+	}
+
+	// absent key
+	proof := mdb.New()
+	assert.NoError(t, trie.Prove([]byte("unknown"), 0, proof))
+	res, err := VerifyProof(root, []byte("unknown"), proof)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	// proof checked against another root
+	proof = mdb.New()
+	assert.NoError(t, trie.Prove([]byte("key-1"), 0, proof))
+	_, err = VerifyProof(utils.HexToHash("0x01"), []byte("key-1"), proof)
+	assert.Error(t, err)
+
+	// empty trie
+	res, err = VerifyProof(emptyRoot, []byte("key-1"), mdb.New())
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
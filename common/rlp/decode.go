@@ -86,12 +86,17 @@ type Decoder interface {
 // error if there are too few or too many elements.
 //
 // The decoding of struct fields honours certain struct tags, "tail",
-// "nil" and "-".
+// "optional", "nil" and "-".
 //
 // The "-" tag ignores fields.
 //
 // For an explanation of "tail", see the example.
 //
+// The "optional" tag lets the input list end before the field, which is
+// then left zero. All the fields following an optional field must be
+// optional too. When encoding, the trailing optional fields are omitted
+// as long as they are zero.
+//
 // The "nil" tag applies to pointer-typed fields and changes the decoding
 // rules for the field such that input values of size zero decode as a nil
 // pointer. This tag can be useful when decoding recursive types.
@@ -450,9 +455,17 @@ func makeStructDecoder(typ reflect.Type) (decoder, error) {
 		if _, err := s.List(); err != nil {
 			return wrapStreamError(err, typ)
 		}
-		for _, f := range fields {
+		for i, f := range fields {
 			err := f.info.decoder(s, val.Field(f.index))
 			if err == EOL {
+				if f.optional {
+					// the list may end before the optional fields, which
+					// are left zero
+					for _, f := range fields[i:] {
+						val.Field(f.index).Set(reflect.Zero(val.Field(f.index).Type()))
+					}
+					break
+				}
 				return &decodeError{msg: "too few elements", typ: typ}
 			} else if err != nil {
 				return addErrorContext(err, "."+typ.Field(f.index).Name)
@@ -347,6 +347,17 @@ type tailUint struct {
 	Tail []uint `rlp:"tail"`
 }
 
+type optionalFields struct {
+	A uint
+	B uint     `rlp:"optional"`
+	C *big.Int `rlp:"optional"`
+}
+
+type invalidOptional struct {
+	A uint `rlp:"optional"`
+	B uint
+}
+
 var (
 	veryBigInt = big.NewInt(0).Add(
 		big.NewInt(0).Lsh(big.NewInt(0xFFFFFFFFFFFFFF), 16),
@@ -511,6 +522,38 @@ var decodeTests = []decodeTest{
 		value: tailRaw{A: 1, Tail: []RawValue{}},
 	},
 
+	// struct tag "optional"
+	{
+		input: "C101",
+		ptr:   new(optionalFields),
+		value: optionalFields{A: 1},
+	},
+	{
+		input: "C20102",
+		ptr:   new(optionalFields),
+		value: optionalFields{A: 1, B: 2},
+	},
+	{
+		input: "C3010203",
+		ptr:   new(optionalFields),
+		value: optionalFields{A: 1, B: 2, C: big.NewInt(3)},
+	},
+	{
+		input: "C401020304",
+		ptr:   new(optionalFields),
+		error: "rlp: input list has too many elements for rlp.optionalFields",
+	},
+	{
+		input: "C0",
+		ptr:   new(optionalFields),
+		error: "rlp: too few elements for rlp.optionalFields",
+	},
+	{
+		input: "C0",
+		ptr:   new(invalidOptional),
+		error: "rlp: struct field rlp.invalidOptional.B needs \"optional\" tag",
+	},
+
 	// struct tag "-"
 	{
 		input: "C20102",
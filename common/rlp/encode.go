@@ -529,9 +529,17 @@ func makeStructWriter(typ reflect.Type) (writer, error) {
 	if err != nil {
 		return nil, err
 	}
+	firstOptional := firstOptionalField(fields)
 	writer := func(val reflect.Value, w *encbuf) error {
+		// the trailing optional fields are omitted while zero
+		last := len(fields) - 1
+		for ; last >= firstOptional; last-- {
+			if !val.Field(fields[last].index).IsZero() {
+				break
+			}
+		}
 		lh := w.list()
-		for _, f := range fields {
+		for _, f := range fields[:last+1] {
 			if err := f.info.writer(val.Field(f.index), w); err != nil {
 				return err
 			}
@@ -55,15 +55,15 @@ const (
 )
 
 var (
-	errMissingSignature           = errors.New("extra-data 65 byte suffix signature missing")
-	ErrInvalidTimestamp           = errors.New("invalid timestamp")
+	errMissingSignature           = consensus.NewInvalidError("extra-data 65 byte suffix signature missing")
+	ErrInvalidTimestamp           = consensus.NewInvalidError("invalid timestamp")
 	ErrWaitForPrevBlock           = errors.New("wait for last block arrived")
 	ErrMintIngoreBlock            = errors.New("mint the ingore block")
 	ErrMintFutureBlock            = errors.New("mint the future block")
-	ErrMismatchSignerAndValidator = errors.New("mismatch block signer and validator")
-	ErrInvalidBlockValidator      = errors.New("invalid block validator")
+	ErrMismatchSignerAndValidator = consensus.NewInvalidError("mismatch block signer and validator")
+	ErrInvalidBlockValidator      = consensus.NewInvalidError("invalid block validator")
 	ErrTooMuchUnconfirmedBlock    = errors.New("too much unconfirmed block")
-	ErrInvalidMintBlockTime       = errors.New("invalid time to mint the block")
+	ErrInvalidMintBlockTime       = consensus.NewInvalidError("invalid time to mint the block")
 	ErrNilBlockHeader             = errors.New("nil block header returned")
 )
 var (
@@ -73,7 +73,8 @@ var (
 	bftConfirmedBlockHead = []byte("bft-confirmed-block-head")
 )
 
-type SignerFn func(utils.Address, string, []byte) ([]byte, error)
+// SignerFn signs a hash with the unlocked key of the given validator.
+type SignerFn func(utils.Address, []byte) ([]byte, error)
 type Dpos struct {
 	eventMux                *feed.TypeMux
 	chainDb                 db.Database
@@ -83,16 +84,14 @@ type Dpos struct {
 	bftConfirmedBlockHeader *types.BlockHeader
 	bftConfirmeds           *lru.Cache
 	coinbase                utils.Address
-	passphrase              string
 }
 
-func NewDpos(eventMux *feed.TypeMux, chainDb db.Database, db state.Database, signFn SignerFn, passphrase string) *Dpos {
+func NewDpos(eventMux *feed.TypeMux, chainDb db.Database, db state.Database, signFn SignerFn) *Dpos {
 	d := &Dpos{
-		eventMux:   eventMux,
-		chainDb:    chainDb,
-		db:         db,
-		signFn:     signFn,
-		passphrase: passphrase,
+		eventMux: eventMux,
+		chainDb:  chainDb,
+		db:       db,
+		signFn:   signFn,
 	}
 	return d
 }
@@ -161,7 +160,7 @@ func updateMintCnt(parentBlockTime, currentBlockTime int64, validator utils.Addr
 
 func sigHash(header *types.BlockHeader) (hash utils.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
-	rlp.Encode(hasher, []interface{}{
+	fields := []interface{}{
 		header.PreviousHash,
 		header.Miner,
 		header.StateRoot,
@@ -176,7 +175,11 @@ func sigHash(header *types.BlockHeader) (hash utils.Hash) {
 		header.ExtraData[:len(header.ExtraData)-extraSeal], // Yes, this will panic if extra is too short
 		header.Nonce,
 		header.DposContext.Root(),
-	})
+	}
+	if header.MinGasPrice != nil {
+		fields = append(fields, header.MinGasPrice)
+	}
+	rlp.Encode(hasher, fields)
 	hasher.Sum(hash[:0])
 	return hash
 }
@@ -189,7 +192,7 @@ func ecrecover(header *types.BlockHeader) (utils.Address, error) {
 	signature := header.ExtraData[len(header.ExtraData)-extraSeal:]
 	pubkey, err := crypto.EcrecoverToByte(sigHash(header).Bytes(), signature)
 	if err != nil {
-		return utils.Address{}, err
+		return utils.Address{}, consensus.NewInvalidError("invalid signature: %v", err)
 	}
 	var signer utils.Address
 	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
@@ -205,7 +208,7 @@ func (d *Dpos) Seal(chain consensus.IChainReader, block *types.Block, stop <-cha
 	block = block.WithSeal(header)
 
 	// time's up, sign the block
-	sighash, err := d.signFn(header.Miner, d.passphrase, sigHash(header).Bytes())
+	sighash, err := d.signFn(header.Miner, sigHash(header).Bytes())
 	if err != nil {
 		return nil, err
 	}
@@ -234,6 +237,19 @@ func (d *Dpos) CalcDifficulty(chain consensus.IChainReader, config *params.Chain
 	return big.NewInt((int64(time)-timeOfGenesisBlock)/int64(Option.BlockInterval) + 1)
 }
 
+// VerifySignature checks that the header is signed by its miner, whether the miner is
+// elected is left to VerifySeal or VerifyValidator.
+func (d *Dpos) VerifySignature(header *types.BlockHeader) error {
+	signer, err := ecrecover(header)
+	if err != nil {
+		return err
+	}
+	if bytes.Compare(signer.Bytes(), header.Miner.Bytes()) != 0 {
+		return ErrMismatchSignerAndValidator
+	}
+	return nil
+}
+
 func (d *Dpos) VerifySeal(chain consensus.IChainReader, header *types.BlockHeader) error {
 	if header == nil || header.Height == nil {
 		return consensus.ErrUnknownBlock
@@ -244,15 +260,10 @@ func (d *Dpos) VerifySeal(chain consensus.IChainReader, header *types.BlockHeade
 		return consensus.ErrUnknownAncestor
 	}
 
-	signer, err := ecrecover(header)
-	if err != nil {
+	if err := d.VerifySignature(header); err != nil {
 		return err
 	}
 
-	if bytes.Compare(signer.Bytes(), header.Miner.Bytes()) != 0 {
-		return ErrMismatchSignerAndValidator
-	}
-
 	epchoHeader := d.EpchoBlockHeader(chain, header.TimeStamp.Int64(), parent)
 	statedb, err := state.New(epchoHeader.StateRoot, d.db)
 	if err != nil {
@@ -273,6 +284,47 @@ func (d *Dpos) VerifySeal(chain consensus.IChainReader, header *types.BlockHeade
 	return d.updateConfirmedBlockHeader(chain, epochContext.DposContext.IsDpos())
 }
 
+// VerifyValidator checks that the header is signed by the validator elected for its
+// slot. Unlike VerifySeal it reads the epoch trie of the electing block only and does
+// not move the confirmed block, so the headers written without their state can be
+// checked. A *mtp.MissingNodeError names the epoch trie node to retrieve first.
+func (d *Dpos) VerifyValidator(chain consensus.IChainReader, header *types.BlockHeader) error {
+	if header == nil || header.Height == nil || header.TimeStamp == nil {
+		return consensus.ErrUnknownBlock
+	}
+	parent := chain.GetHeader(header.PreviousHash)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if err := d.VerifySignature(header); err != nil {
+		return err
+	}
+	epchoHeader := d.EpchoBlockHeader(chain, header.TimeStamp.Int64(), types.NewBlockWithBlockHeader(parent))
+	if epchoHeader == nil || epchoHeader.DposContext == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	epochTrie, err := types.NewEpochTrie(epchoHeader.DposContext.EpochHash, d.db.TrieDB())
+	if err != nil {
+		return err
+	}
+	validatorsRLP, err := epochTrie.TryGet([]byte("validator"))
+	if err != nil {
+		return err
+	}
+	var validators []utils.Address
+	if err := rlp.DecodeBytes(validatorsRLP, &validators); err != nil {
+		return fmt.Errorf("failed to decode validators: %s", err)
+	}
+	validator, err := lookupValidator(validators, header.TimeStamp.Int64())
+	if err != nil {
+		return err
+	}
+	if bytes.Compare(validator.Bytes(), header.Miner.Bytes()) != 0 {
+		return ErrInvalidBlockValidator
+	}
+	return nil
+}
+
 func (d *Dpos) updateConfirmedBlockHeader(chain consensus.IChainReader, dpos bool) error {
 	if d.confirmedBlockHeader == nil {
 		header, err := d.loadConfirmedBlockHeader(chain)
@@ -356,7 +408,7 @@ func (d *Dpos) storeConfirmedBlockHeader(chain consensus.IChainReader, lastBlock
 						BlockHeight: d.confirmedBlockHeader.Height.Uint64(),
 						Address:     d.coinbase,
 					}
-					if sighash, err := d.signFn(d.coinbase, d.passphrase, confirmed.Hash().Bytes()); err == nil {
+					if sighash, err := d.signFn(d.coinbase, confirmed.Hash().Bytes()); err == nil {
 						confirmed.Signature = sighash
 						d.eventMux.Post(feed.NewConfirmedEvent{Confirmed: confirmed})
 						d.bftConfirmeds.Add(d.coinbase, confirmed.BlockHeight)
@@ -423,6 +475,13 @@ func (dpos *Dpos) GetBFTConfirmedBlockNumber() (*big.Int, error) {
 	return header.Height, nil
 }
 
+// StateLookback returns the number of blocks below the head whose state the
+// engine reads, the validators of a block are elected by the state DelayEpcho
+// epochs before its epoch.
+func (d *Dpos) StateLookback() uint64 {
+	return uint64((Option.DelayEpcho + 1) * Option.BlockRepeat * Option.MaxValidatorSize)
+}
+
 func (d *Dpos) EpchoBlockHeader(chain consensus.IChainReader, timestamp int64, lastBlock *types.Block) *types.BlockHeader {
 	timestamp = timestamp - Option.DelayEpcho*Option.epochInterval()
 
@@ -431,7 +490,9 @@ func (d *Dpos) EpchoBlockHeader(chain consensus.IChainReader, timestamp int64, l
 		if header.TimeStamp.Int64() <= timestamp || header.Height.Uint64() == 0 {
 			break
 		}
-		header = chain.GetHeader(header.PreviousHash)
+		if header = chain.GetHeader(header.PreviousHash); header == nil {
+			break
+		}
 	}
 	return header
 }
@@ -503,13 +564,9 @@ func (d *Dpos) Finalize(chain consensus.IChainReader, header *types.BlockHeader,
 	if err != nil {
 		return nil, fmt.Errorf("got error when elect next epoch, err: %v", err)
 	}
-	if _, err := dposContext.CommitTo(state.Database().TrieDB()); err != nil {
-		return nil, err
-	}
+	// the dpos tries are committed to the trie database with the state when the
+	// block is written, a block which is never sealed leaves nothing behind
 	header.StateRoot = state.IntermediateRoot(true)
-	if err := state.Database().TrieDB().Commit(header.StateRoot, false); err != nil {
-		return nil, err
-	}
 	header.DposContext = dposContext.ToProto()
 	return types.NewBlock(header, txs, actions, receipts), nil
 }
@@ -28,24 +28,29 @@ type EpochContext struct {
 }
 
 func (ec *EpochContext) lookupValidator(now int64) (validator utils.Address, err error) {
-	validator = utils.Address{}
+	validators, err := ec.DposContext.GetValidators()
+	if err != nil {
+		return utils.Address{}, err
+	}
+	return lookupValidator(validators, now)
+}
+
+// lookupValidator returns the validator of the slot of now among the elected
+// validators, the zero address if the slot has none.
+func lookupValidator(validators []utils.Address, now int64) (utils.Address, error) {
 	offset := now % Option.epochInterval()
 	// if offset%Option.BlockInterval != 0 {
 	// 	return utils.Address{}, ErrInvalidMintBlockTime
 	// }
 	offset /= Option.BlockInterval * Option.BlockRepeat
 
-	validators, err := ec.DposContext.GetValidators()
-	if err != nil {
-		return utils.Address{}, err
-	}
 	validatorSize := int64(len(validators))
-
 	if validatorSize == 0 {
 		return utils.Address{}, errors.New("failed to lookup validator")
 	}
-	if !ec.DposContext.IsDpos() {
-		offset %= int64(validatorSize)
+	// a single validator mints every slot until the first election
+	if validatorSize == 1 {
+		offset %= validatorSize
 	} else if offset >= validatorSize {
 		return utils.Address{}, nil
 	}
@@ -267,3 +272,53 @@ func (p sortableAddresses) Less(i, j int) bool {
 		return p[i].address.String() < p[j].address.String()
 	}
 }
+
+// SlotStats are the block slots of a validator in an epoch.
+type SlotStats struct {
+	Minted uint64 // blocks minted according to the mint count trie
+	Missed uint64 // elapsed slots of the validator without a block
+}
+
+// SlotStats counts the slots minted and missed by each validator in the epoch
+// of ec.TimeStamp, only the slots elapsed before ec.TimeStamp are expected.
+func (ec *EpochContext) SlotStats() (map[utils.Address]*SlotStats, error) {
+	validators, err := ec.DposContext.GetValidators()
+	if err != nil {
+		return nil, err
+	}
+	epoch := ec.TimeStamp / Option.epochInterval()
+	turn := Option.BlockInterval * Option.BlockRepeat
+
+	expected := make(map[utils.Address]int64)
+	for start := epoch * Option.epochInterval(); start < ec.TimeStamp; start += turn {
+		validator, err := ec.lookupValidator(start)
+		if err != nil {
+			return nil, err
+		}
+		if (validator == utils.Address{}) {
+			continue
+		}
+		slots := (ec.TimeStamp - start) / Option.BlockInterval
+		if slots > Option.BlockRepeat {
+			slots = Option.BlockRepeat
+		}
+		expected[validator] += slots
+	}
+
+	stats := make(map[utils.Address]*SlotStats, len(validators))
+	for _, validator := range validators {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(epoch))
+		key = append(key, validator.Bytes()...)
+
+		stat := &SlotStats{}
+		if cntBytes := ec.DposContext.MintCntTrie().Get(key); cntBytes != nil {
+			stat.Minted = binary.BigEndian.Uint64(cntBytes)
+		}
+		if slots := uint64(expected[validator]); slots > stat.Minted {
+			stat.Missed = slots - stat.Minted
+		}
+		stats[validator] = stat
+	}
+	return stats, nil
+}
@@ -18,6 +18,7 @@ package consensus
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
@@ -77,5 +78,25 @@ var (
 
 	ErrFutureBlock = errors.New("block in the future")
 
-	ErrInvalidNumber = errors.New("invalid block number")
+	ErrInvalidNumber = NewInvalidError("invalid block number")
 )
+
+// InvalidError is the error of a header or a block proven invalid. Unlike the
+// errors of an unknown ancestor or a block from the future, it holds whatever the
+// state of the local chain, so the peer that sent the data can be penalised.
+type InvalidError struct {
+	msg string
+}
+
+// NewInvalidError formats an InvalidError.
+func NewInvalidError(format string, args ...interface{}) error {
+	return &InvalidError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *InvalidError) Error() string { return e.msg }
+
+// IsInvalid reports whether err proves the header or the block invalid.
+func IsInvalid(err error) bool {
+	_, ok := err.(*InvalidError)
+	return ok
+}
@@ -40,6 +40,7 @@ type Config struct {
 	CoinBaseAddr string `mapstructure:"miner-coinbase"`
 	MinerThreads int    `mapstructure:"miner-threads"`
 	ExtraData    string `mapstructure:"miner-extradata"`
+	PasswordFile string `mapstructure:"miner-passwordfile"`
 }
 
 type UMiner struct {
@@ -309,6 +310,7 @@ func (m *UMiner) generateBlock(timestamp int64) error {
 		Height:       height.Add(height, big.NewInt(1)),
 		TimeStamp:    big.NewInt(timestamp),
 		GasLimit:     calcGasLimit(parent),
+		MinGasPrice:  types.CalcMinGasPrice(m.uranus.Config(), parent.BlockHeader()),
 		Difficulty:   difficult,
 		ExtraData:    m.extraData,
 	}
@@ -322,7 +324,7 @@ func (m *UMiner) generateBlock(timestamp int64) error {
 	}
 	quit := make(chan struct{})
 	m.quitCurrentOp = quit
-	currentWork := NewWork(types.NewBlockWithBlockHeader(header), parent.Height().Uint64(), stateDB, dposContext, quit)
+	currentWork := NewWork(m.config, types.NewBlockWithBlockHeader(header), parent.Height().Uint64(), stateDB, dposContext, quit)
 
 	actions := m.uranus.Actions()
 
@@ -363,12 +365,22 @@ func (m *UMiner) generateBlock(timestamp int64) error {
 			return err
 		}
 
+		// The block hash is only known after sealing, update the logs before they are stored.
+		var logs []*types.Log
+		for _, receipt := range currentWork.receipts {
+			for _, l := range receipt.Logs {
+				l.BlockHash = result.Hash()
+				l.BlockHeight = result.Height().Uint64()
+			}
+			logs = append(logs, receipt.Logs...)
+		}
+
 		if _, err := m.uranus.WriteBlockWithState(result, currentWork.receipts, currentWork.state); err != nil {
 			return err
 		}
 
 		log.Infof("Successfully sealed new block number: %v, hash: %v, diff: %v, txs: %v, time: %v", result.Height(), result.Hash(), result.Difficulty(), len(block.Transactions()), result.Time())
-		m.uranus.PostEvent(feed.BlockAndLogsEvent{Block: result})
+		m.uranus.PostEvent(feed.BlockAndLogsEvent{Block: result, Logs: logs})
 		m.mux.Post(feed.NewMinedBlockEvent{
 			Block: result,
 		})
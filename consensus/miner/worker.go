@@ -48,13 +48,14 @@ type Work struct {
 	dposContext *types.DposContext
 }
 
-func NewWork(blk *types.Block, height uint64, state *state.StateDB, dposContext *types.DposContext, quit chan struct{}) *Work {
+func NewWork(config *params.ChainConfig, blk *types.Block, height uint64, state *state.StateDB, dposContext *types.DposContext, quit chan struct{}) *Work {
 	return &Work{
+		config:      config,
 		Block:       blk,
 		Height:      height,
 		state:       state,
 		gasUsed:     new(uint64),
-		signer:      types.Signer{},
+		signer:      types.NewSigner(config.ChainID),
 		dposContext: dposContext,
 		quit:        quit,
 	}
@@ -98,12 +99,17 @@ func (w *Work) applyTransactions(blockchain consensus.IBlockChain, txs *types.Tr
 			break
 		}
 
-		from, _ := tx.Sender(w.signer)
+		// Check whether the tx is replay protected for another chain.
+		from, err := tx.Sender(w.signer)
+		if err != nil {
+			log.Debugf("Ignoring transaction signed for another chain hash: %v", tx.Hash())
+			txs.Pop()
+			continue
+		}
 
-		// Check whether the tx is replay protected.
-		ok, err := tx.Protected(w.signer)
-		if err != nil || ok {
-			log.Debugf("Ignoring reply protected transaction hash: %v", tx.Hash())
+		// Skip the account paying under the minimum gas price of the block
+		if min := w.Block.BlockHeader().MinGasPrice; min != nil && tx.GasPrice().Cmp(min) < 0 {
+			log.Debugf("Skipping account under the minimum gas price sender: %v, price: %v, min: %v", from, tx.GasPrice(), min)
 			txs.Pop()
 			continue
 		}
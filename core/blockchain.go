@@ -37,9 +37,53 @@ import (
 	blockValidator "github.com/UranusBlockStack/uranus/core/validator"
 	"github.com/UranusBlockStack/uranus/core/vm"
 	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/UranusBlockStack/uranus/metrics"
 	"github.com/UranusBlockStack/uranus/params"
+	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
+// triesInMemory is the minimum number of recent state tries always kept in memory,
+// engines reading the state of older blocks extend it to their lookback.
+const triesInMemory = 128
+
+var (
+	blockImportTimer = metrics.NewHistogram("uranus_chain_block_import_seconds", "Time to validate, execute and write a block.", metrics.DefaultBuckets)
+	reorgDepthHist   = metrics.NewHistogram("uranus_chain_reorg_depth", "Number of blocks dropped from the canonical chain by a reorg.", []float64{1, 2, 4, 8, 16, 32, 64, 128})
+)
+
+// CacheConfig contains the configuration values for the trie caching and pruning
+// of the blockchain.
+type CacheConfig struct {
+	Archive       bool   // Whether to write every trie to disk, disabling the pruning (archive node)
+	TrieNodeLimit int    // Memory limit (MB) at which to flush the in-memory tries to disk
+	FlushInterval uint64 // Number of blocks after which to flush the confirmed in-memory tries to disk
+}
+
+// DefaultCacheConfig contains the default trie caching and pruning settings.
+var DefaultCacheConfig = &CacheConfig{
+	TrieNodeLimit: 256,
+	FlushInterval: 7200,
+}
+
+// bftConfirmer is implemented by the consensus engines whose blocks become irreversible
+// once confirmed by the validators.
+type bftConfirmer interface {
+	GetBFTConfirmedBlockNumber() (*big.Int, error)
+}
+
+// stateLookbacker is implemented by the consensus engines which verify blocks against
+// the state of older blocks, like the epoch state the dpos validators are elected by.
+type stateLookbacker interface {
+	StateLookback() uint64
+}
+
+// validatorVerifier is implemented by the consensus engines able to check the seal of
+// a header without the state of its parent, the blocks written by a fast sync are
+// verified this way.
+type validatorVerifier interface {
+	VerifyValidator(chain consensus.IChainReader, header *types.BlockHeader) error
+}
+
 // BlockChain manages chain imports, reverts, chain reorganisations.
 type BlockChain struct {
 	*ledger.Ledger
@@ -48,12 +92,16 @@ type BlockChain struct {
 	genesisBlock        *types.Block
 	currentBlock        atomic.Value
 	stateCache          state.Database // State database to reuse between imports (contains state cache)
+	cacheConfig         *CacheConfig
+	triegc              *prque.Prque // Priority queue mapping block heights to the tries to gc
+	lastWrite           uint64       // Height of the last confirmed tries flushed to disk
 	chainBlockFeed      feed.Feed
 	chainBlockscription feed.Subscription
 	validator           *blockValidator.Validator
 
 	sideBlockFeed      feed.Feed
 	SideBlockscription feed.Subscription
+	rmLogsFeed         feed.Feed
 
 	executor *exec.Executor
 	engine   consensus.Engine
@@ -64,20 +112,25 @@ type BlockChain struct {
 }
 
 // NewBlockChain returns a fully initialised block chain using information available in the database.
-func NewBlockChain(cfg *ledger.Config, chainCfg *params.ChainConfig, statedb state.Database, db db.Database, engine consensus.Engine, vmCfg *vm.Config) (*BlockChain, error) {
+func NewBlockChain(cfg *ledger.Config, cacheCfg *CacheConfig, chainCfg *params.ChainConfig, statedb state.Database, db db.Database, engine consensus.Engine, vmCfg *vm.Config) (*BlockChain, error) {
+	if cacheCfg == nil {
+		cacheCfg = DefaultCacheConfig
+	}
 	stateCache := statedb
 	ledger := ledger.New(cfg, db, func(hash utils.Hash) bool {
 		_, err := stateCache.OpenTrie(hash)
 		return err == nil
 	})
 	bc := &BlockChain{
-		config:     chainCfg,
-		vmConfig:   vmCfg,
-		stateCache: stateCache,
-		Ledger:     ledger,
-		validator:  blockValidator.New(ledger, engine),
-		engine:     engine,
-		quit:       make(chan struct{}),
+		config:      chainCfg,
+		vmConfig:    vmCfg,
+		stateCache:  stateCache,
+		cacheConfig: cacheCfg,
+		triegc:      prque.New(),
+		Ledger:      ledger,
+		validator:   blockValidator.New(ledger, engine),
+		engine:      engine,
+		quit:        make(chan struct{}),
 	}
 	bc.executor = exec.NewExecutor(chainCfg, ledger, bc, engine)
 
@@ -113,6 +166,7 @@ Head:
 	}
 
 	bc.currentBlock.Store(currentBlock)
+	bc.lastWrite = currentBlock.Height().Uint64()
 	blockTd := bc.GetTd(currentBlock.Hash())
 	log.Infof("Loaded most recent local full block number: %v,hash: %v,td: %v", currentBlock.Height(), currentBlock.Hash(), blockTd)
 	return
@@ -140,6 +194,15 @@ func (bc *BlockChain) Stop() {
 	if bc.chainBlockscription != nil {
 		bc.chainBlockscription.Unsubscribe()
 	}
+	// Make sure the state of the head block survives the restart
+	if !bc.cacheConfig.Archive {
+		bc.mu.Lock()
+		head := bc.CurrentBlock().BlockHeader()
+		if err := bc.commitTries(trieRoots(head.StateRoot, head.DposContext)); err != nil {
+			log.Errorf("Failed to commit head state tries err: %v", err)
+		}
+		bc.mu.Unlock()
+	}
 	close(bc.quit)
 	log.Info("Blockchain manager stopped")
 }
@@ -181,7 +244,7 @@ func (bc *BlockChain) InsertChain(blocks types.Blocks) (int, error) {
 
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
-	senderCacher.recoverFromBlocks(types.Signer{}, blocks)
+	senderCacher.recoverFromBlocks(types.NewSigner(bc.config.ChainID), blocks)
 	n := 0
 	for _, blk := range blocks {
 		event, _, err := bc.insertChain(blk)
@@ -198,6 +261,7 @@ func (bc *BlockChain) InsertChain(blocks types.Blocks) (int, error) {
 }
 
 func (bc *BlockChain) insertChain(block *types.Block) (interface{}, []*types.Log, error) {
+	start := time.Now()
 	err := bc.validator.ValidateHeader(bc, block.BlockHeader(), true)
 	if err == nil {
 		err = bc.validator.ValidateTxs(block)
@@ -239,14 +303,19 @@ func (bc *BlockChain) insertChain(block *types.Block) (interface{}, []*types.Log
 		return nil, nil, err
 	}
 
-	forking, err := bc.WriteBlockWithState(block, receipts, state)
+	status, err := bc.writeBlockWithState(block, receipts, state)
 	if err != nil {
 		return nil, nil, err
 	}
+	blockImportTimer.Observe(time.Since(start).Seconds())
 
-	if forking {
-		log.Infof("Inserted forked block number: %v,hash: %v,diff: %v,txs: %v,gas: %v, time: %v.", block.Height(), block.Hash(), block.Difficulty(), len(block.Transactions()), block.GasUsed(), block.Time())
+	switch status {
+	case sideStatTy:
+		log.Infof("Inserted side block number: %v,hash: %v,diff: %v,txs: %v,gas: %v, time: %v.", block.Height(), block.Hash(), block.Difficulty(), len(block.Transactions()), block.GasUsed(), block.Time())
 		return feed.ForkBlockEvent{Block: block}, logs, nil
+	case reorgStatTy:
+		log.Infof("Inserted forked block number: %v,hash: %v,diff: %v,txs: %v,gas: %v, time: %v.", block.Height(), block.Hash(), block.Difficulty(), len(block.Transactions()), block.GasUsed(), block.Time())
+		return feed.BlockAndLogsEvent{Block: block, Logs: logs}, logs, nil
 	}
 	log.Infof("Inserted new block number: %v,hash: %v, diff: %v,txs: %v,gas: %v, time: %v", block.Height(), block.Hash(), block.Difficulty(), len(block.Transactions()), block.GasUsed(), block.Time())
 	return feed.BlockAndLogsEvent{Block: block, Logs: logs}, logs, nil
@@ -297,14 +366,28 @@ func (bc *BlockChain) ExecActions(statedb *state.StateDB, actions []*types.Actio
 	bc.executor.ExecActions(statedb, actions)
 }
 
-//WriteBlockWithState write the block to the chain and get the status.
+// writeStatus is the position of a written block in the chain.
+type writeStatus byte
+
+const (
+	sideStatTy  writeStatus = iota // block stored on a side chain
+	canonStatTy                    // block extends the canonical chain
+	reorgStatTy                    // block is the head of the canonical chain after a reorg
+)
+
+// WriteBlockWithState write the block to the chain and get the status.
 func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts types.Receipts, state *state.StateDB) (bool, error) {
+	status, err := bc.writeBlockWithState(block, receipts, state)
+	return status == reorgStatTy, err
+}
+
+func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts types.Receipts, state *state.StateDB) (writeStatus, error) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 	// get the total difficulty of the block
 	ptd := bc.GetTd(block.PreviousHash())
 	if ptd == nil {
-		return false, blockValidator.ErrUnknownAncestor
+		return sideStatTy, blockValidator.ErrUnknownAncestor
 	}
 	currentBlock := bc.CurrentBlock()
 	localTd := bc.GetTd(currentBlock.Hash())
@@ -315,15 +398,25 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts types.Rec
 
 	triedb := bc.stateCache.TrieDB()
 
-	if _, err := block.DposContext.CommitTo(triedb); err != nil {
-		return false, err
+	dposContextProto, err := block.DposContext.CommitTo(triedb)
+	if err != nil {
+		return sideStatTy, err
 	}
 	root, err := state.Commit(true)
 	if err != nil {
-		return false, err
+		return sideStatTy, err
 	}
-	if err := triedb.Commit(root, false); err != nil {
-		return false, err
+	roots := trieRoots(root, dposContextProto)
+	if bc.cacheConfig.Archive {
+		if err := bc.commitTries(roots); err != nil {
+			return sideStatTy, err
+		}
+	} else {
+		// Keep the tries in memory until the block is confirmed
+		for _, root := range roots {
+			triedb.Reference(root, utils.Hash{})
+		}
+		bc.triegc.Push(roots, -float32(block.Height().Uint64()))
 	}
 
 	reorg := externTd.Cmp(localTd) > 0
@@ -332,20 +425,20 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts types.Rec
 		reorg = block.Height().Uint64() < currentBlock.Height().Uint64() || (block.Height().Uint64() == currentBlock.Height().Uint64() && rand.Float64() < 0.5)
 	}
 
-	var status bool
-
+	status := sideStatTy
 	if reorg {
+		status = canonStatTy
 		// Reorganise the chain if the parent is not the head block
 		if block.PreviousHash() != currentBlock.Hash() {
 			if err := bc.reorg(currentBlock, block); err != nil {
-				return false, err
+				return sideStatTy, err
 			}
-			status = true
+			status = reorgStatTy
 		}
 	}
 
 	bc.WriteBlockAndReceipts(block, receipts)
-	if !status && reorg {
+	if status == canonStatTy {
 		// Set new head.
 		log.Debugf("set head block number: %v,hash: %v, diff: %v,txs: %v,gas: %v, time: %v", block.Height(), block.Hash(), block.Difficulty(), len(block.Transactions()), block.GasUsed(), block.Time())
 		bc.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
@@ -353,9 +446,99 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts types.Rec
 	}
 
 	bc.RemoveFutureBlock(block.Hash())
+
+	if !bc.cacheConfig.Archive {
+		if err := bc.gcTries(block.Height().Uint64(), roots); err != nil {
+			return status, err
+		}
+	}
 	return status, nil
 }
 
+// gcTries flushes the confirmed tries to disk every FlushInterval blocks, or the tries of
+// the newest block once the memory limit is exceeded, and dereferences the tries of the
+// blocks confirmed by the bft validators, as they can't be reorganised anymore.
+func (bc *BlockChain) gcTries(current uint64, roots []utils.Hash) error {
+	triedb := bc.stateCache.TrieDB()
+	if limit := utils.StorageSize(bc.cacheConfig.TrieNodeLimit) * 1024 * 1024; triedb.Size() > limit {
+		if err := bc.commitTries(roots); err != nil {
+			return err
+		}
+	}
+	retain := bc.triesInMemory()
+	if current <= retain {
+		return nil
+	}
+	chosen := current - retain
+	if engine, ok := bc.engine.(bftConfirmer); ok {
+		confirmed, err := engine.GetBFTConfirmedBlockNumber()
+		if err != nil {
+			return err
+		}
+		if confirmed.Uint64() < chosen {
+			chosen = confirmed.Uint64()
+		}
+	}
+	if chosen >= bc.lastWrite+bc.cacheConfig.FlushInterval {
+		if block := bc.GetBlockByHeight(chosen); block != nil {
+			header := block.BlockHeader()
+			if err := bc.commitTries(trieRoots(header.StateRoot, header.DposContext)); err != nil {
+				return err
+			}
+			bc.lastWrite = chosen
+		}
+	}
+	// Garbage collect the tries of the confirmed blocks
+	for !bc.triegc.Empty() {
+		roots, height := bc.triegc.Pop()
+		if uint64(-height) > chosen {
+			bc.triegc.Push(roots, height)
+			break
+		}
+		for _, root := range roots.([]utils.Hash) {
+			triedb.Dereference(root, utils.Hash{})
+		}
+	}
+	return nil
+}
+
+// triesInMemory returns the number of recent blocks whose tries are never
+// dereferenced, it's never less than the state lookback of the engine.
+func (bc *BlockChain) triesInMemory() uint64 {
+	retain := uint64(triesInMemory)
+	if engine, ok := bc.engine.(stateLookbacker); ok && engine.StateLookback() > retain {
+		retain = engine.StateLookback()
+	}
+	return retain
+}
+
+// commitTries writes the given tries from the in-memory trie database to disk.
+func (bc *BlockChain) commitTries(roots []utils.Hash) error {
+	triedb := bc.stateCache.TrieDB()
+	for _, root := range roots {
+		if err := triedb.Commit(root, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trieRoots returns the roots of the state trie and the dpos tries of a block.
+func trieRoots(stateRoot utils.Hash, dposContext *types.DposContextProto) []utils.Hash {
+	roots := []utils.Hash{stateRoot}
+	if dposContext != nil {
+		roots = append(roots, dposContext.Roots()...)
+	}
+	// the empty hash is the root of all the references of the trie database
+	ret := roots[:0]
+	for _, root := range roots {
+		if root != (utils.Hash{}) {
+			ret = append(ret, root)
+		}
+	}
+	return ret
+}
+
 // WriteBlockWithoutState writes only the block and its metadata to the database,
 // but does not write any state.
 func (bc *BlockChain) WriteBlockWithoutState(block *types.Block, td *big.Int) error {
@@ -363,11 +546,111 @@ func (bc *BlockChain) WriteBlockWithoutState(block *types.Block, td *big.Int) er
 	return nil
 }
 
+// InsertReceiptChain writes the blocks and their receipts downloaded by the fast sync
+// to the canonical chain, without executing them nor moving the head, as the state of
+// the blocks is not available. The blocks are verified against their parent before
+// they are written, the seal only against the epoch the validators are elected by.
+func (bc *BlockChain) InsertReceiptChain(blocks types.Blocks, receipts []types.Receipts) (int, error) {
+	if len(blocks) != len(receipts) {
+		return 0, fmt.Errorf("receipts count mismatch: have %d, want %d", len(receipts), len(blocks))
+	}
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	for i, block := range blocks {
+		if i > 0 && (block.Height().Uint64() != blocks[i-1].Height().Uint64()+1 || block.PreviousHash() != blocks[i-1].Hash()) {
+			return i, fmt.Errorf("non contiguous insert: item %d is #%d [%x…], item %d is #%d [%x…] (parent [%x…])", i-1, blocks[i-1].Height().Uint64(),
+				blocks[i-1].Hash().Bytes()[:4], i, block.Height().Uint64(), block.Hash().Bytes()[:4], block.PreviousHash().Bytes()[:4])
+		}
+		if hash := types.DeriveRootHash(receipts[i]); hash != block.ReceiptsRoot() {
+			return i, consensus.NewInvalidError("invalid receipts root of block #%d: have %x, want %x", block.Height().Uint64(), hash, block.ReceiptsRoot())
+		}
+		ptd := bc.GetTd(block.PreviousHash())
+		if ptd == nil {
+			return i, blockValidator.ErrUnknownAncestor
+		}
+		if err := bc.verifyReceiptBlock(block); err != nil {
+			return i, err
+		}
+		bc.WriteBlockAndReceipts(block, receipts[i])
+		bc.WriteTd(block.Hash(), new(big.Int).Add(block.Difficulty(), ptd))
+		bc.WriteLegitimateHash(block.Height().Uint64(), block.Hash())
+	}
+	return len(blocks), nil
+}
+
+// verifyReceiptBlock checks the header and the seal of a block written without its
+// state, and that the transactions match the header. The parent must be written.
+func (bc *BlockChain) verifyReceiptBlock(block *types.Block) error {
+	header := block.BlockHeader()
+	if err := bc.validator.ValidateHeader(bc, header, false); err != nil {
+		return err
+	}
+	if engine, ok := bc.engine.(validatorVerifier); ok {
+		if err := engine.VerifyValidator(bc, header); err != nil {
+			return err
+		}
+	} else if err := bc.engine.VerifySeal(bc, header); err != nil {
+		return err
+	}
+	if root := types.DeriveRootHash(block.Transactions()); root != header.TransactionsRoot {
+		return blockValidator.ErrTxsRootHash(root, header.TransactionsRoot)
+	}
+	return nil
+}
+
+// FastSyncCommitHead sets the given block, written by InsertReceiptChain, as the head
+// of the chain once its state and dpos tries have been downloaded.
+func (bc *BlockChain) FastSyncCommitHead(hash utils.Hash) error {
+	block := bc.GetBlockByHash(hash)
+	if block == nil {
+		return fmt.Errorf("non existent block [%x…]", hash[:4])
+	}
+	if _, err := state.New(block.StateRoot(), bc.stateCache); err != nil {
+		return err
+	}
+	if _, err := types.NewDposContextFromProto(bc.stateCache.TrieDB(), block.BlockHeader().DposContext); err != nil {
+		return err
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
+	bc.currentBlock.Store(block)
+	bc.lastWrite = block.Height().Uint64()
+	log.Infof("Committed new head block number: %v,hash: %v", block.Height(), block.Hash())
+	return nil
+}
+
+// TrieNode retrieves a node of the state or dpos tries, or a contract code, by its hash.
+func (bc *BlockChain) TrieNode(hash utils.Hash) ([]byte, error) {
+	return bc.stateCache.TrieDB().Node(hash)
+}
+
+// reorg takes two blocks, an old chain and a new chain and will reconstruct the blocks and inserts them
+// to be part of the new canonical chain. It collects the logs of the dropped blocks and posts them as
+// removed logs so subscribers can roll back, the dropped blocks are posted as side blocks.
 func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	var (
 		newChain    types.Blocks
 		oldChain    types.Blocks
 		commonBlock *types.Block
+		deletedLogs []*types.Log
+		oldHead     = oldBlock.Height().Uint64()
+		// collectLogs collects the logs that were generated during the
+		// processing of the block that corresponds with the given hash.
+		collectLogs = func(block *types.Block) {
+			for _, receipt := range bc.GetReceipts(block.Hash()) {
+				for _, l := range receipt.Logs {
+					del := *l
+					del.BlockHash = block.Hash()
+					del.BlockHeight = block.Height().Uint64()
+					del.TransactionHash = receipt.TransactionHash
+					del.Removed = true
+					deletedLogs = append(deletedLogs, &del)
+				}
+			}
+		}
 	)
 
 	// first reduce whoever is higher bound
@@ -375,7 +658,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		// reduce old chain
 		for ; oldBlock != nil && oldBlock.Height().Uint64() != newBlock.Height().Uint64(); oldBlock = bc.GetBlock(oldBlock.PreviousHash()) {
 			oldChain = append(oldChain, oldBlock)
-
+			collectLogs(oldBlock)
 		}
 	} else {
 		// reduce new chain and append new chain blocks for inserting later on
@@ -398,6 +681,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 
 		oldChain = append(oldChain, oldBlock)
 		newChain = append(newChain, newBlock)
+		collectLogs(oldBlock)
 
 		oldBlock, newBlock = bc.GetBlock(oldBlock.PreviousHash()), bc.GetBlock(newBlock.PreviousHash())
 		if oldBlock == nil {
@@ -413,6 +697,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		if len(oldChain) > 63 {
 			logFn = log.Warnf
 		}
+		reorgDepthHist.Observe(float64(len(oldChain)))
 		logFn("Chain split detected height: %v, hash: %v, drop: %v, dropfrom: %v, add: %v, addfrom: %v", commonBlock.Height().Uint64(), commonBlock.Hash(), len(oldChain), oldChain[0].Hash(), len(newChain), newChain[0].Hash())
 	} else {
 		log.Errorf("Impossible reorg, please file an issue oldnum: %v, oldhash: %v, newheight: %v, newhash: %v", oldBlock.Height(), oldBlock.Hash(), newBlock.Height(), newBlock.Hash())
@@ -423,9 +708,30 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		block := newChain[i]
 		log.Debugf("set head block number: %v,hash: %v, diff: %v,txs: %v,gas: %v, time: %v", block.Height(), block.Hash(), block.Difficulty(), len(block.Transactions()), block.GasUsed(), block.Time())
 		bc.WriteLegitimateHashAndHeadBlockHash(newChain[i].Height().Uint64(), newChain[i].Hash())
+		bc.WriteTxLookups(newChain[i])
 		bc.currentBlock.Store(newChain[i])
 	}
+	// The old chain may be longer than the new one, drop its canonical hashes above the new head
+	if len(newChain) > 0 {
+		for height := newChain[0].Height().Uint64() + 1; height <= oldHead; height++ {
+			bc.DeleteLegitimateHash(height)
+		}
+	}
 
+	// The lookups of the transactions only included in the dropped blocks are not canonical anymore,
+	// the ledger doesn't return them, see Ledger.GetTransactionByHash.
+
+	// Post the removed logs and the dropped blocks for the subscribers
+	if len(deletedLogs) > 0 {
+		go bc.rmLogsFeed.Send(feed.RemovedLogsEvent{Logs: deletedLogs})
+	}
+	if len(oldChain) > 0 {
+		go func() {
+			for _, block := range oldChain {
+				bc.sideBlockFeed.Send(feed.ForkBlockEvent{Block: block})
+			}
+		}()
+	}
 	return nil
 }
 
@@ -459,12 +765,17 @@ func (bc *BlockChain) SubscribeChainBlockEvent(ch chan<- feed.BlockAndLogsEvent)
 	return bc.chainBlockscription
 }
 
-// SubscribeSideBlockEvent registers a subscription of Blockfeed.
+// SubscribeSideBlockEvent registers a subscription of ForkBlockEvent.
 func (bc *BlockChain) SubscribeSideBlockEvent(ch chan<- feed.ForkBlockEvent) feed.Subscription {
 	bc.SideBlockscription = bc.sideBlockFeed.Subscribe(ch)
 	return bc.SideBlockscription
 }
 
+// SubscribeRemovedLogsEvent registers a subscription of RemovedLogsEvent.
+func (bc *BlockChain) SubscribeRemovedLogsEvent(ch chan<- feed.RemovedLogsEvent) feed.Subscription {
+	return bc.rmLogsFeed.Subscribe(ch)
+}
+
 func (bc *BlockChain) Config() *params.ChainConfig {
 	return bc.config
 }
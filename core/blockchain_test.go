@@ -16,6 +16,138 @@
 
 package core
 
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	ldb "github.com/UranusBlockStack/uranus/common/db/leveldb"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriesInMemory(t *testing.T) {
+	bc := &BlockChain{}
+	assert.Equal(t, uint64(triesInMemory), bc.triesInMemory())
+
+	// the tries are kept for the whole epoch lookback of dpos
+	defer func(repeat, size int64) {
+		dpos.Option.BlockRepeat, dpos.Option.MaxValidatorSize = repeat, size
+	}(dpos.Option.BlockRepeat, dpos.Option.MaxValidatorSize)
+	bc.engine = &dpos.Dpos{}
+	assert.Equal(t, uint64(triesInMemory), bc.triesInMemory())
+
+	dpos.Option.BlockRepeat, dpos.Option.MaxValidatorSize = 12, 21
+	assert.Equal(t, uint64((dpos.Option.DelayEpcho+1)*12*21), bc.triesInMemory())
+}
+
+// newDposTestChain creates a chain on a fresh database with the given genesis, the
+// blocks of the chain are sealed with the key of signer. The returned function stops
+// the chain and removes its database.
+func newDposTestChain(t *testing.T, genesis *ledger.Genesis, signer *ecdsa.PrivateKey) (*BlockChain, *dpos.Dpos, func()) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	chainDb, err := ldb.New(dir, 0, 0)
+	assert.NoError(t, err)
+	_, statedb, err := genesis.Commit(ledger.NewChain(chainDb))
+	assert.NoError(t, err)
+	engine := dpos.NewDpos(new(feed.TypeMux), chainDb, statedb, func(addr utils.Address, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, signer)
+	})
+	bc, err := NewBlockChain(nil, nil, genesis.Config, statedb, chainDb, engine, &vm.Config{})
+	assert.NoError(t, err)
+	return bc, engine, func() {
+		bc.Stop()
+		chainDb.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// sealTestBlock seals an empty child of parent with the key of the engine, the parent
+// must be written to the chain.
+func sealTestBlock(t *testing.T, bc *BlockChain, engine *dpos.Dpos, miner utils.Address, parent *types.Block) *types.Block {
+	time := new(big.Int).Add(parent.Time(), big.NewInt(dpos.Option.BlockInterval))
+	header := &types.BlockHeader{
+		PreviousHash: parent.Hash(),
+		Miner:        miner,
+		StateRoot:    parent.StateRoot(),
+		DposContext:  parent.BlockHeader().DposContext,
+		Difficulty:   engine.CalcDifficulty(bc, bc.Config(), time.Uint64(), parent.BlockHeader()),
+		Height:       new(big.Int).Add(parent.Height(), big.NewInt(1)),
+		GasLimit:     parent.GasLimit(),
+		MinGasPrice:  types.CalcMinGasPrice(bc.Config(), parent.BlockHeader()),
+		TimeStamp:    time,
+	}
+	block, err := engine.Seal(bc, types.NewBlock(header, nil, nil, nil), nil, 0, nil)
+	assert.NoError(t, err)
+	return block
+}
+
+func TestInsertReceiptChain(t *testing.T) {
+	validatorKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(validatorKey.PublicKey)
+	other := crypto.PubkeyToAddress(otherKey.PublicKey)
+
+	genesis := ledger.DefaultGenesis()
+	config := *genesis.Config
+	config.GenesisCandidate = validator.Hex()
+	genesis.Config = &config
+
+	source, engine, closeSource := newDposTestChain(t, genesis, validatorKey)
+	defer closeSource()
+	parent := source.genesisBlock
+	blocks := make(types.Blocks, 3)
+	for i := range blocks {
+		blocks[i] = sealTestBlock(t, source, engine, validator, parent)
+		_, err := source.InsertReceiptChain(blocks[i:i+1], []types.Receipts{nil})
+		assert.NoError(t, err)
+		parent = blocks[i]
+	}
+
+	// a block signed by its own miner is rejected when the miner is not elected
+	_, forgerEngine, closeForger := newDposTestChain(t, genesis, otherKey)
+	defer closeForger()
+	forged := sealTestBlock(t, source, forgerEngine, other, source.genesisBlock)
+
+	chain, _, closeChain := newDposTestChain(t, genesis, validatorKey)
+	defer closeChain()
+	index, err := chain.InsertReceiptChain(types.Blocks{forged}, []types.Receipts{nil})
+	assert.Equal(t, 0, index)
+	assert.Equal(t, dpos.ErrInvalidBlockValidator, err)
+	assert.False(t, chain.HasBlock(forged.Hash()))
+
+	// the transactions must match the header
+	tx := types.NewTransaction(types.Binary, 0, big.NewInt(1), 21000, big.NewInt(1), nil, &other)
+	tampered := types.NewBlockWithBlockHeader(blocks[0].BlockHeader()).WithTxs(types.Transactions{tx})
+	index, err = chain.InsertReceiptChain(types.Blocks{tampered}, []types.Receipts{nil})
+	assert.Equal(t, 0, index)
+	assert.Error(t, err)
+	assert.False(t, chain.HasBlock(blocks[0].Hash()))
+
+	// the receipts must match the header
+	receipts := []types.Receipts{nil, {types.NewReceipt(nil, false, 0)}, nil}
+	index, err = chain.InsertReceiptChain(blocks, receipts)
+	assert.Equal(t, 1, index)
+	assert.Error(t, err)
+
+	index, err = chain.InsertReceiptChain(blocks, make([]types.Receipts, len(blocks)))
+	assert.NoError(t, err)
+	assert.Equal(t, len(blocks), index)
+	for _, block := range blocks {
+		assert.Equal(t, block.Hash(), chain.GetBlockByHeight(block.Height().Uint64()).Hash())
+	}
+	assert.Equal(t, chain.genesisBlock.Hash(), chain.CurrentBlock().Hash())
+}
+
 // func TestTheLastBlock(t *testing.T) {
 // 	cpum := cpuminer.NewCpuMiner()
 // 	_, blockchain, err := newLegitimate(cpum, 0)
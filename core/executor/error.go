@@ -26,4 +26,12 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// errNotMultisig is returned if a transaction acts on a multisig account the
+	// sender isn't.
+	errNotMultisig = errors.New("not a multisig account")
+
+	// errMultisigCollision is returned if the address of a new multisig account
+	// is already in use.
+	errMultisigCollision = errors.New("multisig account address collision")
 )
@@ -53,6 +53,9 @@ func NewEVMContext(tx *types.Transaction, bheader *types.BlockHeader, ledger *le
 		GasLimit:    bheader.GasLimit,
 		GasPrice:    new(big.Int).Set(tx.GasPrice()),
 	}
+	if bheader.MinGasPrice != nil {
+		vm.MinGasPrice = new(big.Int).Set(bheader.MinGasPrice)
+	}
 	vm.GetHash = func(n uint64) utils.Hash {
 		for header := ledger.GetHeader(bheader.PreviousHash); header != nil; header = ledger.GetHeader(header.PreviousHash) {
 			if n == header.Height.Uint64()-1 {
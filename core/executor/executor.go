@@ -76,7 +76,7 @@ func (e *Executor) ExecBlock(block *types.Block, statedb *state.StateDB, cfg vm.
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
 		_, receipt, _, err := e.ExecTransaction(nil, nil, block.DposCtx(), gp, statedb, header, tx, usedGas, cfg)
 		if err != nil {
-			return nil, nil, 0, err
+			return nil, nil, 0, consensus.NewInvalidError("transaction %d [%x]: %v", i, tx.Hash(), err)
 		}
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, receipt.Logs...)
@@ -107,6 +107,21 @@ func (e *Executor) ExecTransaction(author, txFrom *utils.Address,
 		result []byte
 	)
 
+	if txFrom == nil {
+		// the owners of a multisig account authorize its transactions together
+		if tx.MultiSignature() != nil {
+			if err := e.authorizeMultisig(statedb, tx); err != nil {
+				return nil, nil, 0, err
+			}
+		}
+		// reject transactions signed for another chain
+		from, err := tx.Sender(types.NewSigner(e.config.ChainID))
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		txFrom = &from
+	}
+
 	if tx.Type() == types.Binary {
 
 		// Create a new context to be used in the EVM environment
@@ -120,7 +135,7 @@ func (e *Executor) ExecTransaction(author, txFrom *utils.Address,
 		}
 	} else {
 		var vmerr error
-		gas, failed, vmerr = e.applyDposMessage(header.TimeStamp, dposContext, tx, statedb, gp)
+		gas, failed, vmerr = e.applyDposMessage(header.TimeStamp, dposContext, *txFrom, tx, statedb, gp)
 		if vmerr == vm.ErrInsufficientBalance {
 			return nil, nil, 0, vmerr
 		}
@@ -134,13 +149,7 @@ func (e *Executor) ExecTransaction(author, txFrom *utils.Address,
 	receipt.GasUsed = gas
 	// create contract
 	if tx.Tos() == nil {
-		var from utils.Address
-		if txFrom == nil {
-			from, _ = tx.Sender(types.Signer{})
-		} else {
-			from = *txFrom
-		}
-		receipt.ContractAddress = crypto.CreateAddress(from, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(*txFrom, tx.Nonce())
 	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = statedb.GetLogs(tx.Hash())
@@ -148,9 +157,8 @@ func (e *Executor) ExecTransaction(author, txFrom *utils.Address,
 	return result, receipt, gas, err
 }
 
-func (e *Executor) applyDposMessage(timestamp *big.Int, dposContext *types.DposContext, tx *types.Transaction, statedb *state.StateDB, gp *utils.GasPool) (uint64, bool, error) {
-	gas, _ := txpool.IntrinsicGas(tx.Payload(), tx.Type(), false)
-	from, _ := tx.Sender(types.Signer{})
+func (e *Executor) applyDposMessage(timestamp *big.Int, dposContext *types.DposContext, from utils.Address, tx *types.Transaction, statedb *state.StateDB, gp *utils.GasPool) (uint64, bool, error) {
+	gas, _ := txpool.IntrinsicGas(tx.Payload(), tx.Type(), false, len(tx.Tos()), tx.OwnerSignatures())
 	feeval := new(big.Int).Mul(new(big.Int).SetUint64(gas), tx.GasPrice())
 	if statedb.GetBalance(from).Cmp(feeval) < 0 {
 		return gas, true, errInsufficientBalanceForGas
@@ -217,6 +225,11 @@ func (e *Executor) applyDposMessage(timestamp *big.Int, dposContext *types.DposC
 				return gas, true, err
 			}
 		}
+	case types.Multisig:
+		if err := e.applyMultisig(from, tx, statedb); err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			return gas, true, err
+		}
 	case types.Redeem:
 		ttimestamp := statedb.GetUnDelegateTimestamp(from).Int64()
 		tt := time.Unix(ttimestamp/int64(time.Second), ttimestamp%int64(time.Second))
@@ -230,6 +243,47 @@ func (e *Executor) applyDposMessage(timestamp *big.Int, dposContext *types.DposC
 	return gas, false, nil
 }
 
+// authorizeMultisig checks the transaction carries the signatures of enough
+// owners of the multisig account sending it.
+func (e *Executor) authorizeMultisig(statedb *state.StateDB, tx *types.Transaction) error {
+	owners, err := types.NewSigner(e.config.ChainID).Owners(tx)
+	if err != nil {
+		return err
+	}
+	multisig := statedb.GetMultisig(tx.MultiSignature().Account)
+	if multisig == nil {
+		return errNotMultisig
+	}
+	return multisig.Authorize(owners)
+}
+
+// applyMultisig creates a multisig account funded with the value of the
+// transaction, or updates the owners of the multisig account sending it.
+func (e *Executor) applyMultisig(from utils.Address, tx *types.Transaction, statedb *state.StateDB) error {
+	multisig, err := state.DecodeMultisig(tx.Payload())
+	if err != nil {
+		return err
+	}
+	if len(tx.Tos()) == 0 {
+		account := crypto.CreateAddress(from, tx.Nonce())
+		if statedb.GetNonce(account) != 0 || len(statedb.GetCode(account)) > 0 || statedb.GetMultisig(account) != nil {
+			return errMultisigCollision
+		}
+		if statedb.GetBalance(from).Cmp(tx.Value()) < 0 {
+			return vm.ErrInsufficientBalance
+		}
+		statedb.SubBalance(from, tx.Value())
+		statedb.AddBalance(account, tx.Value())
+		statedb.SetMultisig(account, multisig)
+		return nil
+	}
+	if *tx.Tos()[0] != from || statedb.GetMultisig(from) == nil {
+		return errNotMultisig
+	}
+	statedb.SetMultisig(from, multisig)
+	return nil
+}
+
 func (e *Executor) addAction(sender utils.Address, tx *types.Transaction) {
 	a := types.NewAction(tx.Hash(), sender, big.NewInt(time.Now().Unix()), big.NewInt(e.chain.Config().DelayDuration))
 	e.tp.AddAction(a)
@@ -0,0 +1,151 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	mdb "github.com/UranusBlockStack/uranus/common/db/memorydb"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/txpool"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testKey, _  = crypto.GenerateKey()
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+	testMiner   = utils.HexToAddress("0x0a")
+	testTos     = []*utils.Address{{0x01}, {0x02}, {0x03}}
+)
+
+// execTestTx signs tx with the test key and executes it in a block at height, on a state
+// funding the test address with balance and set up further by prepare if given.
+func execTestTx(t *testing.T, config *params.ChainConfig, height int64, balance int64, tx *types.Transaction, prepare func(*state.StateDB)) (*types.Receipt, *state.StateDB, error) {
+	statedb, err := state.New(utils.Hash{}, state.NewDatabase(mdb.New()))
+	assert.NoError(t, err)
+	statedb.AddBalance(testAddress, big.NewInt(balance))
+	if prepare != nil {
+		prepare(statedb)
+	}
+
+	assert.NoError(t, tx.SignTx(types.NewSigner(config.ChainID), testKey))
+	header := &types.BlockHeader{
+		Miner:      testMiner,
+		Difficulty: big.NewInt(1),
+		Height:     big.NewInt(height),
+		GasLimit:   1000000,
+		TimeStamp:  big.NewInt(1),
+	}
+	statedb.Prepare(tx.Hash(), utils.Hash{}, 0)
+	_, receipt, _, err := NewExecutor(config, nil, nil, nil).ExecTransaction(nil, nil, nil, new(utils.GasPool).AddGas(header.GasLimit), statedb, header, tx, new(uint64), vm.Config{})
+	return receipt, statedb, err
+}
+
+func TestExecTransfer(t *testing.T) {
+	tx := types.NewTransaction(types.Binary, 0, big.NewInt(100), 21000, big.NewInt(1), nil, testTos[0])
+	receipt, statedb, err := execTestTx(t, params.TestChainConfig, 1, 1000000, tx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, params.TxGas, receipt.GasUsed)
+	assert.Empty(t, receipt.Logs)
+	assert.Equal(t, big.NewInt(100), statedb.GetBalance(*testTos[0]))
+	assert.Equal(t, big.NewInt(1000000-100-int64(params.TxGas)), statedb.GetBalance(testAddress))
+	assert.Equal(t, uint64(1), statedb.GetNonce(testAddress))
+}
+
+func TestExecBatchPayment(t *testing.T) {
+	// an empty payload splits the value evenly
+	tx := types.NewTransaction(types.Binary, 0, big.NewInt(300), 100000, big.NewInt(1), nil, testTos...)
+	receipt, statedb, err := execTestTx(t, params.TestChainConfig, 1, 1000000, tx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	gas := params.TxGas + 2*params.TxRecipientGas
+	assert.Equal(t, gas, receipt.GasUsed)
+	assert.Equal(t, big.NewInt(1000000-300-int64(gas)), statedb.GetBalance(testAddress))
+	assert.Equal(t, big.NewInt(int64(gas)), statedb.GetBalance(testMiner))
+
+	// every transfer is logged
+	assert.Len(t, receipt.Logs, len(testTos))
+	for i, to := range testTos {
+		assert.Equal(t, big.NewInt(100), statedb.GetBalance(*to))
+		log := receipt.Logs[i]
+		assert.Equal(t, tx.Hash(), log.TransactionHash)
+		assert.Equal(t, []utils.Hash{types.TransferEventTopic, testAddress.Hash(), to.Hash()}, log.Topics)
+		assert.Equal(t, big.NewInt(100), new(big.Int).SetBytes(log.Data))
+		assert.True(t, receipt.LogsBloom.Test(to.Hash()))
+	}
+
+	// the payload lists the amounts of the recipients, its bytes are paid for
+	payload, err := types.EncodePaymentAmounts([]*big.Int{big.NewInt(1), big.NewInt(20), big.NewInt(300)})
+	assert.NoError(t, err)
+	tx = types.NewTransaction(types.Binary, 0, big.NewInt(321), 100000, big.NewInt(1), payload, testTos...)
+	receipt, statedb, err = execTestTx(t, params.TestChainConfig, 1, 1000000, tx, nil)
+	assert.NoError(t, err)
+	gas, err = txpool.IntrinsicGas(payload, types.Binary, false, len(testTos), 0)
+	assert.NoError(t, err)
+	assert.True(t, gas > params.TxGas+2*params.TxRecipientGas)
+	assert.Equal(t, gas, receipt.GasUsed)
+	assert.Equal(t, big.NewInt(1), statedb.GetBalance(*testTos[0]))
+	assert.Equal(t, big.NewInt(20), statedb.GetBalance(*testTos[1]))
+	assert.Equal(t, big.NewInt(300), statedb.GetBalance(*testTos[2]))
+	assert.Len(t, receipt.Logs, len(testTos))
+}
+
+func TestExecBatchPaymentReverted(t *testing.T) {
+	// the sender can not afford the value
+	tx := types.NewTransaction(types.Binary, 0, big.NewInt(300), 100000, big.NewInt(1), nil, testTos...)
+	_, _, err := execTestTx(t, params.TestChainConfig, 1, 100100, tx, nil)
+	assert.Equal(t, vm.ErrInsufficientBalance, err)
+
+	// a recipient reverting fails the payment as a whole, the gas is paid
+	revert := func(statedb *state.StateDB) {
+		statedb.SetCode(*testTos[1], []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)})
+	}
+	tx = types.NewTransaction(types.Binary, 0, big.NewInt(300), 100000, big.NewInt(1), nil, testTos...)
+	receipt, statedb, err := execTestTx(t, params.TestChainConfig, 1, 1000000, tx, revert)
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusFailed, receipt.Status)
+	assert.Empty(t, receipt.Logs)
+	for _, to := range testTos {
+		assert.Equal(t, new(big.Int), statedb.GetBalance(*to))
+	}
+	assert.Equal(t, big.NewInt(1000000-int64(receipt.GasUsed)), statedb.GetBalance(testAddress))
+	assert.Equal(t, uint64(1), statedb.GetNonce(testAddress))
+}
+
+func TestExecBatchPaymentFork(t *testing.T) {
+	config := *params.TestChainConfig
+	config.BatchPaymentBlock = big.NewInt(10)
+
+	tx := types.NewTransaction(types.Binary, 0, big.NewInt(300), 100000, big.NewInt(1), nil, testTos...)
+	_, statedb, err := execTestTx(t, &config, 9, 1000000, tx, nil)
+	assert.Equal(t, types.ErrBatchPaymentDisabled, err)
+	assert.Equal(t, big.NewInt(1000000), statedb.GetBalance(testAddress))
+	assert.Equal(t, uint64(0), statedb.GetNonce(testAddress))
+
+	tx = types.NewTransaction(types.Binary, 0, big.NewInt(300), 100000, big.NewInt(1), nil, testTos...)
+	receipt, _, err := execTestTx(t, &config, 10, 1000000, tx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
@@ -127,6 +127,9 @@ func (st *StateTransition) preCheck() error {
 
 // TransitionDb will transition the state by applying the current message and returning the result including the the used gas.
 func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bool, err error) {
+	if st.tx.IsBatchPayment() && !st.evm.ChainConfig().IsBatchPayment(st.evm.BlockNumber) {
+		return nil, 0, false, types.ErrBatchPaymentDisabled
+	}
 	if err = st.preCheck(); err != nil {
 		return
 	}
@@ -134,7 +137,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	contractCreation := st.tx.Tos() == nil
 
 	// Pay intrinsic gas
-	gas, err := txpool.IntrinsicGas(st.data, st.tx.Type(), contractCreation)
+	gas, err := txpool.IntrinsicGas(st.data, st.tx.Type(), contractCreation, len(st.tos()), st.tx.OwnerSignatures())
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -151,7 +154,11 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	} else {
 		// Increment the nonce for the next transaction
 		st.state.SetNonce(st.from, st.state.GetNonce(sender.Address())+1)
-		ret, st.gas, vmerr = evm.Call(sender, *st.tos()[0], st.data, st.gas, st.value)
+		if st.tx.IsBatchPayment() {
+			vmerr = st.batchPay(sender)
+		} else {
+			ret, st.gas, vmerr = evm.Call(sender, *st.tos()[0], st.data, st.gas, st.value)
+		}
 	}
 	if vmerr != nil {
 		log.Debugf("VM returned with err: %v ", vmerr)
@@ -160,11 +167,29 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		}
 	}
 	st.refundGas()
-	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.tipPrice()))
 
 	return ret, st.gasUsed(), vmerr != nil, err
 }
 
+// batchPay transfers the amounts of a batch payment to every recipient and logs
+// each transfer, the payment is reverted as a whole if any of them fails.
+func (st *StateTransition) batchPay(sender vm.AccountRef) (err error) {
+	amounts, err := st.tx.PaymentAmounts()
+	if err != nil {
+		return err
+	}
+	snapshot := st.state.Snapshot()
+	for i, to := range st.tos() {
+		if _, st.gas, err = st.evm.Call(sender, *to, nil, st.gas, amounts[i]); err != nil {
+			st.state.RevertToSnapshot(snapshot)
+			return err
+		}
+		st.state.AddLog(types.NewTransferLog(st.from, *to, amounts[i]))
+	}
+	return nil
+}
+
 func (st *StateTransition) refundGas() {
 	// Apply refund counter, capped to half of the used gas.
 	refund := st.gasUsed() / 2
@@ -179,6 +204,19 @@ func (st *StateTransition) refundGas() {
 	st.gp.AddGas(st.gas)
 }
 
+// tipPrice returns the part of the gas price paid to the validator, the minimum
+// gas price of the block is burned if the chain is configured so.
+func (st *StateTransition) tipPrice() *big.Int {
+	min := st.evm.MinGasPrice
+	if min == nil || !st.evm.ChainConfig().BurnGasFee {
+		return st.gasPrice
+	}
+	if st.gasPrice.Cmp(min) <= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Sub(st.gasPrice, min)
+}
+
 // gasUsed returns the amount of gas used up by the state transition.
 func (st *StateTransition) gasUsed() uint64 {
 	return st.initialGas - st.gas
@@ -139,7 +139,8 @@ func (g *Genesis) ToBlock(chain *Chain) (*types.Block, state.Database) {
 	dposContext.CandidateTrie().TryUpdate(validator.Bytes(), val)
 
 	triedb := statedb.Database().TrieDB()
-	if _, err := dposContext.CommitTo(triedb); err != nil {
+	dposContextProto, err := dposContext.CommitTo(triedb)
+	if err != nil {
 		panic(err)
 	}
 	root, err := statedb.Commit(false)
@@ -147,11 +148,12 @@ func (g *Genesis) ToBlock(chain *Chain) (*types.Block, state.Database) {
 		panic(err)
 	}
 
-	if err := triedb.Commit(root, false); err != nil {
-		panic(err)
+	for _, root := range append(dposContextProto.Roots(), root) {
+		if err := triedb.Commit(root, false); err != nil {
+			panic(err)
+		}
 	}
 
-	dposContextProto := dposContext.ToProto()
 	head := &types.BlockHeader{
 		Height:       new(big.Int).SetUint64(g.Height),
 		Nonce:        types.EncodeNonce(g.Nonce),
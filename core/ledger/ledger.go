@@ -98,6 +98,11 @@ func (l *Ledger) WriteLegitimateHashAndHeadBlockHash(height uint64, hash utils.H
 	l.chain.putHeadBlockHash(hash)
 }
 
+// WriteLegitimateHash writes the canonical hash of the given height without moving the head.
+func (l *Ledger) WriteLegitimateHash(height uint64, hash utils.Hash) {
+	l.chain.putLegitimateHash(height, hash)
+}
+
 func (l *Ledger) WriteBlockAndReceipts(block *types.Block, receipts types.Receipts) {
 	l.chain.putBlock(block)
 	l.chain.putReceipts(block.Hash(), receipts)
@@ -119,8 +124,12 @@ func (l *Ledger) GetReceipts(blockHash utils.Hash) types.Receipts {
 	return l.chain.getReceipts(blockHash)
 }
 
-// GetReceipt return Receipt by transaction hash.
+// GetReceipt return Receipt by transaction hash, receipts of transactions
+// dropped by a reorg are not returned.
 func (l *Ledger) GetReceipt(txHash utils.Hash) *types.Receipt {
+	if l.GetTransactionByHash(txHash) == nil {
+		return nil
+	}
 	return l.chain.getReceipt(txHash)
 }
 
@@ -227,6 +236,23 @@ func (l *Ledger) GetBlockByHeight(height uint64) *types.Block {
 }
 
 // GetTransactionByHash retrieves a transaction from the database by hash, caching it if found.
+// Transactions of blocks dropped by a reorg are not returned.
 func (l *Ledger) GetTransactionByHash(txHash utils.Hash) *types.StorageTx {
-	return l.chain.getTransaction(txHash)
+	stx := l.chain.getTransaction(txHash)
+	if stx == nil || l.chain.getLegitimateHash(stx.BlockHeight) != stx.BlockHash {
+		return nil
+	}
+	return stx
+}
+
+// WriteTxLookups points the transaction lookups to the given canonical block.
+// The transactions of a block are also stored under their hash, so the entries
+// of a dropped block are rewritten instead of deleted, as they make its body.
+func (l *Ledger) WriteTxLookups(block *types.Block) {
+	l.chain.putTransactions(block.Hash(), block.Height().Uint64(), block.Transactions())
+}
+
+// DeleteLegitimateHash removes the canonical hash of the given height.
+func (l *Ledger) DeleteLegitimateHash(height uint64) {
+	l.chain.deleteLegitimateHash(height)
 }
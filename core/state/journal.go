@@ -126,6 +126,11 @@ type (
 		prev    *big.Int
 	}
 
+	multisigChange struct {
+		account *utils.Address
+		prev    *Multisig
+	}
+
 	unlockedBalanceChange struct {
 		account *utils.Address
 		prev    *big.Int
@@ -222,6 +227,14 @@ func (ch lockedBalanceChange) dirtied() *utils.Address {
 	return ch.account
 }
 
+func (ch multisigChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setMultisig(ch.prev)
+}
+
+func (ch multisigChange) dirtied() *utils.Address {
+	return ch.account
+}
+
 func (ch unlockedBalanceChange) revert(s *StateDB) {
 	s.getStateObject(*ch.account).setUnLockedBalance(ch.prev)
 }
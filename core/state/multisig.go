@@ -0,0 +1,104 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+var (
+	// ErrInvalidMultisig is returned if the owners or the threshold of a multisig account are invalid.
+	ErrInvalidMultisig = errors.New("invalid multisig owners or threshold")
+	// ErrUnauthorizedMultisig is returned if a transaction lacks the signatures of enough owners.
+	ErrUnauthorizedMultisig = errors.New("multisig threshold not reached by the owner signatures")
+)
+
+// Multisig is the owner set and the threshold of a multisig account, the
+// transactions sent from it need the signatures of at least threshold owners.
+type Multisig struct {
+	Owners    []utils.Address `json:"owners"`
+	Threshold uint64          `json:"threshold"`
+}
+
+// DecodeMultisig decodes the owners and the threshold of a multisig account from
+// the payload of a transaction and validates them.
+func DecodeMultisig(payload []byte) (*Multisig, error) {
+	multisig := new(Multisig)
+	if err := rlp.DecodeBytes(payload, multisig); err != nil {
+		return nil, ErrInvalidMultisig
+	}
+	if err := multisig.Validate(); err != nil {
+		return nil, err
+	}
+	return multisig, nil
+}
+
+// Encode encodes the multisig into the payload of a transaction.
+func (m *Multisig) Encode() ([]byte, error) {
+	return rlp.EncodeToBytes(m)
+}
+
+// Validate checks the owners are distinct and the threshold reachable.
+func (m *Multisig) Validate() error {
+	if len(m.Owners) == 0 || len(m.Owners) > params.MaxMultisigOwners {
+		return fmt.Errorf("%v: owners must be between 1 and %v", ErrInvalidMultisig, params.MaxMultisigOwners)
+	}
+	if m.Threshold == 0 || m.Threshold > uint64(len(m.Owners)) {
+		return fmt.Errorf("%v: threshold must be between 1 and %v", ErrInvalidMultisig, len(m.Owners))
+	}
+	seen := make(map[utils.Address]bool)
+	for _, owner := range m.Owners {
+		if seen[owner] {
+			return fmt.Errorf("%v: duplicate owner %v", ErrInvalidMultisig, owner.Hex())
+		}
+		seen[owner] = true
+	}
+	return nil
+}
+
+// Authorize checks the distinct signers are owners reaching the threshold.
+func (m *Multisig) Authorize(signers []utils.Address) error {
+	owners := make(map[utils.Address]bool)
+	for _, owner := range m.Owners {
+		owners[owner] = true
+	}
+	approvals := uint64(0)
+	for _, signer := range signers {
+		if !owners[signer] {
+			return fmt.Errorf("%v: %v isn't an owner", ErrUnauthorizedMultisig, signer.Hex())
+		}
+		delete(owners, signer)
+		approvals++
+	}
+	if approvals < m.Threshold {
+		return fmt.Errorf("%v: have %v, want %v", ErrUnauthorizedMultisig, approvals, m.Threshold)
+	}
+	return nil
+}
+
+// Copy returns a deep copy of the multisig.
+func (m *Multisig) Copy() *Multisig {
+	return &Multisig{
+		Owners:    append([]utils.Address(nil), m.Owners...),
+		Threshold: m.Threshold,
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	mdb "github.com/UranusBlockStack/uranus/common/db/memorydb"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+func TestMultisigValidateAndAuthorize(t *testing.T) {
+	a, b, c := utils.HexToAddress("0x01"), utils.HexToAddress("0x02"), utils.HexToAddress("0x03")
+
+	invalid := []*Multisig{
+		{Threshold: 1},
+		{Owners: []utils.Address{a, b}, Threshold: 0},
+		{Owners: []utils.Address{a, b}, Threshold: 3},
+		{Owners: []utils.Address{a, a}, Threshold: 1},
+	}
+	for i, multisig := range invalid {
+		if err := multisig.Validate(); err == nil {
+			t.Errorf("test %d: invalid multisig accepted", i)
+		}
+	}
+
+	multisig := &Multisig{Owners: []utils.Address{a, b, c}, Threshold: 2}
+	if err := multisig.Validate(); err != nil {
+		t.Fatalf("valid multisig rejected: %v", err)
+	}
+	if err := multisig.Authorize([]utils.Address{c, a}); err != nil {
+		t.Errorf("threshold reached but unauthorized: %v", err)
+	}
+	if err := multisig.Authorize([]utils.Address{a}); err == nil {
+		t.Errorf("authorized under the threshold")
+	}
+	if err := multisig.Authorize([]utils.Address{a, utils.HexToAddress("0x04")}); err == nil {
+		t.Errorf("authorized by a stranger")
+	}
+	if err := multisig.Authorize([]utils.Address{a, a}); err == nil {
+		t.Errorf("authorized by an owner twice")
+	}
+}
+
+func TestMultisigAccount(t *testing.T) {
+	db := NewDatabase(mdb.New())
+	statedb, _ := New(utils.Hash{}, db)
+	account := utils.HexToAddress("0xaa")
+	multisig := &Multisig{Owners: []utils.Address{utils.HexToAddress("0x01"), utils.HexToAddress("0x02")}, Threshold: 2}
+
+	// the changes of the owners are reverted along the snapshots
+	statedb.SetMultisig(account, multisig)
+	snapshot := statedb.Snapshot()
+	statedb.SetMultisig(account, &Multisig{Owners: multisig.Owners, Threshold: 1})
+	statedb.RevertToSnapshot(snapshot)
+	if have := statedb.GetMultisig(account); have == nil || have.Threshold != 2 {
+		t.Fatalf("multisig not reverted: %v", have)
+	}
+
+	// the multisig account isn't empty without balance and survives the commit
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.TrieDB().Commit(root, false)
+	statedb, _ = New(root, db)
+	have := statedb.GetMultisig(account)
+	if have == nil || have.Threshold != 2 || len(have.Owners) != 2 || have.Owners[1] != multisig.Owners[1] {
+		t.Fatalf("multisig mismatch after commit: have %v, want %v", have, multisig)
+	}
+	if plain := statedb.GetMultisig(utils.HexToAddress("0xbb")); plain != nil {
+		t.Errorf("single key account with owners: %v", plain)
+	}
+}
+
+func TestMultisigAccountEncoding(t *testing.T) {
+	multisig := &Multisig{Owners: []utils.Address{utils.HexToAddress("0x01")}, Threshold: 1}
+	zero := new(big.Int)
+	fields := []interface{}{zero, zero, zero, zero, uint64(0), zero, utils.Hash{}, emptyCodeHash}
+
+	// the owners are omitted for the accounts controlled by a single key
+	enc, err := rlp.EncodeToBytes(&Account{zero, zero, zero, zero, 0, zero, utils.Hash{}, emptyCodeHash, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, _ := rlp.EncodeToBytes(fields); string(enc) != string(plain) {
+		t.Errorf("single key account encoding mismatch: have %x, want %x", enc, plain)
+	}
+
+	var account Account
+	enc, _ = rlp.EncodeToBytes(append(fields, multisig))
+	if err := rlp.DecodeBytes(enc, &account); err != nil || account.Multisig == nil || account.Multisig.Threshold != 1 {
+		t.Fatalf("multisig account decoding failed: %v %v", account.Multisig, err)
+	}
+	enc, _ = rlp.EncodeToBytes(append(fields, multisig, multisig))
+	if err := rlp.DecodeBytes(enc, &account); err == nil {
+		t.Errorf("account with two owner sets accepted")
+	}
+}
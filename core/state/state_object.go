@@ -90,7 +90,7 @@ type stateObject struct {
 
 // empty returns whether the account is considered empty.
 func (s *stateObject) empty() bool {
-	return s.data.Nonce == 0 && s.data.Balance.Sign() == 0 && bytes.Equal(s.data.CodeHash, emptyCodeHash)
+	return s.data.Nonce == 0 && s.data.Balance.Sign() == 0 && bytes.Equal(s.data.CodeHash, emptyCodeHash) && s.data.Multisig == nil
 }
 
 // Account is the Ethereum consensus representation of accounts.
@@ -107,6 +107,10 @@ type Account struct {
 	Balance  *big.Int
 	Root     utils.Hash // merkle root of the storage trie
 	CodeHash []byte
+
+	// Multisig holds the owners of a multisig account, it's nil for the
+	// accounts controlled by a single key.
+	Multisig *Multisig `rlp:"optional"`
 }
 
 // newObject creates a state object.
@@ -296,6 +300,22 @@ func (s *stateObject) setLockedBalance(amount *big.Int) {
 	s.data.LockedBalance = amount
 }
 
+func (s *stateObject) SetMultisig(multisig *Multisig) {
+	s.db.journal.append(multisigChange{
+		account: &s.address,
+		prev:    s.data.Multisig,
+	})
+	s.setMultisig(multisig)
+}
+
+func (s *stateObject) setMultisig(multisig *Multisig) {
+	if multisig == nil {
+		s.data.Multisig = nil
+		return
+	}
+	s.data.Multisig = multisig.Copy()
+}
+
 func (s *stateObject) SetUnLockedBalance(amount *big.Int) {
 	s.db.journal.append(unlockedBalanceChange{
 		account: &s.address,
@@ -414,6 +434,13 @@ func (s *stateObject) Nonce() uint64 {
 	return s.data.Nonce
 }
 
+func (s *stateObject) Multisig() *Multisig {
+	if s.data.Multisig == nil {
+		return nil
+	}
+	return s.data.Multisig.Copy()
+}
+
 func (s *stateObject) UnLockedBalance() *big.Int {
 	return s.data.UnLockedBalance
 }
@@ -250,6 +250,16 @@ func (s *StateDB) GetLockedBalance(addr utils.Address) *big.Int {
 	return utils.Big0
 }
 
+// GetMultisig returns the owners of the multisig account, nil if the account is
+// controlled by a single key.
+func (s *StateDB) GetMultisig(addr utils.Address) *Multisig {
+	stateObject := s.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.Multisig()
+	}
+	return nil
+}
+
 func (s *StateDB) GetDelegateTimestamp(addr utils.Address) *big.Int {
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
@@ -290,6 +300,43 @@ func (s *StateDB) StorageTrie(addr utils.Address) Trie {
 	return cpy.updateTrie(s.db)
 }
 
+// GetStorageRoot returns the root hash of the storage trie of the account.
+func (s *StateDB) GetStorageRoot(addr utils.Address) utils.Hash {
+	stateObject := s.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.data.Root
+	}
+	return utils.Hash{}
+}
+
+// proofList collects the nodes of a merkle proof in path order.
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// GetProof returns the merkle proof of the account in the state trie, the
+// account is keyed by the hash of its address.
+func (s *StateDB) GetProof(addr utils.Address) ([][]byte, error) {
+	var proof proofList
+	err := s.trie.Prove(crypto.Keccak256(addr.Bytes()), 0, &proof)
+	return proof, err
+}
+
+// GetStorageProof returns the merkle proof of the storage slot in the storage
+// trie of the account, the slot is keyed by the hash of its key.
+func (s *StateDB) GetStorageProof(addr utils.Address, key utils.Hash) ([][]byte, error) {
+	var proof proofList
+	trie := s.StorageTrie(addr)
+	if trie == nil {
+		return proof, nil
+	}
+	err := trie.Prove(crypto.Keccak256(key.Bytes()), 0, &proof)
+	return proof, err
+}
+
 func (s *StateDB) HasSuicided(addr utils.Address) bool {
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
@@ -353,6 +400,14 @@ func (s *StateDB) SetLockedBalance(addr utils.Address, amount *big.Int) {
 	}
 }
 
+// SetMultisig sets the owners of the multisig account.
+func (s *StateDB) SetMultisig(addr utils.Address, multisig *Multisig) {
+	stateObject := s.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetMultisig(multisig)
+	}
+}
+
 func (s *StateDB) SetUnLockedBalance(addr utils.Address, amount *big.Int) {
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
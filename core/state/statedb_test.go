@@ -29,7 +29,10 @@ import (
 	"testing/quick"
 	"time"
 
+	"github.com/UranusBlockStack/uranus/common/crypto"
 	mdb "github.com/UranusBlockStack/uranus/common/db/memorydb"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/types"
 	check "gopkg.in/check.v1"
@@ -481,3 +484,57 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+func TestGetProof(t *testing.T) {
+	sdb, _ := New(utils.Hash{}, NewDatabase(mdb.New()))
+	addr := utils.HexToAddress("aaaa")
+	key, value := utils.HexToHash("01"), utils.HexToHash("0102")
+	sdb.SetBalance(addr, big.NewInt(42))
+	sdb.SetState(addr, key, value)
+	for i := byte(0); i < 100; i++ {
+		sdb.SetBalance(utils.BytesToAddress([]byte{i}), big.NewInt(int64(i)))
+	}
+	root, _ := sdb.Commit(false)
+	sdb, _ = New(root, sdb.Database())
+
+	// load the proof nodes keyed by their hash
+	proofDb := func(proof [][]byte) *mdb.Database {
+		db := mdb.New()
+		for _, node := range proof {
+			db.Put(crypto.Keccak256(node), node)
+		}
+		return db
+	}
+
+	proof, err := sdb.GetProof(addr)
+	if err != nil {
+		t.Fatalf("failed to get account proof: %v", err)
+	}
+	enc, err := mtp.VerifyProof(root, crypto.Keccak256(addr.Bytes()), proofDb(proof))
+	if err != nil {
+		t.Fatalf("failed to verify account proof: %v", err)
+	}
+	var account Account
+	if err := rlp.DecodeBytes(enc, &account); err != nil {
+		t.Fatalf("failed to decode account: %v", err)
+	}
+	if account.Balance.Int64() != 42 || account.Root != sdb.GetStorageRoot(addr) {
+		t.Fatalf("unexpected account %+v", account)
+	}
+
+	proof, err = sdb.GetStorageProof(addr, key)
+	if err != nil {
+		t.Fatalf("failed to get storage proof: %v", err)
+	}
+	enc, err = mtp.VerifyProof(account.Root, crypto.Keccak256(key.Bytes()), proofDb(proof))
+	if err != nil {
+		t.Fatalf("failed to verify storage proof: %v", err)
+	}
+	var stored []byte
+	if err := rlp.DecodeBytes(enc, &stored); err != nil {
+		t.Fatalf("failed to decode storage value: %v", err)
+	}
+	if utils.BytesToHash(stored) != value {
+		t.Fatalf("unexpected storage value %x", stored)
+	}
+}
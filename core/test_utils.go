@@ -54,7 +54,7 @@ func newLegitimate(engine consensus.Engine, n int) (db.Database, *BlockChain, er
 		return ldb, nil, err
 	}
 
-	bc, err := NewBlockChain(nil, params.TestChainConfig, statedb, ldb, engine, &vm.Config{})
+	bc, err := NewBlockChain(nil, nil, params.TestChainConfig, statedb, ldb, engine, &vm.Config{})
 	if err != nil {
 		return ldb, bc, err
 	}
@@ -100,7 +100,7 @@ func generateChainBlocks(config *params.ChainConfig, parent *types.Block, engine
 	blocks, receipts := make(types.Blocks, n), make([]types.Receipts, n)
 	genblock := func(i int, parent *types.Block, statedb *state.StateDB) (*types.Block, types.Receipts) {
 
-		blockchain, err := NewBlockChain(nil, config, statedb.Database(), db, engine, &vm.Config{})
+		blockchain, err := NewBlockChain(nil, nil, config, statedb.Database(), db, engine, &vm.Config{})
 		if err != nil {
 			panic(err)
 		}
@@ -29,6 +29,11 @@ type Config struct {
 	GlobalQueue  uint64 `mapstructure:"txpool-globalqueue"`
 
 	TimeoutDuration time.Duration `mapstructure:"txpool-timeout"`
+
+	Journal   string        `mapstructure:"txpool-journal"`   // Journal of local transactions to survive node restarts
+	Rejournal time.Duration `mapstructure:"txpool-rejournal"` // Time interval to regenerate the local transaction journal
+
+	AllowUnprotectedTxs bool `mapstructure:"txpool-allowunprotected"`
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -44,4 +49,8 @@ var DefaultTxPoolConfig = Config{
 	GlobalQueue:  1024,
 
 	TimeoutDuration: 3 * time.Hour,
+
+	Rejournal: time.Hour,
+
+	AllowUnprotectedTxs: true,
 }
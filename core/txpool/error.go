@@ -25,6 +25,10 @@ var (
 	// ErrInvalidSender is returned if the transaction contains an invalid signature.
 	ErrInvalidSender = errors.New("invalid sender")
 
+	// ErrUnprotectedTx is returned if the transaction is signed without chain id
+	// and the pool doesn't accept unprotected transactions.
+	ErrUnprotectedTx = errors.New("only replay-protected transactions allowed")
+
 	// ErrNonceTooLow is returned if the nonce of a transaction is lower than the
 	// one present in the local chain.
 	ErrNonceTooLow = errors.New("nonce too low")
@@ -33,6 +37,14 @@ var (
 	// configured for the transaction pool.
 	ErrUnderPriced = errors.New("transaction underpriced")
 
+	// ErrNotMultisig is returned if a transaction carrying owner signatures isn't
+	// sent from a multisig account.
+	ErrNotMultisig = errors.New("not a multisig account")
+
+	// ErrUnderMinGasPrice is returned if a transaction's gas price is below the
+	// minimum gas price of the next block.
+	ErrUnderMinGasPrice = errors.New("transaction under the minimum gas price of the block")
+
 	// ErrReplaceUnderpriced is returned if a transaction is attempted to be replaced
 	// with a different one without the required price bump.
 	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
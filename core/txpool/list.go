@@ -56,11 +56,8 @@ func (l *txList) Overlaps(tx *types.Transaction) bool {
 func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transaction) {
 	// If there's an older better transaction, abort
 	old := l.txs.Get(tx.Nonce())
-	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
-			return false, nil
-		}
+	if old != nil && MinReplacementPrice(old.GasPrice(), priceBump).Cmp(tx.GasPrice()) > 0 {
+		return false, nil
 	}
 	// Otherwise overwrite the old transaction with the current one
 	l.txs.Put(tx)
@@ -370,14 +367,16 @@ func (l *priceList) Removed() {
 
 // Cap finds all the transactions below the given price threshold, drops them
 // from the priced list and returs them for further removal from the entire pool.
-func (l *priceList) Cap(threshold *big.Int) []*priceNonce {
+// The transactions of the local accounts are never dropped.
+func (l *priceList) Cap(threshold *big.Int, local *accountSet) []*priceNonce {
 	drop := make([]*priceNonce, 0, 128)
 	save := make([]*priceNonce, 0, 64)
 
 	for len(*l.items) > 0 {
 		// Discard stale transactions if found during cleanup
 		pn := heap.Pop(l.items).(*priceNonce)
-		if l.all.Get(pn.hash) == nil {
+		tx := l.all.Get(pn.hash)
+		if tx == nil {
 			l.stales--
 			continue
 		}
@@ -386,7 +385,12 @@ func (l *priceList) Cap(threshold *big.Int) []*priceNonce {
 			save = append(save, pn)
 			break
 		}
-		drop = append(drop, pn)
+		// Non stale transaction found, discard unless local
+		if local.containsTx(tx) {
+			save = append(save, pn)
+		} else {
+			drop = append(drop, pn)
+		}
 	}
 	for _, pn := range save {
 		heap.Push(l.items, pn)
@@ -395,8 +399,12 @@ func (l *priceList) Cap(threshold *big.Int) []*priceNonce {
 }
 
 // Underpriced checks whether a transaction is cheaper than (or as cheap as) the
-// lowest priced transaction currently being tracked.
-func (l *priceList) Underpriced(tx *types.Transaction) bool {
+// lowest priced remote transaction currently being tracked.
+func (l *priceList) Underpriced(tx *types.Transaction, local *accountSet) bool {
+	// Local transactions cannot be underpriced
+	if local.containsTx(tx) {
+		return false
+	}
 	// Discard stale price points if found at the heap start
 	for len(*l.items) > 0 {
 		head := []*priceNonce(*l.items)[0]
@@ -416,19 +424,29 @@ func (l *priceList) Underpriced(tx *types.Transaction) bool {
 	return cheapest.price.Cmp(tx.GasPrice()) >= 0
 }
 
-// Discard finds a number of most underpriced transactions, removes them from the
-// priced list and returns them for further removal from the entire pool.
-func (l *priceList) Discard(count int) []*priceNonce {
+// Discard finds a number of most underpriced remote transactions, removes them
+// from the priced list and returns them for further removal from the entire pool.
+func (l *priceList) Discard(count int, local *accountSet) []*priceNonce {
 	drop := make([]*priceNonce, 0, count) // Remote underpriced transactions to drop
+	save := make([]*priceNonce, 0, 64)    // Local underpriced transactions to keep
 	for len(*l.items) > 0 && count > 0 {
 		// Discard stale transactions if found during cleanup
 		pn := heap.Pop(l.items).(*priceNonce)
-		if l.all.Get(pn.hash) == nil {
+		tx := l.all.Get(pn.hash)
+		if tx == nil {
 			l.stales--
 			continue
 		}
-		drop = append(drop, pn)
-		count--
+		// Non stale transaction found, discard unless local
+		if local.containsTx(tx) {
+			save = append(save, pn)
+		} else {
+			drop = append(drop, pn)
+			count--
+		}
+	}
+	for _, pn := range save {
+		heap.Push(l.items, pn)
 	}
 	return drop
 }
@@ -58,6 +58,39 @@ func (bc *testBlockChain) SubscribeChainBlockEvent(ch chan<- feed.BlockAndLogsEv
 	return bc.chainBlockFeed.Subscribe(ch)
 }
 
+// testReorgChain keeps its blocks by hash, so the pool can walk the branches of
+// a reorg back to their common ancestor.
+type testReorgChain struct {
+	*testBlockChain
+	blocks map[utils.Hash]*types.Block
+	head   *types.Block
+}
+
+func (c *testReorgChain) CurrentBlock() *types.Block {
+	return c.head
+}
+
+func (c *testReorgChain) GetBlock(hash utils.Hash) *types.Block {
+	return c.blocks[hash]
+}
+
+// newBlock creates a child block of parent including the transactions, a nil
+// parent creates the genesis.
+func (c *testReorgChain) newBlock(parent *types.Block, txs types.Transactions) *types.Block {
+	header := &types.BlockHeader{
+		GasLimit:  c.gasLimit,
+		Height:    big.NewInt(0),
+		TimeStamp: big.NewInt(int64(len(c.blocks))),
+	}
+	if parent != nil {
+		header.PreviousHash = parent.Hash()
+		header.Height = new(big.Int).Add(parent.Height(), big.NewInt(1))
+	}
+	block := types.NewBlock(header, txs, nil, nil)
+	c.blocks[block.Hash()] = block
+	return block
+}
+
 func transaction(nonce uint64, gaslimit uint64, key *ecdsa.PrivateKey) *types.Transaction {
 	return pricedTransaction(nonce, gaslimit, big.NewInt(1), key)
 }
@@ -49,6 +49,8 @@ type TxPool struct {
 	currentState *state.StateDB      // Current state in the blockchain head
 	tmpState     *state.ManagedState // Pending state tracking virtual nonces
 	curMaxGas    uint64              // Current gas limit for transaction caps
+	curMinPrice  *big.Int            // Minimum gas price of the next block, nil if not dynamic
+	curHeight    *big.Int            // Height of the next block
 
 	pending map[utils.Address]*txList   // All currently processable transactions
 	queue   map[utils.Address]*txList   // Queued but non-processable transactions
@@ -56,6 +58,9 @@ type TxPool struct {
 
 	dList *deferredList
 
+	locals  *accountSet // Set of local transaction to exempt from eviction rules
+	journal *txJournal  // Journal of local transaction to back up to disk
+
 	txs       *allTxs    // All transactions cache
 	priceList *priceList // All transactions sorted by price
 
@@ -77,12 +82,17 @@ func New(config *Config, chainconfig *params.ChainConfig, chain blockChainHelper
 		log.Warnf("Sanitizing invalid txpool price bump provided: %v updated: %v", config.PriceBump, DefaultTxPoolConfig.PriceBump)
 		config.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if config.Rejournal < time.Second {
+		log.Warnf("Sanitizing invalid txpool journal time provided: %v updated: %v", config.Rejournal, time.Second)
+		config.Rejournal = time.Second
+	}
 
 	tp := &TxPool{}
 	tp.config = config
 	tp.chainconfig = chainconfig
 	tp.chain = chain
-	tp.signer = types.Signer{}
+	tp.signer = types.NewSigner(chainconfig.ChainID)
+	tp.locals = newAccountSet(tp.signer)
 	tp.pending = make(map[utils.Address]*txList)
 	tp.queue = make(map[utils.Address]*txList)
 	tp.beats = make(map[utils.Address]time.Time)
@@ -93,6 +103,18 @@ func New(config *Config, chainconfig *params.ChainConfig, chain blockChainHelper
 	tp.priceList = newpriceList(tp.txs)
 	tp.resetTxpoolState(nil, chain.CurrentBlock())
 
+	// If local transactions and journaling is enabled, load from disk
+	if config.Journal != "" {
+		tp.journal = newTxJournal(config.Journal)
+
+		if err := tp.journal.load(tp.AddLocals); err != nil {
+			log.Warnf("Failed to load transaction journal err: %v", err)
+		}
+		if err := tp.journal.rotate(tp.local()); err != nil {
+			log.Warnf("Failed to rotate transaction journal err: %v", err)
+		}
+	}
+
 	tp.chainBlockSub = tp.chain.SubscribeChainBlockEvent(tp.chainBlockCh)
 
 	tp.addTxsChan = make(chan types.Transactions, tp.config.GlobalQueue)
@@ -112,6 +134,9 @@ func (tp *TxPool) loop() {
 	timeout := time.NewTicker(timeoutInterval)
 	defer timeout.Stop()
 
+	journal := time.NewTicker(tp.config.Rejournal)
+	defer journal.Stop()
+
 	block := tp.chain.CurrentBlock()
 
 	// Keep waiting for and reacting to the various events
@@ -121,6 +146,10 @@ func (tp *TxPool) loop() {
 		case <-timeout.C:
 			tp.mu.Lock()
 			for addr := range tp.queue {
+				// Skip local transactions from the eviction mechanism
+				if tp.locals.contains(addr) {
+					continue
+				}
 				// Any non-locals old enough should be removed
 				if time.Since(tp.beats[addr]) > tp.config.TimeoutDuration {
 					for _, tx := range tp.queue[addr].Flatten() {
@@ -129,6 +158,15 @@ func (tp *TxPool) loop() {
 				}
 			}
 			tp.mu.Unlock()
+		// Handle local transaction journal rotation
+		case <-journal.C:
+			if tp.journal != nil {
+				tp.mu.Lock()
+				if err := tp.journal.rotate(tp.local()); err != nil {
+					log.Warnf("Failed to rotate local tx journal err: %v", err)
+				}
+				tp.mu.Unlock()
+			}
 		// Be unsubscribed due to system stopped
 		case <-tp.chainBlockSub.Err():
 			return
@@ -151,7 +189,7 @@ func (tp *TxPool) txloop() {
 	for {
 		select {
 		case txs := <-tp.addTxsChan:
-			tp.addTxs(txs)
+			tp.addTxs(txs, false)
 		}
 	}
 }
@@ -164,55 +202,62 @@ func (tp *TxPool) Stop() {
 
 	tp.chainBlockSub.Unsubscribe()
 	tp.wg.Wait()
+
+	if tp.journal != nil {
+		tp.journal.close()
+	}
 	log.Info("Transaction pool service stopped")
 }
 
-// reinjectTxs reorging an old state, reinject all dropped transactions
-func (tp *TxPool) reinjectTxs(old, new *types.Block) types.Transactions {
-	var reinject types.Transactions
-	if old != nil && old.Hash() != new.PreviousHash() {
-		oldHeight := old.Height().Uint64()
-		newHeight := new.Height().Uint64()
-		if depth := uint64(math.Abs(float64(oldHeight) - float64(newHeight))); depth > 64 {
-			log.Debugf("Skipping deep transaction reorg depth: %v ", depth)
-		} else {
-			// Reorg seems shallow enough to pull in all transactions into memory
-			var discarded, included types.Transactions
-
-			var (
-				rem = tp.chain.GetBlock(old.Hash())
-				add = tp.chain.GetBlock(new.Hash())
-			)
-			for rem.Height().Uint64() > add.Height().Uint64() {
-				discarded = append(discarded, rem.Transactions()...)
-				if rem = tp.chain.GetBlock(rem.PreviousHash()); rem == nil {
-					log.Errorf("Unrooted old chain seen by tx pool block height: %v ,hash: %v", old.Height(), old.Hash())
-					return nil
-				}
-			}
-			for add.Height().Uint64() > rem.Height().Uint64() {
-				included = append(included, add.Transactions()...)
-				if add = tp.chain.GetBlock(add.PreviousHash()); add == nil {
-					log.Errorf("Unrooted new chain seen by tx pool block height: %v ,hash: %v", new.Height(), new.Hash())
-					return nil
-				}
-			}
-			for rem.Hash() != add.Hash() {
-				discarded = append(discarded, rem.Transactions()...)
-				if rem = tp.chain.GetBlock(rem.PreviousHash()); rem == nil {
-					log.Errorf("Unrooted old chain seen by tx pool block height: %v ,hash: %v", old.Height(), old.Hash())
-					return nil
-				}
-				included = append(included, add.Transactions()...)
-				if add = tp.chain.GetBlock(add.PreviousHash()); add == nil {
-					log.Errorf("Unrooted new chain seen by tx pool block height: %v ,hash: %v", new.Height(), new.Hash())
-					return nil
-				}
-			}
-			reinject = TxDifference(discarded, included)
+// reorgTxs walks the old and the new canonical branches back to their common
+// ancestor, returning the transactions of the blocks dropped from the canonical
+// chain and the ones of the blocks added to it.
+func (tp *TxPool) reorgTxs(old, new *types.Block) (discarded, included types.Transactions) {
+	if old == nil || old.Hash() == new.PreviousHash() {
+		return nil, nil
+	}
+	oldHeight := old.Height().Uint64()
+	newHeight := new.Height().Uint64()
+	if depth := uint64(math.Abs(float64(oldHeight) - float64(newHeight))); depth > 64 {
+		log.Debugf("Skipping deep transaction reorg depth: %v ", depth)
+		return nil, nil
+	}
+	// Reorg seems shallow enough to pull in all transactions into memory
+	var (
+		rem = tp.chain.GetBlock(old.Hash())
+		add = tp.chain.GetBlock(new.Hash())
+	)
+	if rem == nil || add == nil {
+		log.Errorf("Unknown reorg head seen by tx pool old: %v, new: %v", old.Hash(), new.Hash())
+		return nil, nil
+	}
+	for rem.Height().Uint64() > add.Height().Uint64() {
+		discarded = append(discarded, rem.Transactions()...)
+		if rem = tp.chain.GetBlock(rem.PreviousHash()); rem == nil {
+			log.Errorf("Unrooted old chain seen by tx pool block height: %v ,hash: %v", old.Height(), old.Hash())
+			return nil, nil
+		}
+	}
+	for add.Height().Uint64() > rem.Height().Uint64() {
+		included = append(included, add.Transactions()...)
+		if add = tp.chain.GetBlock(add.PreviousHash()); add == nil {
+			log.Errorf("Unrooted new chain seen by tx pool block height: %v ,hash: %v", new.Height(), new.Hash())
+			return nil, nil
 		}
 	}
-	return reinject
+	for rem.Hash() != add.Hash() {
+		discarded = append(discarded, rem.Transactions()...)
+		if rem = tp.chain.GetBlock(rem.PreviousHash()); rem == nil {
+			log.Errorf("Unrooted old chain seen by tx pool block height: %v ,hash: %v", old.Height(), old.Hash())
+			return nil, nil
+		}
+		included = append(included, add.Transactions()...)
+		if add = tp.chain.GetBlock(add.PreviousHash()); add == nil {
+			log.Errorf("Unrooted new chain seen by tx pool block height: %v ,hash: %v", new.Height(), new.Hash())
+			return nil, nil
+		}
+	}
+	return discarded, included
 }
 
 func (tp *TxPool) processTxslist() {
@@ -225,12 +270,17 @@ func (tp *TxPool) processTxslist() {
 	tp.promoteQueue(nil)
 }
 
+// resetTxpoolState moves the pool from the old head to the new one. On a reorg
+// the transactions of the new canonical branch are removed from the pool and
+// the ones left only on the dropped branch are injected back, before the pool
+// is revalidated and promoted against the new state.
 func (tp *TxPool) resetTxpoolState(old, new *types.Block) {
-	txs := tp.reinjectTxs(old, new)
 	// Initialize the internal state to the current head
 	if new == nil {
 		new = tp.chain.CurrentBlock()
 	}
+	discarded, included := tp.reorgTxs(old, new)
+
 	statedb, err := tp.chain.StateAt(new.StateRoot())
 	if err != nil {
 		log.Errorf("Failed to reset txpool state err %v", err)
@@ -239,10 +289,21 @@ func (tp *TxPool) resetTxpoolState(old, new *types.Block) {
 	tp.currentState = statedb
 	tp.tmpState = state.ManageState(statedb)
 	tp.curMaxGas = new.GasLimit()
+	tp.curMinPrice = types.CalcMinGasPrice(tp.chainconfig, new.BlockHeader())
+	tp.curHeight = new.Height().Add(new.Height(), big.NewInt(1))
 
+	// Drop the transactions included by the new branch
+	for _, tx := range included {
+		tp.removeTx(tx.Hash(), true)
+	}
 	// Inject any transactions discarded due to reorgs
-	log.Debugf("Reinjecting stale transactions count %v", len(txs))
-	tp.addTxsLocked(txs)
+	reinject := TxDifference(discarded, included)
+	log.Debugf("Reinjecting stale transactions count %v", len(reinject))
+	for _, tx := range reinject {
+		if _, err := tp.add(tx, false); err != nil {
+			log.Debugf("Failed to reinject transaction hash: %v, err: %v", tx.Hash(), err)
+		}
+	}
 
 	tp.processTxslist()
 }
@@ -266,7 +327,7 @@ func (tp *TxPool) SetGasPrice(price *big.Int) {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
 	tp.gasPrice = price
-	for _, pn := range tp.priceList.Cap(price) {
+	for _, pn := range tp.priceList.Cap(price, tp.locals) {
 		tp.removeTx(pn.hash, false)
 	}
 	log.Debugf("Transaction pool price threshold updated price %v", price)
@@ -333,6 +394,34 @@ func (tp *TxPool) Pending() (map[utils.Address]types.Transactions, error) {
 	return pending, nil
 }
 
+// Locals retrieves the accounts currently considered local by the pool.
+func (tp *TxPool) Locals() []utils.Address {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	accounts := make([]utils.Address, 0, len(tp.locals.accounts))
+	for addr := range tp.locals.accounts {
+		accounts = append(accounts, addr)
+	}
+	return accounts
+}
+
+// local retrieves all currently known local transactions, groupped by origin
+// account and sorted by nonce. The returned transaction set is a copy and can be
+// freely modified by calling code.
+func (tp *TxPool) local() map[utils.Address]types.Transactions {
+	txs := make(map[utils.Address]types.Transactions)
+	for addr := range tp.locals.accounts {
+		if pending := tp.pending[addr]; pending != nil {
+			txs[addr] = append(txs[addr], pending.Flatten()...)
+		}
+		if queued := tp.queue[addr]; queued != nil {
+			txs[addr] = append(txs[addr], queued.Flatten()...)
+		}
+	}
+	return txs
+}
+
 // Actions get actions
 func (tp *TxPool) Actions() []*types.Action {
 	tp.mu.Lock()
@@ -343,11 +432,13 @@ func (tp *TxPool) Actions() []*types.Action {
 
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
-func (tp *TxPool) validateTx(tx *types.Transaction) error {
-	// Heuristic limit, reject transactions over 32KB to prevent DOS attacks
-	if err := tx.Validate(tp.chainconfig); err != nil {
+func (tp *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	// Reject the malformed transactions before recovering any signature, as the
+	// multisig ones carrying more signatures than an account can have owners
+	if err := tx.Validate(tp.chainconfig, tp.curHeight); err != nil {
 		return err
 	}
+	// Heuristic limit, reject transactions over 32KB to prevent DOS attacks
 	if tx.Size() > 32*1024 {
 		return ErrOversizedData
 	}
@@ -361,16 +452,46 @@ func (tp *TxPool) validateTx(tx *types.Transaction) error {
 	}
 	// Make sure the transaction is signed properly
 	from, err := tx.Sender(tp.signer)
-	if err != nil {
+	if err == types.ErrInvalidChainId {
+		return err
+	} else if err != nil {
 		return ErrInvalidSender
 	}
+	// Unprotected transactions can be replayed on other chains
+	if protected, _ := tx.Protected(tp.signer); !protected && !tp.config.AllowUnprotectedTxs {
+		return ErrUnprotectedTx
+	}
+	// Multisig accounts need the signatures of enough owners
+	if tx.MultiSignature() != nil {
+		owners, err := tp.signer.Owners(tx)
+		if err != nil {
+			return err
+		}
+		multisig := tp.currentState.GetMultisig(from)
+		if multisig == nil {
+			return ErrNotMultisig
+		}
+		if err := multisig.Authorize(owners); err != nil {
+			return err
+		}
+	}
+	if tx.Type() == types.Multisig {
+		if _, err := state.DecodeMultisig(tx.Payload()); err != nil {
+			return err
+		}
+	}
 	if tx.Type() == types.LogoutCandidate && bytes.Compare(from.Bytes(), utils.HexToAddress(tp.chainconfig.GenesisCandidate).Bytes()) == 0 {
 		return fmt.Errorf("genesis candidate not allow logout")
 	}
-	// Drop transactions under our own minimal accepted gas price
-	if tp.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	// Drop non-local transactions under our own minimal accepted gas price
+	local = local || tp.locals.contains(from) // account may be local even if the transaction arrived from the network
+	if !local && tp.gasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrUnderPriced
 	}
+	// Drop transactions the next block can't include
+	if tp.curMinPrice != nil && tp.curMinPrice.Cmp(tx.GasPrice()) > 0 {
+		return ErrUnderMinGasPrice
+	}
 
 	// Ensure the transaction adheres to nonce ordering
 	if tp.currentState.GetNonce(from) > tx.Nonce() {
@@ -388,7 +509,7 @@ func (tp *TxPool) validateTx(tx *types.Transaction) error {
 	} else if tp.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
-	intrGas, err := IntrinsicGas(tx.Payload(), tx.Type(), len(tx.Tos()) == 0 && tx.Type() == types.Binary)
+	intrGas, err := IntrinsicGas(tx.Payload(), tx.Type(), len(tx.Tos()) == 0 && tx.Type() == types.Binary, len(tx.Tos()), tx.OwnerSignatures())
 	if err != nil {
 		return err
 	}
@@ -399,7 +520,8 @@ func (tp *TxPool) validateTx(tx *types.Transaction) error {
 }
 
 // add validates a transaction and inserts it into the non-executable queue for later pending promotion and execution.
-func (tp *TxPool) add(tx *types.Transaction) (bool, error) {
+// If the transaction is a local one, its sender is marked local and the transaction is journaled.
+func (tp *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	// If the transaction is already known, discard it
 	hash := tx.Hash()
 	if tp.txs.Get(hash) != nil {
@@ -407,26 +529,31 @@ func (tp *TxPool) add(tx *types.Transaction) (bool, error) {
 		return false, fmt.Errorf("known transaction: %x", hash)
 	}
 	// If the transaction fails basic validation, discard it
-	if err := tp.validateTx(tx); err != nil {
+	if err := tp.validateTx(tx, local); err != nil {
 		log.Warnf("Discarding invalid transaction hash: %v, nonce: %v,err: %v", hash, tx.Nonce(), err)
 		return false, err
 	}
 	// If the transaction pool is full, discard underpriceList transactions
 	if uint64(tp.txs.Count()) >= tp.config.GlobalSlots+tp.config.GlobalQueue {
 		// If the new transaction is underpriceList, don't accept it
-		if tp.priceList.Underpriced(tx) {
+		if !local && tp.priceList.Underpriced(tx, tp.locals) {
 			log.Warnf("Discarding underpriceList transaction hash: %v ,price: %v", hash, tx.GasPrice())
 			return false, ErrUnderPriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := tp.priceList.Discard(tp.txs.Count() - int(tp.config.GlobalSlots+tp.config.GlobalQueue-1))
+		drop := tp.priceList.Discard(tp.txs.Count()-int(tp.config.GlobalSlots+tp.config.GlobalQueue-1), tp.locals)
 		for _, pn := range drop {
 			log.Warnf("Discarding freshly underpriceList transaction hash: %v ,price: %v", tx.Hash(), tx.GasPrice())
 			tp.removeTx(pn.hash, false)
 		}
 	}
-	// If the transaction is replacing an already pending one, do directly
+	// Mark local addresses, their transactions are journaled
 	from, _ := tx.Sender(tp.signer)
+	if local && !tp.locals.contains(from) {
+		log.Infof("Setting new local account address: %v", from)
+		tp.locals.add(from)
+	}
+	// If the transaction is replacing an already pending one, do directly
 	if list := tp.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, tp.config.PriceBump)
@@ -440,6 +567,7 @@ func (tp *TxPool) add(tx *types.Transaction) (bool, error) {
 		}
 		tp.txs.Add(tx)
 		tp.priceList.Put(tx)
+		tp.journalTx(from, tx)
 
 		log.Warnf("Pooled new executable transaction hash: %v, from: %v, to: %v", hash, from, tx.Tos())
 
@@ -452,6 +580,7 @@ func (tp *TxPool) add(tx *types.Transaction) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	tp.journalTx(from, tx)
 
 	log.Warnf("Pooled new future transaction hash: %v, from: %v ,to: %v", hash, from, tx.Tos())
 	return replace, nil
@@ -482,6 +611,18 @@ func (tp *TxPool) enqueueTx(hash utils.Hash, tx *types.Transaction) (bool, error
 	return old != nil, nil
 }
 
+// journalTx adds the specified transaction to the local disk journal if it is
+// deemed to have been sent from a local account.
+func (tp *TxPool) journalTx(from utils.Address, tx *types.Transaction) {
+	// Only journal if it's enabled and the transaction is local
+	if tp.journal == nil || !tp.locals.contains(from) {
+		return
+	}
+	if err := tp.journal.insert(tx); err != nil {
+		log.Warnf("Failed to journal local transaction err: %v", err)
+	}
+}
+
 // promoteTx adds a transaction to the pending (processable) list of transactions
 // and returns whether it was inserted or an older was better.
 //
@@ -523,8 +664,21 @@ func (tp *TxPool) AddAction(a *types.Action) {
 	tp.dList.Put(a)
 }
 
+// AddLocal enqueues a single transaction into the pool if it is valid, marking
+// the sender as a local one. Local transactions are exempt from the price based
+// eviction and the queue timeout, and are journaled to survive restarts.
+func (tp *TxPool) AddLocal(tx *types.Transaction) error {
+	return tp.addTx(tx, true)
+}
+
+// AddLocals enqueues a batch of transactions into the pool if they are valid,
+// marking the senders as local ones.
+func (tp *TxPool) AddLocals(txs []*types.Transaction) []error {
+	return tp.addTxs(txs, true)
+}
+
 func (tp *TxPool) AddTx(tx *types.Transaction) error {
-	return tp.addTx(tx)
+	return tp.addTx(tx, false)
 }
 
 func (tp *TxPool) AddTxsChan(txs types.Transactions) bool {
@@ -540,16 +694,16 @@ func (tp *TxPool) AddTxsChan(txs types.Transactions) bool {
 }
 
 func (tp *TxPool) AddTxs(txs []*types.Transaction) []error {
-	return tp.addTxs(txs)
+	return tp.addTxs(txs, false)
 }
 
 // addTx enqueues a single transaction into the pool if it is valid.
-func (tp *TxPool) addTx(tx *types.Transaction) error {
+func (tp *TxPool) addTx(tx *types.Transaction, local bool) error {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
 
 	// Try to inject the transaction and update any state
-	replace, err := tp.add(tx)
+	replace, err := tp.add(tx, local)
 	if err != nil {
 		return err
 	}
@@ -562,22 +716,22 @@ func (tp *TxPool) addTx(tx *types.Transaction) error {
 }
 
 // addTxs attempts to queue a batch of transactions if they are valid.
-func (tp *TxPool) addTxs(txs []*types.Transaction) []error {
+func (tp *TxPool) addTxs(txs []*types.Transaction, local bool) []error {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
 
-	return tp.addTxsLocked(txs)
+	return tp.addTxsLocked(txs, local)
 }
 
 // addTxsLocked attempts to queue a batch of transactions if they are valid,
 // whilst assuming the transaction pool lock is already held.
-func (tp *TxPool) addTxsLocked(txs []*types.Transaction) []error {
+func (tp *TxPool) addTxsLocked(txs []*types.Transaction, local bool) []error {
 	// Add the batch of transaction, tracking the accepted ones
 	dirty := make(map[utils.Address]struct{})
 	errs := make([]error, len(txs))
 	for i, tx := range txs {
 		var replace bool
-		if replace, errs[i] = tp.add(tx); errs[i] == nil {
+		if replace, errs[i] = tp.add(tx, local); errs[i] == nil {
 			if !replace {
 				from, _ := tx.Sender(tp.signer) // already validated
 				dirty[from] = struct{}{}
@@ -732,7 +886,7 @@ func (tp *TxPool) promoteQueue(accounts []utils.Address) {
 		spammers := prque.New()
 		for addr, list := range tp.pending {
 			// Only evict transactions from high rollers
-			if uint64(list.Len()) > tp.config.AccountSlots {
+			if !tp.locals.contains(addr) && uint64(list.Len()) > tp.config.AccountSlots {
 				spammers.Push(addr, float32(list.Len()))
 			}
 		}
@@ -800,7 +954,9 @@ func (tp *TxPool) promoteQueue(accounts []utils.Address) {
 		// Sort all accounts with queued transactions by heartbeat
 		addresses := make(addresssByHeartbeat, 0, len(tp.queue))
 		for addr := range tp.queue {
-			addresses = append(addresses, addressByHeartbeat{addr, tp.beats[addr]})
+			if !tp.locals.contains(addr) { // don't drop locals
+				addresses = append(addresses, addressByHeartbeat{addr, tp.beats[addr]})
+			}
 		}
 		sort.Sort(addresses)
 		// Drop transactions until the total is below the limit or only locals remain
@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"math/rand"
+	"os"
 	"testing"
 	"time"
 
@@ -115,6 +116,40 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+func TestMultisigTransactionLimits(t *testing.T) {
+	t.Parallel()
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account, to := utils.HexToAddress("0xaa"), utils.Address{}
+	pool.currentState.SetMultisig(account, &state.Multisig{Owners: []utils.Address{crypto.PubkeyToAddress(key.PublicKey)}, Threshold: 1})
+	pool.currentState.AddBalance(account, big.NewInt(0xffffffffffffff))
+
+	newTx := func(gaslimit uint64) *types.Transaction {
+		tx := types.NewTransaction(types.Binary, 0, big.NewInt(100), gaslimit, big.NewInt(1), nil, &to)
+		tx.WithMultisigAccount(account)
+		tx.SignTx(types.Signer{}, key)
+		return tx
+	}
+
+	// every owner signature is paid for
+	if err := pool.AddTx(newTx(params.TxGas)); err != ErrIntrinsicGas {
+		t.Error("expected", ErrIntrinsicGas, "got", err)
+	}
+	// the signatures over the maximum number of owners aren't recovered
+	tx := newTx(100000)
+	ms := tx.MultiSignature()
+	for len(ms.Signatures) <= params.MaxMultisigOwners {
+		ms.Signatures = append(ms.Signatures, ms.Signatures[0])
+	}
+	if err := pool.AddTx(tx); err != types.ErrTooManySignatures {
+		t.Error("expected", types.ErrTooManySignatures, "got", err)
+	}
+	if err := pool.AddTx(newTx(params.TxGas + params.TxSignatureGas)); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 	pool, key := setupTxPool()
@@ -199,14 +234,14 @@ func TestTransactionChainFork(t *testing.T) {
 	resetState()
 
 	tx := transaction(0, 100000, key)
-	if _, err := pool.add(tx); err != nil {
+	if _, err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	pool.removeTx(tx.Hash(), true)
 
 	// reset the pool's internal state
 	resetState()
-	if _, err := pool.add(tx); err != nil {
+	if _, err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 }
@@ -237,10 +272,10 @@ func TestTransactionDoubleNonce(t *testing.T) {
 	tx3.SignTx(signer, key)
 
 	// Add the first two transaction, ensure higher priced stays only
-	if replace, err := pool.add(tx1); err != nil || replace {
+	if replace, err := pool.add(tx1, false); err != nil || replace {
 		t.Errorf("first transaction insert failed (%v) or reported replacement (%v)", err, replace)
 	}
-	if replace, err := pool.add(tx2); err != nil || !replace {
+	if replace, err := pool.add(tx2, false); err != nil || !replace {
 		t.Errorf("second transaction insert failed (%v) or not reported replacement (%v)", err, replace)
 	}
 	pool.promoteQueue([]utils.Address{addr})
@@ -251,7 +286,7 @@ func TestTransactionDoubleNonce(t *testing.T) {
 		t.Errorf("transaction mismatch: have %x, want %x", tx.Hash(), tx2.Hash())
 	}
 	// Add the third transaction and ensure it's not saved (smaller price)
-	pool.add(tx3)
+	pool.add(tx3, false)
 	pool.promoteQueue([]utils.Address{addr})
 	if pool.pending[addr].Len() != 1 {
 		t.Error("expected 1 pending transactions, got", pool.pending[addr].Len())
@@ -274,7 +309,7 @@ func TestTransactionMissingNonce(t *testing.T) {
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	pool.currentState.AddBalance(addr, big.NewInt(100000000000000))
 	tx := transaction(1, 100000, key)
-	if _, err := pool.add(tx); err != nil {
+	if _, err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	if len(pool.pending) != 0 {
@@ -1293,6 +1328,198 @@ func TestTransactionStatusCheck(t *testing.T) {
 	}
 }
 
+// Tests that on a reorg the transactions left only on the dropped branch are
+// injected back into the pool, and the ones included by the new branch removed.
+func TestTransactionReorgReinjection(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(utils.Hash{}, state.NewDatabase(mdb.New()))
+	blockchain := &testReorgChain{
+		testBlockChain: &testBlockChain{statedb, 1000000, new(feed.Feed)},
+		blocks:         make(map[utils.Hash]*types.Block),
+	}
+	genesis := blockchain.newBlock(nil, nil)
+	blockchain.head = genesis
+
+	pool := New(testTxPoolConfig, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	statedb.AddBalance(account, big.NewInt(1000000000))
+
+	tx0 := transaction(0, 100000, key)
+	tx1 := transaction(1, 100000, key)
+	pool.AddTxs(types.Transactions{tx0, tx1})
+
+	// Include the first transaction into the head block
+	oldHead := blockchain.newBlock(genesis, types.Transactions{tx0})
+	statedb.SetNonce(account, 1)
+	pool.lockedReset(genesis, oldHead)
+
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Reorg to a heavier branch without the transaction, it must be reinjected
+	side := blockchain.newBlock(genesis, nil)
+	newHead := blockchain.newBlock(side, nil)
+	statedb.SetNonce(account, 0)
+	pool.lockedReset(oldHead, newHead)
+
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if pool.Get(tx0.Hash()) == nil {
+		t.Fatalf("transaction of the dropped branch not reinjected")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Reorg back to the first branch including both transactions
+	last := blockchain.newBlock(blockchain.newBlock(oldHead, types.Transactions{tx1}), nil)
+	statedb.SetNonce(account, 2)
+	pool.lockedReset(newHead, last)
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pool transactions mismatched: have %d pending %d queued, want none", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that local transactions are exempt from the price limit of the pool and
+// survive the repricing dropping the remote ones.
+func TestTransactionPoolRepricingKeepsLocals(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the pricing enforcement with
+	statedb, _ := state.New(utils.Hash{}, state.NewDatabase(mdb.New()))
+	blockchain := &testBlockChain{statedb, 1000000, new(feed.Feed)}
+
+	pool := New(testTxPoolConfig, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	localTx := pricedTransaction(0, 100000, big.NewInt(1), local)
+	if err := pool.AddLocal(localTx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddTx(pricedTransaction(0, 100000, big.NewInt(1), remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	// Reprice the pool and check that only the remote transaction is dropped
+	pool.SetGasPrice(big.NewInt(2))
+
+	pending, queued := pool.Stats()
+	if pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if pool.Get(localTx.Hash()) == nil {
+		t.Fatalf("local transaction dropped by repricing")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Check that locals are still accepted under the price limit, but remotes not
+	if err := pool.AddTx(pricedTransaction(1, 100000, big.NewInt(1), remote)); err != ErrUnderPriced {
+		t.Fatalf("adding underpriced remote transaction error mismatch: have %v, want %v", err, ErrUnderPriced)
+	}
+	if err := pool.AddLocal(pricedTransaction(1, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add underpriced local transaction: %v", err)
+	}
+}
+
+// Tests that local transactions are journaled to disk and reloaded by a pool
+// created on the same journal, while the remote ones are lost.
+func TestTransactionJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(journal)
+
+	// Create the original pool to inject transaction into the journal
+	statedb, _ := state.New(utils.Hash{}, state.NewDatabase(mdb.New()))
+	blockchain := &testBlockChain{statedb, 1000000, new(feed.Feed)}
+
+	config := DefaultTxPoolConfig
+	config.Journal = journal
+	config.Rejournal = time.Second
+
+	pool := New(&config, params.TestChainConfig, blockchain)
+
+	// Create two test accounts to ensure remotes expire but locals do not
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	// Add three local and a remote transactions and ensure they are queued up
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddLocal(pricedTransaction(1, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddLocal(pricedTransaction(2, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddTx(pricedTransaction(0, 100000, big.NewInt(1), remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	pending, queued := pool.Stats()
+	if pending != 4 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 4)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Terminate the old pool, bump the local nonce, create a new pool and ensure relevant transaction survive
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
+
+	pool = New(&config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued = pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	if locals := pool.Locals(); len(locals) != 1 || locals[0] != crypto.PubkeyToAddress(local.PublicKey) {
+		t.Fatalf("local accounts mismatched: have %v, want %v", locals, crypto.PubkeyToAddress(local.PublicKey))
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
@@ -1389,3 +1616,21 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddTxs(batch)
 	}
 }
+
+// Tests that the minimum replacement price applies the bump percentage and
+// always exceeds the original price.
+func TestMinReplacementPrice(t *testing.T) {
+	tests := []struct {
+		price, bump, want int64
+	}{
+		{100, 10, 110},
+		{1, 10, 2},
+		{1000, 0, 1001},
+		{0, 10, 1},
+	}
+	for i, tt := range tests {
+		if have := MinReplacementPrice(big.NewInt(tt.price), uint64(tt.bump)); have.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("test %d: minimum replacement price mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package txpool
 
 import (
 	"math"
+	"math/big"
 	"time"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
@@ -45,6 +46,52 @@ func (a addresssByHeartbeat) Len() int           { return len(a) }
 func (a addresssByHeartbeat) Less(i, j int) bool { return a[i].heartbeat.Before(a[j].heartbeat) }
 func (a addresssByHeartbeat) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
+// accountSet is simply a set of addresses to check for existence, and a signer
+// capable of deriving addresses from transactions.
+type accountSet struct {
+	accounts map[utils.Address]struct{}
+	signer   types.Signer
+}
+
+// newAccountSet creates a new address set with an associated signer for sender
+// derivations.
+func newAccountSet(signer types.Signer) *accountSet {
+	return &accountSet{
+		accounts: make(map[utils.Address]struct{}),
+		signer:   signer,
+	}
+}
+
+// contains checks if a given address is contained within the set.
+func (as *accountSet) contains(addr utils.Address) bool {
+	_, exist := as.accounts[addr]
+	return exist
+}
+
+// containsTx checks if the sender of a given tx is within the set. If the sender
+// cannot be derived, this method returns false.
+func (as *accountSet) containsTx(tx *types.Transaction) bool {
+	if addr, err := tx.Sender(as.signer); err == nil {
+		return as.contains(addr)
+	}
+	return false
+}
+
+// add inserts a new address into the set to track.
+func (as *accountSet) add(addr utils.Address) {
+	as.accounts[addr] = struct{}{}
+}
+
+// MinReplacementPrice returns the lowest gas price a transaction replacing one
+// of the given gas price is accepted with, the price bump being a percentage.
+func MinReplacementPrice(price *big.Int, priceBump uint64) *big.Int {
+	threshold := new(big.Int).Div(new(big.Int).Mul(price, big.NewInt(100+int64(priceBump))), big.NewInt(100))
+	if threshold.Cmp(price) <= 0 {
+		threshold = new(big.Int).Add(price, big.NewInt(1))
+	}
+	return threshold
+}
+
 // TxDifference returns a new set which is the difference between a and b.
 func TxDifference(a, b types.Transactions) types.Transactions {
 	keep := make(types.Transactions, 0, len(a))
@@ -63,16 +110,21 @@ func TxDifference(a, b types.Transactions) types.Transactions {
 	return keep
 }
 
-// IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, tp types.TxType, contractCreation bool) (uint64, error) {
-	// Set the starting gas for the raw transaction
-	var gas uint64
+// IntrinsicGas computes the 'intrinsic gas' for a message with the given data,
+// signatures is the number of owner signatures of a multisig transaction.
+func IntrinsicGas(data []byte, tp types.TxType, contractCreation bool, recipients, signatures int) (uint64, error) {
+	// Every owner signature is recovered, even for the free dpos transactions
+	gas := uint64(signatures) * params.TxSignatureGas
 	if tp == types.Delegate || tp == types.UnDelegate {
 		return gas, nil
 	} else if contractCreation {
-		gas = params.TxGasContractCreation
+		gas += params.TxGasContractCreation
 	} else {
-		gas = params.TxGas
+		gas += params.TxGas
+	}
+	// Every further recipient of a batch payment is a transfer on its own
+	if tp == types.Binary && recipients > 1 {
+		gas += uint64(recipients-1) * params.TxRecipientGas
 	}
 	// Bump the required gas by the amount of transactional data
 	if len(data) > 0 {
@@ -71,6 +71,10 @@ type BlockHeader struct {
 	TimeStamp        *big.Int          `json:"timestamp"`
 	ExtraData        []byte            `json:"extraData"`
 	Nonce            MinerNonce        `json:"nonce"`
+
+	// MinGasPrice is only set on the chains with a minimum gas price, the
+	// headers without it keep their encoding and hash.
+	MinGasPrice *big.Int `json:"minGasPrice" rlp:"optional"`
 }
 
 // Hash returns the block hash of the header
@@ -80,7 +84,7 @@ func (h *BlockHeader) Hash() utils.Hash {
 
 // HashNoNonce returns the hash which is used as input for the proof-of-work search.
 func (h *BlockHeader) HashNoNonce() utils.Hash {
-	return rlpHash([]interface{}{
+	fields := []interface{}{
 		h.PreviousHash,
 		h.Miner,
 		h.StateRoot,
@@ -94,7 +98,11 @@ func (h *BlockHeader) HashNoNonce() utils.Hash {
 		h.GasUsed,
 		h.TimeStamp,
 		h.ExtraData,
-	})
+	}
+	if h.MinGasPrice != nil {
+		fields = append(fields, h.MinGasPrice)
+	}
+	return rlpHash(fields)
 }
 
 // Size returns the approximate memory used by all internal contents.
@@ -114,6 +122,9 @@ func CopyBlockHeader(h *BlockHeader) *BlockHeader {
 	if cpy.Height = new(big.Int); h.Height != nil {
 		cpy.Height.Set(h.Height)
 	}
+	if h.MinGasPrice != nil {
+		cpy.MinGasPrice = new(big.Int).Set(h.MinGasPrice)
+	}
 	if len(h.ExtraData) > 0 {
 		cpy.ExtraData = make([]byte, len(h.ExtraData))
 		copy(cpy.ExtraData, h.ExtraData)
@@ -241,6 +252,15 @@ func (b *Block) ExtraData() []byte            { return utils.CopyBytes(b.header.
 func (b *Block) BlockHeader() *BlockHeader    { return CopyBlockHeader(b.header) }
 func (b *Block) DposCtx() *DposContext        { return b.DposContext }
 
+// MinGasPrice returns the minimum gas price of the block, nil if the chain has no
+// dynamic gas pricing.
+func (b *Block) MinGasPrice() *big.Int {
+	if b.header.MinGasPrice == nil {
+		return nil
+	}
+	return new(big.Int).Set(b.header.MinGasPrice)
+}
+
 // Hash returns the keccak256 hash of b's header.
 func (b *Block) Hash() utils.Hash {
 	if hash := b.hash.Load(); hash != nil {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,7 +73,53 @@ func TestBlockEncodeAndDecode(t *testing.T) {
 
 }
 
+func TestHeaderMinGasPrice(t *testing.T) {
+	header := CopyBlockHeader(testHeader)
+	data, err := rlp.EncodeToBytes(header)
+	assert.NoError(t, err)
+	decoded := new(BlockHeader)
+	assert.NoError(t, rlp.DecodeBytes(data, decoded))
+	assert.Nil(t, decoded.MinGasPrice)
+	assert.Equal(t, header.Hash(), decoded.Hash())
+
+	priced := CopyBlockHeader(testHeader)
+	priced.MinGasPrice = big.NewInt(1000)
+	data, err = rlp.EncodeToBytes(priced)
+	assert.NoError(t, err)
+	decoded = new(BlockHeader)
+	assert.NoError(t, rlp.DecodeBytes(data, decoded))
+	assert.Equal(t, priced.MinGasPrice, decoded.MinGasPrice)
+	assert.Equal(t, priced.Hash(), decoded.Hash())
+
+	// the price is part of the hashes only when set
+	assert.NotEqual(t, header.Hash(), priced.Hash())
+	assert.NotEqual(t, header.HashNoNonce(), priced.HashNoNonce())
+}
+
 func decodeBlock(data []byte) (*Block, error) {
 	var b Block
 	return &b, rlp.Decode(bytes.NewReader(data), &b)
 }
+
+func TestCalcMinGasPrice(t *testing.T) {
+	config := &params.ChainConfig{MinGasPrice: big.NewInt(1000)}
+	tests := []struct {
+		parent  *big.Int
+		gasUsed uint64
+		want    int64
+	}{
+		{nil, 500, 1000},                // first block starts at the floor
+		{big.NewInt(8000), 500, 8000},   // half full keeps the price
+		{big.NewInt(8000), 1000, 9000},  // full raises it by 1/8
+		{big.NewInt(8000), 0, 7000},     // empty lowers it by 1/8
+		{big.NewInt(1000), 0, 1000},     // never under the floor
+		{big.NewInt(1000), 501, 1001},   // raises by one at least
+		{big.NewInt(16000), 750, 17000}, // in proportion to the congestion
+		{big.NewInt(16000), 250, 15000}, // in proportion to the spare room
+	}
+	for i, tt := range tests {
+		parent := &BlockHeader{GasLimit: 1000, GasUsed: tt.gasUsed, MinGasPrice: tt.parent}
+		assert.Equal(t, big.NewInt(tt.want), CalcMinGasPrice(config, parent), "test %d", i)
+	}
+	assert.Nil(t, CalcMinGasPrice(&params.ChainConfig{}, &BlockHeader{GasLimit: 1000}))
+}
@@ -242,6 +242,11 @@ func (d *DposContext) ToProto() *DposContextProto {
 	}
 }
 
+// Roots returns the root hashes of the dpos tries.
+func (p *DposContextProto) Roots() []utils.Hash {
+	return []utils.Hash{p.EpochHash, p.DelegateHash, p.CandidateHash, p.VoteHash, p.MintCntHash}
+}
+
 func (p *DposContextProto) Root() (h utils.Hash) {
 	hw := sha3.NewLegacyKeccak256()
 	rlp.Encode(hw, p.EpochHash)
@@ -403,6 +408,8 @@ func (d *DposContext) UnDelegate(delegatorAddr utils.Address) error {
 	return d.voteTrie.TryDelete(delegator)
 }
 
+// CommitTo writes the nodes of the dpos tries to the in-memory trie database,
+// they are flushed to disk by committing the roots of the returned proto.
 func (d *DposContext) CommitTo(dbw *mtp.Database) (*DposContextProto, error) {
 	epochRoot, err := d.epochTrie.CommitTo(dbw)
 	if err != nil {
@@ -425,21 +432,6 @@ func (d *DposContext) CommitTo(dbw *mtp.Database) (*DposContextProto, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := dbw.Commit(epochRoot, false); err != nil {
-		return nil, err
-	}
-	if err := dbw.Commit(delegateRoot, false); err != nil {
-		return nil, err
-	}
-	if err := dbw.Commit(voteRoot, false); err != nil {
-		return nil, err
-	}
-	if err := dbw.Commit(candidateRoot, false); err != nil {
-		return nil, err
-	}
-	if err := dbw.Commit(mintCntRoot, false); err != nil {
-		return nil, err
-	}
 	// fmt.Println("===Debug=====")
 	// fmt.Println("===CommitTo epochRoot 		===>", epochRoot.Hex())
 	// fmt.Println("===CommitTo delegateRoot	===>", delegateRoot.Hex())
@@ -0,0 +1,140 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+var (
+	// ErrMissingSignatures is returned if a multisig transaction carries no owner signature.
+	ErrMissingSignatures = errors.New("missing multisig owner signatures")
+	// ErrTooManySignatures is returned if a multisig transaction carries more
+	// signatures than a multisig account can have owners.
+	ErrTooManySignatures = errors.New("too many multisig owner signatures")
+	// ErrDuplicateSignature is returned if an owner signed a multisig transaction twice.
+	ErrDuplicateSignature = errors.New("duplicate multisig owner signature")
+	// ErrMultisigMismatch is returned when combining the signatures of different transactions.
+	ErrMultisigMismatch = errors.New("multisig transactions mismatch")
+)
+
+// MultiSignature carries the account sending a transaction on behalf of its
+// owners, and the signatures collected from them.
+type MultiSignature struct {
+	Account    utils.Address `json:"account"`
+	Signatures [][]byte      `json:"signatures"`
+}
+
+// MultiSignature returns the multisig account and the owner signatures of the
+// transaction, nil if it's signed by a single key.
+func (tx *Transaction) MultiSignature() *MultiSignature {
+	return tx.data.MultiSig
+}
+
+// OwnerSignatures returns the number of owner signatures of the transaction, zero
+// if it's signed by a single key.
+func (tx *Transaction) OwnerSignatures() int {
+	if tx.data.MultiSig == nil {
+		return 0
+	}
+	return len(tx.data.MultiSig.Signatures)
+}
+
+// WithMultisigAccount marks the transaction as sent from the multisig account,
+// the owners add their signatures through SignTx.
+func (tx *Transaction) WithMultisigAccount(account utils.Address) {
+	tx.data.Signature = nil
+	tx.data.MultiSig = &MultiSignature{Account: account}
+	tx.hash, tx.size, tx.from = atomic.Value{}, atomic.Value{}, atomic.Value{}
+}
+
+// signature returns the signature carrying the chain id of the transaction, the
+// first owner signature of a multisig one.
+func (tx *Transaction) signature() []byte {
+	if ms := tx.MultiSignature(); ms != nil {
+		if len(ms.Signatures) == 0 {
+			return nil
+		}
+		return ms.Signatures[0]
+	}
+	return tx.data.Signature
+}
+
+// Owners recovers the owners who signed the multisig transaction, every
+// signature must be valid and come from a distinct owner.
+func (s Signer) Owners(tx *Transaction) ([]utils.Address, error) {
+	ms := tx.MultiSignature()
+	if ms == nil || len(ms.Signatures) == 0 {
+		return nil, ErrMissingSignatures
+	}
+	if len(ms.Signatures) > params.MaxMultisigOwners {
+		return nil, ErrTooManySignatures
+	}
+	owners := make([]utils.Address, 0, len(ms.Signatures))
+	seen := make(map[utils.Address]bool)
+	for _, sig := range ms.Signatures {
+		owner, err := s.recover(tx, sig)
+		if err != nil {
+			return nil, err
+		}
+		if seen[owner] {
+			return nil, ErrDuplicateSignature
+		}
+		seen[owner] = true
+		owners = append(owners, owner)
+	}
+	return owners, nil
+}
+
+// CombineMultiSignatures merges the owner signatures collected separately for
+// the same multisig transaction into a single transaction.
+func CombineMultiSignatures(s Signer, txs ...*Transaction) (*Transaction, error) {
+	if len(txs) == 0 || txs[0].MultiSignature() == nil {
+		return nil, ErrMissingSignatures
+	}
+	hash := s.Hash(txs[0])
+	combined := &Transaction{data: txs[0].data}
+	combined.WithMultisigAccount(txs[0].MultiSignature().Account)
+
+	ms := combined.MultiSignature()
+	for _, tx := range txs {
+		if tx.MultiSignature() == nil || s.Hash(tx) != hash {
+			return nil, ErrMultisigMismatch
+		}
+		for _, sig := range tx.MultiSignature().Signatures {
+			known := false
+			for _, have := range ms.Signatures {
+				if bytes.Equal(have, sig) {
+					known = true
+					break
+				}
+			}
+			if !known {
+				ms.Signatures = append(ms.Signatures, utils.CopyBytes(sig))
+			}
+		}
+	}
+	if _, err := s.Owners(combined); err != nil {
+		return nil, err
+	}
+	return combined, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiSignature(t *testing.T) {
+	signer := NewSigner(big.NewInt(1))
+	account := utils.HexToAddress("0x1234")
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+
+	newTx := func() *Transaction {
+		tx := NewTransaction(Binary, 0, big.NewInt(10), 21000, big.NewInt(1), nil, &to)
+		tx.WithMultisigAccount(account)
+		return tx
+	}
+
+	// the owners sign copies of the transaction separately
+	tx1, tx2 := newTx(), newTx()
+	assert.NoError(t, tx1.SignTx(signer, key1))
+	assert.NoError(t, tx2.SignTx(signer, key2))
+
+	tx, err := CombineMultiSignatures(signer, tx1, tx2, tx1)
+	assert.NoError(t, err)
+	owners, err := signer.Owners(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []utils.Address{crypto.PubkeyToAddress(key1.PublicKey), crypto.PubkeyToAddress(key2.PublicKey)}, owners)
+
+	// the multisig account is the sender, also after an encoding round trip
+	enc, err := rlp.EncodeToBytes(tx)
+	assert.NoError(t, err)
+	dec := new(Transaction)
+	assert.NoError(t, rlp.DecodeBytes(enc, dec))
+	assert.Equal(t, tx.Hash(), dec.Hash())
+	from, err := dec.Sender(signer)
+	assert.NoError(t, err)
+	assert.Equal(t, account, from)
+
+	// an owner signs only once, and signatures of another chain are rejected
+	assert.NoError(t, tx1.SignTx(signer, key1))
+	_, err = signer.Owners(tx1)
+	assert.Equal(t, ErrDuplicateSignature, err)
+
+	other := newTx()
+	assert.NoError(t, other.SignTx(NewSigner(big.NewInt(2)), key1))
+	_, err = other.Sender(signer)
+	assert.Equal(t, ErrInvalidChainId, err)
+
+	// the signatures cover the multisig account
+	moved := newTx()
+	moved.WithMultisigAccount(utils.HexToAddress("0x5678"))
+	_, err = CombineMultiSignatures(signer, tx2, moved)
+	assert.Equal(t, ErrMultisigMismatch, err)
+}
+
+func TestMultiSignatureLimit(t *testing.T) {
+	signer := NewSigner(big.NewInt(1))
+	tx := NewTransaction(Binary, 0, big.NewInt(10), 21000, big.NewInt(1), nil, &to)
+	tx.WithMultisigAccount(utils.HexToAddress("0x1234"))
+
+	// the signatures are counted before any of them is recovered
+	ms := tx.MultiSignature()
+	for i := 0; i <= params.MaxMultisigOwners; i++ {
+		ms.Signatures = append(ms.Signatures, make([]byte, 65))
+	}
+	assert.Equal(t, params.MaxMultisigOwners+1, tx.OwnerSignatures())
+	assert.Equal(t, ErrTooManySignatures, tx.Validate(params.TestChainConfig, big.NewInt(1)))
+	_, err := signer.Owners(tx)
+	assert.Equal(t, ErrTooManySignatures, err)
+}
+
+func TestMultiSignatureEncoding(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := NewSigner(big.NewInt(1))
+
+	// the multisig field is omitted for the transactions signed by a single key
+	tx := NewTransaction(Binary, 0, big.NewInt(10), 21000, big.NewInt(1), nil, &to)
+	assert.NoError(t, tx.SignTx(signer, key))
+	enc, err := rlp.EncodeToBytes(tx)
+	assert.NoError(t, err)
+	dec := new(Transaction)
+	assert.NoError(t, rlp.DecodeBytes(enc, dec))
+	assert.Nil(t, dec.MultiSignature())
+	assert.Equal(t, tx.Hash(), dec.Hash())
+
+	// a single multisig entry is accepted, the trailing extra ones are rejected
+	tx.WithMultisigAccount(utils.HexToAddress("0x1234"))
+	assert.NoError(t, tx.SignTx(signer, key))
+	d := tx.data
+	fields := []interface{}{d.Type, d.Nonce, d.GasPrice, d.GasLimit, d.Tos, d.Value, d.Payload, d.Signature, d.MultiSig}
+	enc, err = rlp.EncodeToBytes(fields)
+	assert.NoError(t, err)
+	assert.NoError(t, rlp.DecodeBytes(enc, new(Transaction)))
+
+	enc, err = rlp.EncodeToBytes(append(fields, d.MultiSig))
+	assert.NoError(t, err)
+	assert.Error(t, rlp.DecodeBytes(enc, new(Transaction)))
+}
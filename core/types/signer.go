@@ -74,23 +74,61 @@ func isProtectedV(V *big.Int) bool {
 	return true
 }
 
-// Signer encapsulates transaction signature handling.
-type Signer struct{}
+// ErrInvalidChainId is returned if the transaction was signed for another chain.
+var ErrInvalidChainId = errors.New("invalid chain id for signer")
 
-// SignatureValues returns signature values. This signature  needs to be in the [R || S || V] format where V is 0 or 1.
+// Signer encapsulates transaction signature handling. The zero value is the
+// unprotected signer, which is kept to verify transactions signed before the
+// chain id was mixed into the signature.
+type Signer struct {
+	chainID, chainIDMul *big.Int
+}
+
+// NewSigner returns a signer protected from replay on chains with another id.
+func NewSigner(chainID *big.Int) Signer {
+	if chainID == nil {
+		return Signer{}
+	}
+	return Signer{
+		chainID:    new(big.Int).Set(chainID),
+		chainIDMul: new(big.Int).Mul(chainID, big.NewInt(2)),
+	}
+}
+
+// ChainID returns the chain id of the signer, nil for the unprotected signer.
+func (s Signer) ChainID() *big.Int {
+	if s.chainID == nil {
+		return nil
+	}
+	return new(big.Int).Set(s.chainID)
+}
+
+// Protected returns whether the signer mixes the chain id into signatures.
+func (s Signer) Protected() bool {
+	return s.chainID != nil
+}
+
+// SignatureValues returns signature values. The signature is in the [R || S || V] format,
+// V is the recovery id (0 or 1) of unprotected signatures, or the big endian encoding of
+// recovery id + 35 + 2 * chainID for protected ones.
 func (s Signer) SignatureValues(signature []byte) (r, sb, v *big.Int, err error) {
-	if len(signature) != 65 {
-		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(signature)))
+	if len(signature) < 65 {
+		return nil, nil, nil, fmt.Errorf("wrong size for signature: got %d, want at least 65", len(signature))
 	}
 	r = new(big.Int).SetBytes(signature[:32])
 	sb = new(big.Int).SetBytes(signature[32:64])
-	v = new(big.Int).SetBytes([]byte{signature[64] + 27})
+	if len(signature) == 65 && signature[64] < 27 {
+		v = new(big.Int).SetBytes([]byte{signature[64] + 27})
+	} else {
+		v = new(big.Int).SetBytes(signature[64:])
+	}
 	return r, sb, v, nil
 }
 
-// Hash returns the hash to be signed by the sender.
+// Hash returns the hash to be signed by the sender, the owners of a multisig
+// account sign its address along.
 func (s Signer) Hash(tx *Transaction) utils.Hash {
-	return rlpHash([]interface{}{
+	fields := []interface{}{
 		tx.data.Type,
 		tx.data.Nonce,
 		tx.data.GasPrice,
@@ -98,5 +136,56 @@ func (s Signer) Hash(tx *Transaction) utils.Hash {
 		tx.data.Tos,
 		tx.data.Value,
 		tx.data.Payload,
-	})
+	}
+	if s.Protected() {
+		fields = append(fields, s.chainID, uint(0), uint(0))
+	}
+	if ms := tx.MultiSignature(); ms != nil {
+		fields = append(fields, ms.Account)
+	}
+	return rlpHash(fields)
+}
+
+// signature converts a [R || S || V] signature with V as recovery id to the format of the signer.
+func (s Signer) signature(sig []byte) []byte {
+	if !s.Protected() {
+		return sig
+	}
+	v := new(big.Int).Add(s.chainIDMul, big.NewInt(int64(sig[64])+35))
+	return append(utils.CopyBytes(sig[:64]), v.Bytes()...)
+}
+
+// sender returns the address sending the transaction, the multisig account if
+// the owner signatures are all valid or the one recovered from the signature.
+func (s Signer) sender(tx *Transaction) (utils.Address, error) {
+	if ms := tx.MultiSignature(); ms != nil {
+		if _, err := s.Owners(tx); err != nil {
+			return utils.Address{}, err
+		}
+		return ms.Account, nil
+	}
+	return s.recover(tx, tx.data.Signature)
+}
+
+// recover recovers the address of the signature, it rejects protected signatures of
+// other chains. Unprotected signatures are accepted by every signer.
+func (s Signer) recover(tx *Transaction, signature []byte) (utils.Address, error) {
+	r, sb, v, err := s.SignatureValues(signature)
+	if err != nil {
+		return utils.Address{}, err
+	}
+	if !isProtectedV(v) {
+		return recoverPlain(Signer{}.Hash(tx), r, sb, v)
+	}
+	if v.Cmp(big.NewInt(35)) < 0 {
+		return utils.Address{}, ErrInvalidSig
+	}
+	id := chainID(v)
+	if s.Protected() && id.Cmp(s.chainID) != 0 {
+		return utils.Address{}, ErrInvalidChainId
+	}
+	signer := NewSigner(id)
+	v = new(big.Int).Sub(v, signer.chainIDMul)
+	v.Sub(v, big.NewInt(8))
+	return recoverPlain(signer.Hash(tx), r, sb, v)
 }
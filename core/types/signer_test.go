@@ -86,3 +86,35 @@ func TestRecoverPlain(t *testing.T) {
 	}
 	assert.Equal(t, testaddr, addr)
 }
+
+func TestProtectedSigner(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testPrivHex)
+	signer := NewSigner(big.NewInt(18))
+
+	tx := NewTransaction(Binary, 1, big.NewInt(10000), 1000, big.NewInt(10000), []byte("sign tx test"), &to)
+	assert.NoError(t, tx.SignTx(signer, key))
+
+	protected, err := tx.Protected(signer)
+	assert.NoError(t, err)
+	assert.True(t, protected)
+	id, err := tx.ChainID(signer)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(18), id)
+
+	from, err := tx.Sender(signer)
+	assert.NoError(t, err)
+	assert.Equal(t, testaddr, from)
+
+	// the cached sender must not bypass the chain id check
+	_, err = tx.Sender(NewSigner(big.NewInt(1)))
+	assert.Equal(t, ErrInvalidChainId, err)
+
+	// unprotected transactions are still accepted by protected signers
+	legacy := NewTransaction(Binary, 1, big.NewInt(10000), 1000, big.NewInt(10000), []byte("sign tx test"), &to)
+	assert.NoError(t, legacy.SignTx(Signer{}, key))
+	protected, _ = legacy.Protected(signer)
+	assert.False(t, protected)
+	from, err = legacy.Sender(signer)
+	assert.NoError(t, err)
+	assert.Equal(t, testaddr, from)
+}
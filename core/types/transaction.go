@@ -40,6 +40,7 @@ const (
 	Delegate
 	UnDelegate
 	Redeem
+	Multisig
 )
 
 var (
@@ -49,8 +50,15 @@ var (
 	ErrInvalidAddress = errors.New("invalid transaction payload address")
 	ErrInvalidAction  = errors.New("invalid transaction payload action")
 	ErrNotFound       = errors.New("not found")
+
+	ErrBatchPaymentDisabled  = errors.New("batch payments not enabled at the block height")
+	ErrInvalidPaymentAmounts = errors.New("invalid batch payment amounts")
+	ErrUnevenPayment         = errors.New("batch payment value not evenly divisible among the recipients")
 )
 
+// TransferEventTopic is the topic of the logs recording the transfers of a batch payment.
+var TransferEventTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
 // Transaction transaction
 type Transaction struct {
 	data txdata
@@ -70,6 +78,10 @@ type txdata struct {
 	Value     *big.Int         `json:"value"`
 	Payload   []byte           `json:"payload"`
 	Signature []byte           `json:"signature"`
+
+	// MultiSig holds the signatures of a transaction sent from a multisig
+	// account, it's nil for the ones signed by a single key.
+	MultiSig *MultiSignature `json:"multisig,omitempty" rlp:"optional"`
 }
 
 // NewTransaction new transaction
@@ -96,12 +108,30 @@ func NewTransaction(txType TxType, nonce uint64, value *big.Int, gasLimit uint64
 	return &Transaction{data: d}
 }
 
-// Validate Valid the transaction when the type isn't the binary
-func (tx *Transaction) Validate(cfg *params.ChainConfig) error {
+// Validate Valid the transaction when the type isn't the binary, height is the
+// one of the block the transaction is to be included in.
+func (tx *Transaction) Validate(cfg *params.ChainConfig, height *big.Int) error {
+	// a multisig transaction carries the owner signatures only
+	if ms := tx.data.MultiSig; ms != nil {
+		if len(tx.data.Signature) > 0 {
+			return ErrInvalidSig
+		}
+		if len(ms.Signatures) > params.MaxMultisigOwners {
+			return ErrTooManySignatures
+		}
+	}
 	switch tx.Type() {
 	case Binary:
-		if len(tx.Tos()) > 1 {
-			return errors.New("binary transaction tos need not greater than 1")
+		if cnt := len(tx.Tos()); cnt > params.MaxPaymentRecipients {
+			return fmt.Errorf("binary transaction tos need not greater than %v", params.MaxPaymentRecipients)
+		}
+		if tx.IsBatchPayment() {
+			if !cfg.IsBatchPayment(height) {
+				return ErrBatchPaymentDisabled
+			}
+			if _, err := tx.PaymentAmounts(); err != nil {
+				return err
+			}
 		}
 	case Delegate:
 		if cnt := int64(len(tx.Tos())); cnt > cfg.MaxVotes {
@@ -125,6 +155,14 @@ func (tx *Transaction) Validate(cfg *params.ChainConfig) error {
 		if tx.Value().Sign() != 0 {
 			return errors.New("LoginCandidate、LogoutCandidate、UnDelegate、Redeem tx.value wasn't required")
 		}
+	case Multisig:
+		// no recipient creates a multisig account, the sender updates its own one otherwise
+		if len(tx.Tos()) > 1 {
+			return errors.New("multisig transaction tos need not greater than 1")
+		}
+		if len(tx.Tos()) == 1 && tx.Value().Sign() != 0 {
+			return errors.New("multisig update transaction value wasn't required")
+		}
 	default:
 		return ErrInvalidType
 	}
@@ -145,6 +183,56 @@ func (tx *Transaction) Tos() []*utils.Address {
 	return tx.data.Tos
 }
 
+// IsBatchPayment reports whether the transaction is a Binary one paying several
+// recipients at once.
+func (tx *Transaction) IsBatchPayment() bool {
+	return tx.Type() == Binary && len(tx.Tos()) > 1
+}
+
+// PaymentAmounts returns the amount paid to each recipient of a batch payment.
+// The payload lists the amounts in RLP, the value is split evenly if it's empty.
+func (tx *Transaction) PaymentAmounts() ([]*big.Int, error) {
+	tos := tx.Tos()
+	if len(tx.data.Payload) == 0 {
+		amount, rem := new(big.Int).QuoRem(tx.data.Value, big.NewInt(int64(len(tos))), new(big.Int))
+		if rem.Sign() != 0 {
+			return nil, ErrUnevenPayment
+		}
+		amounts := make([]*big.Int, len(tos))
+		for i := range amounts {
+			amounts[i] = new(big.Int).Set(amount)
+		}
+		return amounts, nil
+	}
+	var amounts []*big.Int
+	if err := rlp.DecodeBytes(tx.data.Payload, &amounts); err != nil || len(amounts) != len(tos) {
+		return nil, ErrInvalidPaymentAmounts
+	}
+	sum := new(big.Int)
+	for _, amount := range amounts {
+		sum.Add(sum, amount)
+	}
+	if sum.Cmp(tx.data.Value) != 0 {
+		return nil, ErrInvalidPaymentAmounts
+	}
+	return amounts, nil
+}
+
+// EncodePaymentAmounts encodes the per-recipient amounts of a batch payment into
+// the payload of the transaction.
+func EncodePaymentAmounts(amounts []*big.Int) ([]byte, error) {
+	return rlp.EncodeToBytes(amounts)
+}
+
+// NewTransferLog creates the log recording a transfer of a batch payment.
+func NewTransferLog(from, to utils.Address, amount *big.Int) *Log {
+	return &Log{
+		Address: from,
+		Topics:  []utils.Hash{TransferEventTopic, from.Hash(), to.Hash()},
+		Data:    utils.LeftPadBytes(amount.Bytes(), 32),
+	}
+}
+
 // Cost returns value + gasprice * gaslimit.
 func (tx *Transaction) Cost() *big.Int {
 	total := new(big.Int).Mul(tx.data.GasPrice, new(big.Int).SetUint64(tx.data.GasLimit))
@@ -194,27 +282,35 @@ func (tx *Transaction) WithSignature(signature []byte) {
 	tx.data.Signature = utils.CopyBytes(signature)
 }
 
-// SignTx signs the transaction using the given signer and private key
+// SignTx signs the transaction using the given signer and private key, the
+// signature is added to the ones of the owners if it's sent from a multisig account.
 func (tx *Transaction) SignTx(s Signer, prv *ecdsa.PrivateKey) error {
 	h := s.Hash(tx)
 	sig, err := crypto.Sign(h[:], prv)
 	if err != nil {
 		return err
 	}
-	tx.WithSignature(sig)
+	if ms := tx.MultiSignature(); ms != nil {
+		ms.Signatures = append(ms.Signatures, s.signature(sig))
+		tx.hash, tx.size, tx.from = atomic.Value{}, atomic.Value{}, atomic.Value{}
+		return nil
+	}
+	tx.WithSignature(s.signature(sig))
 	return nil
 }
 
 // Sender sender address of the transaction using the given signer
 func (tx *Transaction) Sender(signer Signer) (utils.Address, error) {
+	if signer.Protected() {
+		// the cached sender doesn't tell for which chain the transaction was signed
+		if _, _, v, err := signer.SignatureValues(tx.signature()); err == nil && isProtectedV(v) && chainID(v).Cmp(signer.chainID) != 0 {
+			return utils.Address{}, ErrInvalidChainId
+		}
+	}
 	if sender := tx.from.Load(); sender != nil {
 		return sender.(utils.Address), nil
 	}
-	r, s, v, err := signer.SignatureValues(tx.data.Signature)
-	if err != nil {
-		return utils.Address{}, err
-	}
-	addr, err := recoverPlain(signer.Hash(tx), r, s, v)
+	addr, err := signer.sender(tx)
 	if err != nil {
 		return utils.Address{}, err
 	}
@@ -224,16 +320,19 @@ func (tx *Transaction) Sender(signer Signer) (utils.Address, error) {
 
 // ChainID returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainID(signer Signer) (*big.Int, error) {
-	_, _, v, err := signer.SignatureValues(tx.data.Signature)
+	_, _, v, err := signer.SignatureValues(tx.signature())
 	if err != nil {
 		return nil, err
 	}
+	if !isProtectedV(v) {
+		return new(big.Int), nil
+	}
 	return chainID(v), err
 }
 
 // Protected returns whether the transaction is protected from replay protection.
 func (tx *Transaction) Protected(signer Signer) (bool, error) {
-	_, _, v, err := signer.SignatureValues(tx.data.Signature)
+	_, _, v, err := signer.SignatureValues(tx.signature())
 	if err != nil {
 		return false, err
 	}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,3 +64,39 @@ func decodeTx(data []byte) (*Transaction, error) {
 	var tx Transaction
 	return &tx, rlp.Decode(bytes.NewReader(data), &tx)
 }
+
+func TestBatchPaymentAmounts(t *testing.T) {
+	to1, to2 := utils.HexToAddress("0x01"), utils.HexToAddress("0x02")
+
+	// an empty payload splits the value evenly
+	tx := NewTransaction(Binary, 0, big.NewInt(10), 50000, big.NewInt(1), nil, &to1, &to2)
+	amounts, err := tx.PaymentAmounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(5), big.NewInt(5)}, amounts)
+	assert.NoError(t, tx.Validate(params.TestChainConfig, big.NewInt(1)))
+	// the batch payments are enabled from the fork height
+	config := *params.TestChainConfig
+	config.BatchPaymentBlock = big.NewInt(2)
+	assert.Equal(t, ErrBatchPaymentDisabled, tx.Validate(&config, big.NewInt(1)))
+	assert.NoError(t, tx.Validate(&config, big.NewInt(2)))
+	config.BatchPaymentBlock = nil
+	assert.Equal(t, ErrBatchPaymentDisabled, tx.Validate(&config, big.NewInt(2)))
+
+	tx = NewTransaction(Binary, 0, big.NewInt(11), 50000, big.NewInt(1), nil, &to1, &to2)
+	_, err = tx.PaymentAmounts()
+	assert.Equal(t, ErrUnevenPayment, err)
+	assert.Equal(t, ErrUnevenPayment, tx.Validate(params.TestChainConfig, big.NewInt(1)))
+
+	// the payload lists the amounts, they must add up to the value
+	payload, _ := EncodePaymentAmounts([]*big.Int{big.NewInt(3), big.NewInt(8)})
+	tx = NewTransaction(Binary, 0, big.NewInt(11), 50000, big.NewInt(1), payload, &to1, &to2)
+	amounts, err = tx.PaymentAmounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(3), big.NewInt(8)}, amounts)
+
+	tx = NewTransaction(Binary, 0, big.NewInt(12), 50000, big.NewInt(1), payload, &to1, &to2)
+	assert.Equal(t, ErrInvalidPaymentAmounts, tx.Validate(params.TestChainConfig, big.NewInt(1)))
+
+	tx = NewTransaction(Binary, 0, big.NewInt(11), 50000, big.NewInt(1), payload, &to1, &to2, &to)
+	assert.Equal(t, ErrInvalidPaymentAmounts, tx.Validate(params.TestChainConfig, big.NewInt(1)))
+}
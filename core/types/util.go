@@ -147,3 +147,46 @@ func CalcGasLimit(parent *Block) uint64 {
 	}
 	return limit
 }
+
+// CalcMinGasPrice computes the minimum gas price of the next block after parent,
+// it moves by up to 1/8 towards keeping the blocks half full and never falls
+// under the configured floor. It returns nil if the dynamic pricing is disabled.
+func CalcMinGasPrice(config *params.ChainConfig, parent *BlockHeader) *big.Int {
+	if config.MinGasPrice == nil {
+		return nil
+	}
+	// the floor is kept positive, a zero price would encode as a missing one
+	floor := config.MinGasPrice
+	if floor.Sign() <= 0 {
+		floor = big.NewInt(1)
+	}
+	price := parent.MinGasPrice
+	if price == nil || price.Cmp(floor) < 0 {
+		price = floor
+	}
+	target := parent.GasLimit / params.MinGasPriceElasticity
+	if target == 0 || parent.GasUsed == target {
+		return new(big.Int).Set(price)
+	}
+	var delta uint64
+	if parent.GasUsed > target {
+		delta = parent.GasUsed - target
+	} else {
+		delta = target - parent.GasUsed
+	}
+	// change = price * delta / target / 8
+	change := new(big.Int).Mul(price, new(big.Int).SetUint64(delta))
+	change.Div(change, new(big.Int).SetUint64(target))
+	change.Div(change, new(big.Int).SetUint64(params.MinGasPriceChangeDenominator))
+
+	if parent.GasUsed > target {
+		if change.Sign() == 0 {
+			change.SetUint64(1)
+		}
+		return change.Add(price, change)
+	}
+	if change.Sub(price, change); change.Cmp(floor) < 0 {
+		change.Set(floor)
+	}
+	return change
+}
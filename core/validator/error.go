@@ -18,11 +18,11 @@ package validator
 
 import (
 	"errors"
-	"fmt"
 	"math/big"
 
 	"github.com/UranusBlockStack/uranus/common/bloom"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus"
 )
 
 var (
@@ -35,56 +35,64 @@ var (
 	// that is unknown.
 	ErrUnknownAncestor = errors.New("unknown ancestor")
 	// ErrBlockTime timestamp less than or equal to parent's
-	ErrBlockTime = errors.New("timestamp less than or equal to parent's")
+	ErrBlockTime = consensus.NewInvalidError("timestamp less than or equal to parent's")
 	// ErrInvalidNumber is returned if a block's number doesn't equal it's parent's
 	// plus one.
-	ErrInvalidNumber = errors.New("invalid block number")
+	ErrInvalidNumber = consensus.ErrInvalidNumber
 	// ErrFutureBlock is returned when a block's timestamp is in the future according
 	// to the current node.
 	ErrFutureBlock = errors.New("block in the future")
 	// ErrExtraDataTooLong is returned when extra-data too long
 	ErrExtraDataTooLong = func(actual, expected uint64) error {
-		return fmt.Errorf("extra-data too long: %d > %d", actual, expected)
+		return consensus.NewInvalidError("extra-data too long: %d > %d", actual, expected)
 	}
 	// ErrDifficulty is returned invalid difficulty
 	ErrDifficulty = func(actual, expected *big.Int) error {
-		return fmt.Errorf("invalid difficulty: have %v, want %v", actual, expected)
+		return consensus.NewInvalidError("invalid difficulty: have %v, want %v", actual, expected)
 	}
 	// ErrGasLimitTooBig is returned invalid gasLimit,the gas limit is > 2^63-1
 	ErrGasLimitTooBig = func(actual, expected uint64) error {
-		return fmt.Errorf("invalid max gaslimit: have %v, max %v", actual, expected)
+		return consensus.NewInvalidError("invalid max gaslimit: have %v, max %v", actual, expected)
 	}
 	// ErrGasUsed is returned invalid gasUsed
 	ErrGasUsed = func(actual, expected uint64) error {
-		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", actual, expected)
+		return consensus.NewInvalidError("invalid gasUsed: have %d, gasLimit %d", actual, expected)
 	}
 	// ErrGasLimit is returned invalid gaslimit
 	ErrGasLimit = func(actual, expected, extra uint64) error {
-		return fmt.Errorf("invalid gaslimit: have %d, want %d += %d", actual, expected, extra)
+		return consensus.NewInvalidError("invalid gaslimit: have %d, want %d += %d", actual, expected, extra)
+	}
+	// ErrMinGasPrice is returned invalid minimum gas price
+	ErrMinGasPrice = func(actual, expected *big.Int) error {
+		return consensus.NewInvalidError("invalid minimum gas price: have %v, want %v", actual, expected)
+	}
+	// ErrTxGasPrice is returned if a transaction pays under the minimum gas price
+	ErrTxGasPrice = func(hash utils.Hash, actual, expected *big.Int) error {
+		return consensus.NewInvalidError("transaction %x gas price under the minimum: have %v, want %v", hash, actual, expected)
 	}
 	// ErrTxsRootHash is returned invalid txs root hash
 	ErrTxsRootHash = func(actual, expected utils.Hash) error {
-		return fmt.Errorf("transaction txs root hash mismatch: have %x, want %x", actual, expected)
+		return consensus.NewInvalidError("transaction txs root hash mismatch: have %x, want %x", actual, expected)
 	}
 
 	// ErrReceiptRootHash is returned invalid receiptroot hash
 	ErrReceiptRootHash = func(actual, expected utils.Hash) error {
-		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", actual, expected)
+		return consensus.NewInvalidError("invalid receipt root hash (remote: %x local: %x)", actual, expected)
 	}
 	// ErrStateRootHash is returned invalid stateroot hash
 	ErrStateRootHash = func(actual, expected utils.Hash) error {
-		return fmt.Errorf("transaction state root hash mismatch: have %x, want %x", actual, expected)
+		return consensus.NewInvalidError("transaction state root hash mismatch: have %x, want %x", actual, expected)
 	}
 	// ErrDposRootHash is returned invalid stateroot hash
 	ErrDposRootHash = func(actual, expected utils.Hash) error {
-		return fmt.Errorf("dpos state root hash mismatch: have %s, want %s", actual.String(), expected.String())
+		return consensus.NewInvalidError("dpos state root hash mismatch: have %s, want %s", actual.String(), expected.String())
 	}
 	// ErrLogsBloom is returned invalid logs bloom
 	ErrLogsBloom = func(actual, expected bloom.Bloom) error {
-		return fmt.Errorf("invalid logs bloom (remote: %x  local: %x)", actual, expected)
+		return consensus.NewInvalidError("invalid logs bloom (remote: %x  local: %x)", actual, expected)
 	}
 	// ErrLocalGasUsed is returned invalid local gas used
 	ErrLocalGasUsed = func(actual, expected uint64) error {
-		return fmt.Errorf("invalid gas used (remote: %d local: %d)", actual, expected)
+		return consensus.NewInvalidError("invalid gas used (remote: %d local: %d)", actual, expected)
 	}
 )
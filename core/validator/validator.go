@@ -17,7 +17,6 @@
 package validator
 
 import (
-	"fmt"
 	"math/big"
 
 	"github.com/UranusBlockStack/uranus/consensus"
@@ -86,6 +85,11 @@ func (v *Validator) ValidateHeader(chain consensus.IChainReader, header *types.B
 	if uint64(diff) >= limit || header.GasLimit < params.MinGasLimit {
 		return ErrGasLimit(header.GasLimit, parent.GasLimit, limit)
 	}
+	// Verify the minimum gas price follows the congestion of the parent
+	expectedPrice := types.CalcMinGasPrice(chain.Config(), parent)
+	if (expectedPrice == nil) != (header.MinGasPrice == nil) || expectedPrice != nil && expectedPrice.Cmp(header.MinGasPrice) != 0 {
+		return ErrMinGasPrice(header.MinGasPrice, expectedPrice)
+	}
 	// Verify that the block number is parent's +1
 	if diff := new(big.Int).Sub(header.Height, parent.Height); diff.Cmp(big.NewInt(1)) != 0 {
 		return ErrInvalidNumber
@@ -125,6 +129,15 @@ func (v *Validator) ValidateTxs(block *types.Block) error {
 		return ErrTxsRootHash(root, header.TransactionsRoot)
 	}
 
+	// check txs pay the minimum gas price of the block
+	if header.MinGasPrice != nil {
+		for _, tx := range block.Transactions() {
+			if tx.GasPrice().Cmp(header.MinGasPrice) < 0 {
+				return ErrTxGasPrice(tx.Hash(), tx.GasPrice(), header.MinGasPrice)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -140,7 +153,7 @@ func (v *Validator) ValidateState(
 
 	header := block.BlockHeader()
 	if block.GasUsed() != usedGas {
-		return fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), usedGas)
+		return ErrLocalGasUsed(block.GasUsed(), usedGas)
 	}
 	// check the received block's bloom with the one derived from the generated receipts For valid blocks this should always validate to true.
 	rbloom := types.CreateBloom(receipts)
@@ -0,0 +1,238 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+// CallFrame is a single call of the call tree built by the CallTracer.
+type CallFrame struct {
+	Type    string        `json:"type"`
+	From    utils.Address `json:"from"`
+	To      utils.Address `json:"to"`
+	Value   *utils.Big    `json:"value,omitempty"`
+	Gas     utils.Uint64  `json:"gas"`
+	GasUsed utils.Uint64  `json:"gasUsed"`
+	Input   utils.Bytes   `json:"input"`
+	Output  utils.Bytes   `json:"output,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Calls   []*CallFrame  `json:"calls,omitempty"`
+
+	gasIn   uint64 // gas available to the caller before the call opcode
+	gasCost uint64 // cost of the call opcode, including the gas handed to the callee
+	outOff  int64  // memory offset of the return data of a call
+	outLen  int64  // memory size of the return data of a call
+}
+
+// CallTracer is an EVM state logger and implements Tracer.
+//
+// CallTracer builds the tree of the internal CALL, CALLCODE, DELEGATECALL,
+// STATICCALL, CREATE and SELFDESTRUCT frames of a transaction, which makes the
+// internal value transfers visible.
+type CallTracer struct {
+	callstack []*CallFrame
+	// descended is set when a call has just been entered, the first step of the
+	// callee reveals the gas it was handed
+	descended bool
+}
+
+// NewCallTracer returns a new call tracer.
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+func (t *CallTracer) CaptureStart(from utils.Address, to utils.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	frame := &CallFrame{
+		Type:  CALL.String(),
+		From:  from,
+		To:    to,
+		Gas:   utils.Uint64(gas),
+		Input: utils.CopyBytes(input),
+	}
+	if create {
+		frame.Type = CREATE.String()
+	}
+	if value != nil {
+		frame.Value = (*utils.Big)(new(big.Int).Set(value))
+	}
+	t.callstack = []*CallFrame{frame}
+	return nil
+}
+
+// CaptureState tracks the call opcodes to open new frames and the depth of the
+// steps to close them once the callee returned.
+func (t *CallTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	if err != nil {
+		return t.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	if len(t.callstack) == 0 {
+		return nil
+	}
+	// the first step of the callee tells how much gas was handed to it
+	if t.descended {
+		if depth >= len(t.callstack) {
+			t.callstack[len(t.callstack)-1].Gas = utils.Uint64(gas)
+		}
+		t.descended = false
+	}
+	// back in the caller, close the frame of the callee
+	if depth == len(t.callstack)-1 {
+		t.exit(env, gas, memory, stack)
+	}
+
+	switch op {
+	case CREATE:
+		offset, size := stack.Back(1).Int64(), stack.Back(2).Int64()
+		t.enter(&CallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			Input:   memory.Get(offset, size),
+			Value:   (*utils.Big)(new(big.Int).Set(stack.Back(0))),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+		to := utils.BigToAddress(stack.Back(1))
+		off := 1
+		if op == DELEGATECALL || op == STATICCALL {
+			off = 0
+		}
+		frame := &CallFrame{
+			Type:    op.String(),
+			From:    contract.Address(),
+			To:      to,
+			Input:   memory.Get(stack.Back(2+off).Int64(), stack.Back(3+off).Int64()),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stack.Back(4 + off).Int64(),
+			outLen:  stack.Back(5 + off).Int64(),
+		}
+		if op == CALL || op == CALLCODE {
+			frame.Value = (*utils.Big)(new(big.Int).Set(stack.Back(2)))
+		}
+		// a precompiled contract runs no steps, the gas it is handed is known
+		// from the call opcode
+		if _, ok := PrecompiledContractsByzantium[to]; ok {
+			frame.Gas = utils.Uint64(env.callGasTemp)
+			if frame.Value != nil && frame.Value.ToInt().Sign() != 0 {
+				frame.Gas += utils.Uint64(params.CallStipend)
+			}
+		}
+		t.enter(frame)
+	case SELFDESTRUCT:
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    utils.BigToAddress(stack.Back(0)),
+			Value: (*utils.Big)(new(big.Int).Set(env.StateDB.GetBalance(contract.Address()))),
+		})
+	case REVERT:
+		t.callstack[len(t.callstack)-1].Error = errExecutionReverted.Error()
+	}
+	return nil
+}
+
+// CaptureFault records the error of the current frame and closes it, the
+// callee consumed all the gas it was handed.
+func (t *CallTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	if len(t.callstack) == 0 {
+		return nil
+	}
+	top := t.callstack[len(t.callstack)-1]
+	if t.descended && depth >= len(t.callstack) {
+		top.Gas = utils.Uint64(gas)
+	}
+	t.descended = false
+	// reverted frames are closed by the caller, they keep the gas left
+	if top.Error != "" {
+		return nil
+	}
+	top.Error = err.Error()
+	if len(t.callstack) == 1 {
+		return nil
+	}
+	top.GasUsed = top.Gas
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, top)
+	return nil
+}
+
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if len(t.callstack) == 0 {
+		return nil
+	}
+	top := t.callstack[0]
+	top.GasUsed = utils.Uint64(gasUsed)
+	top.Output = utils.CopyBytes(output)
+	if err != nil {
+		top.Error = err.Error()
+	}
+	return nil
+}
+
+// enter opens a new frame for a call made by the current frame.
+func (t *CallTracer) enter(frame *CallFrame) {
+	t.callstack = append(t.callstack, frame)
+	t.descended = true
+}
+
+// exit closes the current frame, the call result has been pushed on the stack
+// of the caller.
+func (t *CallTracer) exit(env *EVM, gas uint64, memory *Memory, stack *Stack) {
+	frame := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	ret := stack.Back(0)
+	if frame.Type == CREATE.String() {
+		frame.GasUsed = utils.Uint64(frame.gasIn - frame.gasCost - gas)
+		if ret.Sign() != 0 {
+			frame.To = utils.BigToAddress(ret)
+			frame.Output = env.StateDB.GetCode(frame.To)
+		} else if frame.Error == "" {
+			frame.Error = "internal failure"
+		}
+	} else {
+		// a call to an account without code never reports its gas, it used none
+		if frame.Gas == 0 {
+			frame.Gas = utils.Uint64(gas + frame.gasCost - frame.gasIn)
+		}
+		frame.GasUsed = utils.Uint64(frame.gasIn - frame.gasCost + uint64(frame.Gas) - gas)
+		if ret.Sign() != 0 {
+			frame.Output = memory.Get(frame.outOff, frame.outLen)
+		} else if frame.Error == "" {
+			frame.Error = "internal failure"
+		}
+	}
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, frame)
+}
+
+// Result returns the call tree of the traced transaction, nil if the
+// transaction did not run in the EVM.
+func (t *CallTracer) Result() *CallFrame {
+	if len(t.callstack) == 0 {
+		return nil
+	}
+	return t.callstack[0]
+}
@@ -70,6 +70,7 @@ type Context struct {
 	BlockNumber *big.Int      // Provides information for NUMBER
 	Time        *big.Int      // Provides information for TIME
 	Difficulty  *big.Int      // Provides information for DIFFICULTY
+	MinGasPrice *big.Int      // Minimum gas price of the block, nil if not dynamic
 }
 
 // EVM is the Ethereum Virtual Machine base object and provides
@@ -26,6 +26,7 @@ import (
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/state"
 	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/params"
 )
 
 func TestDefaults(t *testing.T) {
@@ -116,6 +117,64 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestCallTracer(t *testing.T) {
+	state, _ := state.New(utils.Hash{}, state.NewDatabase(mdb.New()))
+	var (
+		address  = utils.HexToAddress("0x0a")
+		receiver = utils.HexToAddress("0xbb")
+		reverter = utils.HexToAddress("0x0c")
+	)
+	state.AddBalance(address, big.NewInt(10))
+	state.SetCode(reverter, []byte{
+		byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 0,
+		byte(vm.REVERT),
+	})
+	state.SetCode(address, []byte{
+		// transfer 1 to the receiver
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0xbb, byte(vm.PUSH2), 0xff, 0xff,
+		byte(vm.CALL), byte(vm.POP),
+		// call the reverter
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0x0c, byte(vm.PUSH2), 0xff, 0xff,
+		byte(vm.CALL), byte(vm.POP),
+		// transfer 2 to the identity precompile
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 2, byte(vm.PUSH1), 0x04, byte(vm.PUSH2), 0xff, 0xff,
+		byte(vm.CALL), byte(vm.POP),
+		byte(vm.STOP),
+	})
+
+	tracer := vm.NewCallTracer()
+	_, _, err := Call(address, nil, &Config{State: state, GasLimit: 100000, EVMConfig: vm.Config{Debug: true, Tracer: tracer}})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+
+	result := tracer.Result()
+	if result == nil || result.To != address {
+		t.Fatalf("unexpected top level frame %v", result)
+	}
+	if len(result.Calls) != 3 {
+		t.Fatalf("expected 3 internal calls, got %d", len(result.Calls))
+	}
+	transfer, reverted, precompiled := result.Calls[0], result.Calls[1], result.Calls[2]
+	if transfer.Type != "CALL" || transfer.From != address || transfer.To != receiver || transfer.Value.ToInt().Int64() != 1 || transfer.Error != "" {
+		t.Errorf("unexpected transfer frame %+v", transfer)
+	}
+	if reverted.To != reverter || reverted.Error != "evm: execution reverted" || reverted.GasUsed == 0 {
+		t.Errorf("unexpected reverted frame %+v", reverted)
+	}
+	identity := utils.BytesToAddress([]byte{4})
+	if precompiled.To != identity || precompiled.Value.ToInt().Int64() != 2 || precompiled.GasUsed != utils.Uint64(params.IdentityBaseGas) || precompiled.Gas <= utils.Uint64(params.CallStipend) || precompiled.Error != "" {
+		t.Errorf("unexpected precompiled frame %+v", precompiled)
+	}
+	if state.GetBalance(receiver).Int64() != 1 {
+		t.Errorf("expected receiver balance 1, got %v", state.GetBalance(receiver))
+	}
+}
+
 // func BenchmarkCall(b *testing.B) {
 // 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -23,6 +23,7 @@ import (
 	"runtime"
 
 	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/metrics"
 	"github.com/fjl/memsize/memsizeui"
 )
 
@@ -36,6 +37,9 @@ type Config struct {
 	Blockprofilerate int    `mapstructure:"debug-blockprofilerate"`
 	Cpuprofile       string `mapstructure:"debug-cpuprofile"`
 	Trace            string `mapstructure:"debug-trace"`
+	Metrics          bool   `mapstructure:"debug-metrics"`
+	MetricsPort      int    `mapstructure:"debug-metricsport"`
+	MetricsAddr      string `mapstructure:"debug-metricsaddr"`
 }
 
 func DefaultConfig() *Config {
@@ -44,6 +48,8 @@ func DefaultConfig() *Config {
 		PprofPort:      6060,
 		PprofAddr:      "localhost",
 		Memprofilerate: runtime.MemProfileRate,
+		MetricsPort:    6061,
+		MetricsAddr:    "localhost",
 	}
 }
 
@@ -69,6 +75,11 @@ func Setup(debugCfg *Config) error {
 		address := fmt.Sprintf("%s:%d", debugCfg.PprofAddr, debugCfg.PprofPort)
 		StartPProf(address)
 	}
+
+	// prometheus metrics server
+	if debugCfg.Metrics {
+		metrics.StartHTTP(fmt.Sprintf("%s:%d", debugCfg.MetricsAddr, debugCfg.MetricsPort))
+	}
 	return nil
 }
 
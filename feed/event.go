@@ -30,6 +30,8 @@ type NewTxsEvent struct{ Txs []*types.Transaction }
 
 type ForkBlockEvent struct{ Block *types.Block }
 
+type RemovedLogsEvent struct{ Logs []*types.Log }
+
 type NewMiner struct {
 }
 
@@ -0,0 +1,505 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+const forceSyncCycle = 10 * time.Second
+
+var (
+	errTimeout = errors.New("request timeout")
+	errClosed  = errors.New("light client closed")
+	errNoServe = errors.New("peer does not serve light clients")
+
+	emptyRoot = types.DeriveRootHash(types.Transactions{})
+)
+
+type pendingRequest struct {
+	peer *peer
+	req  request
+	done chan error
+}
+
+// ClientManager runs the light protocol of a light client. It keeps the header
+// chain in sync with the best server and retrieves the rest of the chain data on
+// demand.
+type ClientManager struct {
+	networkId    uint64
+	chain        *HeaderChain
+	stateDb      state.Database
+	maxPeers     int
+	peers        *peerSet
+	SubProtocols []*p2p.Protocol
+
+	reqID       uint64
+	pendingLock sync.Mutex
+	pending     map[uint64]*pendingRequest
+
+	syncCh chan *peer
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewClientManager creates the light client on the chain stored in chainDb, the
+// genesis must have been set up already. The engine checking the seal of the
+// headers is created on the state database retrieving the missing trie nodes from
+// the servers, as the validators are elected by the state of the epochs.
+func NewClientManager(cfg *ledger.Config, config *params.ChainConfig, chainDb db.Database, newEngine func(state.Database) consensus.Engine) (*ClientManager, error) {
+	cm := &ClientManager{
+		peers:   newPeerSet(),
+		pending: make(map[uint64]*pendingRequest),
+		syncCh:  make(chan *peer, 1),
+		quit:    make(chan struct{}),
+	}
+	cm.stateDb = state.NewDatabase(&odrDatabase{Database: chainDb, retrieve: cm.GetNodeData})
+
+	chain, err := NewHeaderChain(cfg, config, chainDb, newEngine(cm.stateDb))
+	if err != nil {
+		return nil, err
+	}
+	cm.chain = chain
+
+	cm.SubProtocols = []*p2p.Protocol{{
+		Name:    ProtocolName,
+		Version: protocolVersion,
+		Offset:  protocolOffset,
+		Size:    protocolSize,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			cm.wg.Add(1)
+			defer cm.wg.Done()
+			return cm.handle(newPeer(int(protocolVersion), p, rw))
+		},
+		PeerInfo: func(id discover.NodeID) interface{} {
+			if p := cm.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+				return p.Info()
+			}
+			return nil
+		},
+	}}
+	return cm, nil
+}
+
+// HeaderChain returns the chain of the verified headers.
+func (cm *ClientManager) HeaderChain() *HeaderChain {
+	return cm.chain
+}
+
+// StateDatabase returns the state database retrieving the missing trie nodes from
+// the servers.
+func (cm *ClientManager) StateDatabase() state.Database {
+	return cm.stateDb
+}
+
+// Start starts syncing the header chain.
+func (cm *ClientManager) Start(maxPeers int) {
+	cm.maxPeers = maxPeers
+	go cm.syncer()
+}
+
+// Stop aborts the pending retrievals and disconnects the servers.
+func (cm *ClientManager) Stop() {
+	log.Info("Stopping uranus light client")
+	close(cm.quit)
+	cm.peers.Close()
+	cm.wg.Wait()
+	log.Info("uranus light client stopped")
+}
+
+func (cm *ClientManager) handle(p *peer) error {
+	if cm.peers.Len() >= cm.maxPeers && !p.Trusted() {
+		return fmt.Errorf("too many peer")
+	}
+	var (
+		head = cm.chain.CurrentHeader()
+		hash = head.Hash()
+		td   = cm.chain.GetTd(hash)
+	)
+	if err := p.Handshake(cm.networkId, td, hash, head.Height.Uint64(), cm.chain.Genesis().Hash(), false); err != nil {
+		log.Debugf("uranus light handshake failed --- %v", err)
+		return err
+	}
+	if !p.serve {
+		return errNoServe
+	}
+	if err := cm.peers.Register(p); err != nil {
+		return err
+	}
+	defer cm.peers.Unregister(p.id)
+	log.Debugf("uranus light server connected name %v", p.Name())
+	cm.triggerSync(p)
+
+	for {
+		if err := cm.handleMsg(p); err != nil {
+			log.Debugf("uranus light message handling failed --- %v", err)
+			return err
+		}
+	}
+}
+
+func (cm *ClientManager) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+
+	switch msg.Code {
+	case StatusMsg:
+		return fmt.Errorf("uncontrolled status message")
+
+	case AnnounceMsg:
+		var announce announceData
+		if err := msg.DecodePayload(&announce); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		if announce.TD == nil {
+			return fmt.Errorf("msg %v: missing total difficulty", msg)
+		}
+		p.SetHead(announce.Hash, announce.Height, announce.TD)
+		if announce.TD.Cmp(cm.chain.GetTd(cm.chain.CurrentHeader().Hash())) > 0 {
+			cm.triggerSync(p)
+		}
+
+	case BlockHeadersMsg:
+		var packet blockHeadersPacket
+		if err := msg.DecodePayload(&packet); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		return cm.deliver(p, packet.ReqID, &packet)
+
+	case BlockBodiesMsg:
+		var packet blockBodiesPacket
+		if err := msg.DecodePayload(&packet); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		return cm.deliver(p, packet.ReqID, &packet)
+
+	case ReceiptsMsg:
+		var packet receiptsPacket
+		if err := msg.DecodePayload(&packet); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		return cm.deliver(p, packet.ReqID, &packet)
+
+	case NodeDataMsg:
+		var packet nodeDataPacket
+		if err := msg.DecodePayload(&packet); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		return cm.deliver(p, packet.ReqID, &packet)
+
+	case TxLookupsMsg:
+		var packet txLookupsPacket
+		if err := msg.DecodePayload(&packet); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		return cm.deliver(p, packet.ReqID, &packet)
+
+	case GetBlockHeadersMsg, GetBlockBodiesMsg, GetReceiptsMsg, GetNodeDataMsg, GetTxLookupsMsg, SendTxMsg:
+		// A light client has no data to serve, its status tells so to the peers
+
+	default:
+		return fmt.Errorf("invalid message code %v", msg.Code)
+	}
+	return nil
+}
+
+// retrieve asks the servers whose head reaches the given height in turn until one
+// of them answers the request.
+func (cm *ClientManager) retrieve(ctx context.Context, height uint64, req request) error {
+	var (
+		tried   = make(map[string]bool)
+		lastErr error
+	)
+	for {
+		p := cm.peers.BestServer(height, tried)
+		if p == nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			return errNoPeers
+		}
+		tried[p.id] = true
+
+		err := cm.retrieveFrom(ctx, p, req)
+		switch err {
+		case nil, ctx.Err(), errClosed:
+			return err
+		}
+		log.Debugf("Light retrieval from peer %v failed: %v", p.id, err)
+		lastErr = err
+	}
+}
+
+// retrieveFrom sends the request to the peer and waits for its response.
+func (cm *ClientManager) retrieveFrom(ctx context.Context, p *peer, req request) error {
+	id := atomic.AddUint64(&cm.reqID, 1)
+	done := make(chan error, 1)
+
+	cm.pendingLock.Lock()
+	cm.pending[id] = &pendingRequest{peer: p, req: req, done: done}
+	cm.pendingLock.Unlock()
+
+	// abort drops the request unless its response is being delivered already, the
+	// result of the delivery is returned then.
+	abort := func(err error) error {
+		cm.pendingLock.Lock()
+		_, ok := cm.pending[id]
+		delete(cm.pending, id)
+		cm.pendingLock.Unlock()
+		if !ok {
+			return <-done
+		}
+		return err
+	}
+	if err := req.send(p, id); err != nil {
+		return abort(err)
+	}
+	timeout := time.NewTimer(requestTimeout)
+	defer timeout.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timeout.C:
+		return abort(errTimeout)
+	case <-ctx.Done():
+		return abort(ctx.Err())
+	case <-cm.quit:
+		return abort(errClosed)
+	}
+}
+
+// deliver hands the response to the pending request it answers. An invalid
+// response is returned as error to drop the peer.
+func (cm *ClientManager) deliver(p *peer, reqID uint64, resp interface{}) error {
+	cm.pendingLock.Lock()
+	pending := cm.pending[reqID]
+	if pending == nil || pending.peer != p {
+		// late response of a timed out request
+		cm.pendingLock.Unlock()
+		return nil
+	}
+	delete(cm.pending, reqID)
+	cm.pendingLock.Unlock()
+
+	err := pending.req.deliver(resp)
+	pending.done <- err
+	if err == errNotFound {
+		return nil
+	}
+	return err
+}
+
+// GetBlock retrieves the body of the block of the header.
+func (cm *ClientManager) GetBlock(ctx context.Context, header *types.BlockHeader) (*types.Block, error) {
+	if header.TransactionsRoot == emptyRoot && header.ActionsRoot == emptyRoot {
+		return types.NewBlockWithBlockHeader(header), nil
+	}
+	req := &bodyRequest{header: header}
+	if err := cm.retrieve(ctx, header.Height.Uint64(), req); err != nil {
+		return nil, err
+	}
+	return req.block, nil
+}
+
+// GetReceipts retrieves the receipts of the block of the header.
+func (cm *ClientManager) GetReceipts(ctx context.Context, header *types.BlockHeader) (types.Receipts, error) {
+	if header.ReceiptsRoot == emptyRoot {
+		return types.Receipts{}, nil
+	}
+	req := &receiptsRequest{header: header}
+	if err := cm.retrieve(ctx, header.Height.Uint64(), req); err != nil {
+		return nil, err
+	}
+	return req.receipts, nil
+}
+
+// GetNodeData retrieves the trie node or contract code of the given hash.
+func (cm *ClientManager) GetNodeData(ctx context.Context, hash utils.Hash) ([]byte, error) {
+	req := &nodeDataRequest{hash: hash}
+	if err := cm.retrieve(ctx, 0, req); err != nil {
+		return nil, err
+	}
+	return req.data, nil
+}
+
+// GetTransaction locates the transaction in the local header chain, nil is
+// returned if no server knows it.
+func (cm *ClientManager) GetTransaction(ctx context.Context, hash utils.Hash) (*types.StorageTx, error) {
+	req := &txLookupRequest{hash: hash, chain: cm.chain}
+	if err := cm.retrieve(ctx, 0, req); err != nil {
+		if err == errNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return req.stx, nil
+}
+
+// SendTxs relays the transactions to all the servers.
+func (cm *ClientManager) SendTxs(txs types.Transactions) error {
+	sent := false
+	for _, p := range cm.peers.AllPeers() {
+		if err := p.SendTransactions(txs); err != nil {
+			log.Debugf("Failed to relay transactions to peer %v: %v", p.id, err)
+			continue
+		}
+		sent = true
+	}
+	if !sent {
+		return errNoPeers
+	}
+	return nil
+}
+
+func (cm *ClientManager) triggerSync(p *peer) {
+	select {
+	case cm.syncCh <- p:
+	default:
+	}
+}
+
+func (cm *ClientManager) syncer() {
+	forceSync := time.NewTicker(forceSyncCycle)
+	defer forceSync.Stop()
+
+	for {
+		select {
+		case p := <-cm.syncCh:
+			cm.synchronise(p)
+		case <-forceSync.C:
+			cm.synchronise(cm.peers.BestServer(0, nil))
+		case <-cm.quit:
+			return
+		}
+	}
+}
+
+// synchronise syncs the header chain with the peer if it is heavier, the peer is
+// dropped if it served invalid headers.
+func (cm *ClientManager) synchronise(p *peer) {
+	if p == nil {
+		return
+	}
+	_, height, td := p.Head()
+	if td.Cmp(cm.chain.GetTd(cm.chain.CurrentHeader().Hash())) <= 0 {
+		return
+	}
+	start := time.Now()
+	if err := cm.syncWithPeer(p, height); err != nil {
+		log.Debugf("Light sync with peer %v failed: %v", p.id, err)
+		if !isRetrievalError(err) {
+			p.Disconnect(fmt.Sprintf("invalid headers: %v", err))
+		}
+		return
+	}
+	head := cm.chain.CurrentHeader()
+	log.Infof("Light sync with peer %v done height: %v, hash: %v, elapsed: %v", p.id, head.Height, head.Hash().Hex(), time.Since(start))
+}
+
+func (cm *ClientManager) syncWithPeer(p *peer, height uint64) error {
+	ctx := context.Background()
+	ancestor, err := cm.findAncestor(ctx, p, height)
+	if err != nil {
+		return err
+	}
+	for from := ancestor + 1; ; {
+		req := &headersRequest{query: getBlockHeadersData{Origin: from, Amount: MaxHeaderFetch}}
+		if err := cm.retrieveFrom(ctx, p, req); err != nil {
+			return err
+		}
+		if len(req.headers) == 0 {
+			return nil
+		}
+		if req.headers[0].Height.Uint64() != from {
+			return errInvalidData
+		}
+		if _, err := cm.chain.InsertHeaders(req.headers); err != nil {
+			return err
+		}
+		from += uint64(len(req.headers))
+	}
+}
+
+// findAncestor walks the canonical chain of the peer back from the given height
+// until a header of the local canonical chain is met.
+func (cm *ClientManager) findAncestor(ctx context.Context, p *peer, height uint64) (uint64, error) {
+	top := cm.chain.CurrentHeader().Height.Uint64()
+	if height < top {
+		top = height
+	}
+	for {
+		amount := uint64(MaxHeaderFetch)
+		if top+1 < amount {
+			amount = top + 1
+		}
+		req := &headersRequest{query: getBlockHeadersData{Origin: top, Amount: amount, Reverse: true}}
+		if err := cm.retrieveFrom(ctx, p, req); err != nil {
+			return 0, err
+		}
+		if len(req.headers) == 0 {
+			return 0, errNotFound
+		}
+		for i, header := range req.headers {
+			h := header.Height.Uint64()
+			if h != top-uint64(i) {
+				return 0, errInvalidData
+			}
+			if canon := cm.chain.GetHeaderByHeight(h); canon != nil && canon.Hash() == header.Hash() {
+				return h, nil
+			}
+		}
+		last := req.headers[len(req.headers)-1].Height.Uint64()
+		if last == 0 {
+			// the genesis is checked by the handshake
+			return 0, errInvalidData
+		}
+		top = last - 1
+	}
+}
+
+// isRetrievalError tells whether the error is caused by a failed retrieval rather
+// than by invalid data.
+func isRetrievalError(err error) bool {
+	if _, ok := err.(*mtp.MissingNodeError); ok {
+		return true
+	}
+	switch err {
+	case errNoPeers, errNotFound, errTimeout, errClosed:
+		return true
+	}
+	return false
+}
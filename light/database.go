@@ -0,0 +1,50 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+// odrDatabase backs the state of a light client. The trie nodes and contract
+// codes are stored under their hash, the ones missing locally are retrieved from
+// the light servers and kept once they match the hash.
+type odrDatabase struct {
+	db.Database
+	retrieve func(ctx context.Context, hash utils.Hash) ([]byte, error)
+}
+
+// Get implements db.Reader.
+func (d *odrDatabase) Get(key []byte) ([]byte, error) {
+	if data, err := d.Database.Get(key); err == nil && len(data) > 0 {
+		return data, nil
+	}
+	if len(key) != utils.HashLength {
+		return d.Database.Get(key)
+	}
+	data, err := d.retrieve(context.Background(), utils.BytesToHash(key))
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Database.Put(key, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
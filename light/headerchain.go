@@ -0,0 +1,180 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+var (
+	errNoGenesis        = errors.New("genesis not found in chain")
+	errNonContiguous    = errors.New("non contiguous header")
+	errInvalidTimestamp = errors.New("header timestamp not after its parent")
+)
+
+// HeaderChain is the chain of a light client, it holds the verified block headers
+// only, the bodies, receipts and state are retrieved on demand. It implements
+// consensus.IChainReader so the seal of the headers is checked by the engine,
+// the blocks it returns have a header and no body.
+type HeaderChain struct {
+	*ledger.Ledger
+	config        *params.ChainConfig
+	engine        consensus.Engine
+	genesisBlock  *types.Block
+	currentHeader atomic.Value // Head of the canonical header chain
+
+	chainBlockFeed feed.Feed
+	rmLogsFeed     feed.Feed
+
+	mu sync.Mutex // Serialises the header insertions
+}
+
+// NewHeaderChain opens the header chain stored in db, the genesis must have been
+// set up already.
+func NewHeaderChain(cfg *ledger.Config, config *params.ChainConfig, db db.Database, engine consensus.Engine) (*HeaderChain, error) {
+	hc := &HeaderChain{
+		// the state is never complete on a light client
+		Ledger: ledger.New(cfg, db, func(hash utils.Hash) bool { return false }),
+		config: config,
+		engine: engine,
+	}
+	hc.genesisBlock = hc.GetBlockByHeight(0)
+	if hc.genesisBlock == nil {
+		return nil, errNoGenesis
+	}
+	head := hc.CheckLastBlock(hc.genesisBlock)
+	hc.currentHeader.Store(head.BlockHeader())
+	log.Infof("Loaded most recent local header height: %v, hash: %v", head.Height(), head.Hash().Hex())
+	return hc, nil
+}
+
+// Config returns the chain configuration.
+func (hc *HeaderChain) Config() *params.ChainConfig {
+	return hc.config
+}
+
+// Genesis returns the genesis block.
+func (hc *HeaderChain) Genesis() *types.Block {
+	return hc.genesisBlock
+}
+
+// CurrentHeader returns the head of the canonical header chain.
+func (hc *HeaderChain) CurrentHeader() *types.BlockHeader {
+	return hc.currentHeader.Load().(*types.BlockHeader)
+}
+
+// CurrentBlock returns the head of the canonical header chain as a block without body.
+func (hc *HeaderChain) CurrentBlock() *types.Block {
+	return types.NewBlockWithBlockHeader(hc.CurrentHeader())
+}
+
+// GetHeaderByHeight returns the canonical header of the given height.
+func (hc *HeaderChain) GetHeaderByHeight(height uint64) *types.BlockHeader {
+	block := hc.GetBlockByHeight(height)
+	if block == nil {
+		return nil
+	}
+	return block.BlockHeader()
+}
+
+// InsertHeaders verifies the headers and writes them into the chain, moving the
+// head if the total difficulty of the last one exceeds the current head. The
+// headers must be contiguous and the parent of the first one known. It returns
+// the index of the failing header with the error.
+func (hc *HeaderChain) InsertHeaders(headers []*types.BlockHeader) (int, error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	for i, header := range headers {
+		if hc.HasHeader(header.Hash(), header.Height.Uint64()) {
+			continue
+		}
+		parent := hc.GetHeader(header.PreviousHash)
+		if parent == nil {
+			return i, consensus.ErrUnknownAncestor
+		}
+		if header.Height.Uint64() != parent.Height.Uint64()+1 {
+			return i, errNonContiguous
+		}
+		if header.TimeStamp.Cmp(parent.TimeStamp) <= 0 {
+			return i, errInvalidTimestamp
+		}
+		if err := hc.engine.VerifySeal(hc, header); err != nil {
+			return i, err
+		}
+		if err := hc.writeHeader(header); err != nil {
+			return i, err
+		}
+	}
+	return len(headers), nil
+}
+
+// writeHeader stores the header and makes it the head of the canonical chain if
+// it is the heaviest header known.
+func (hc *HeaderChain) writeHeader(header *types.BlockHeader) error {
+	ptd := hc.GetTd(header.PreviousHash)
+	if ptd == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	td := new(big.Int).Add(ptd, header.Difficulty)
+	block := types.NewBlockWithBlockHeader(header)
+	hc.WriteBlockAndTd(block, td)
+
+	head := hc.CurrentHeader()
+	if td.Cmp(hc.GetTd(head.Hash())) <= 0 {
+		return nil
+	}
+	// Drop the canonical hashes above the new head, then rewrite the ones of the
+	// new branch down to the common ancestor
+	for height := head.Height.Uint64(); height > header.Height.Uint64(); height-- {
+		hc.DeleteLegitimateHash(height)
+	}
+	for ancestor := hc.GetHeader(header.PreviousHash); ancestor != nil; ancestor = hc.GetHeader(ancestor.PreviousHash) {
+		if canon := hc.GetHeaderByHeight(ancestor.Height.Uint64()); canon != nil && canon.Hash() == ancestor.Hash() {
+			break
+		}
+		hc.WriteLegitimateHash(ancestor.Height.Uint64(), ancestor.Hash())
+	}
+	hc.WriteLegitimateHashAndHeadBlockHash(header.Height.Uint64(), header.Hash())
+	hc.currentHeader.Store(types.CopyBlockHeader(header))
+
+	hc.chainBlockFeed.Send(feed.BlockAndLogsEvent{Block: block})
+	return nil
+}
+
+// SubscribeChainBlockEvent registers a subscription of the new canonical heads.
+func (hc *HeaderChain) SubscribeChainBlockEvent(ch chan<- feed.BlockAndLogsEvent) feed.Subscription {
+	return hc.chainBlockFeed.Subscribe(ch)
+}
+
+// SubscribeRemovedLogsEvent registers a subscription of logs removed by chain reorgs,
+// a light client does not know the logs of the dropped headers so none are sent.
+func (hc *HeaderChain) SubscribeRemovedLogsEvent(ch chan<- feed.RemovedLogsEvent) feed.Subscription {
+	return hc.rmLogsFeed.Subscribe(ch)
+}
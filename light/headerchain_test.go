@@ -0,0 +1,188 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	ldb "github.com/UranusBlockStack/uranus/common/db/leveldb"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestHeaderChain creates a header chain on a fresh database with a genesis
+// electing validator. It returns the sealers of the headers on the chain, and the
+// function removing its database.
+func newTestHeaderChain(t *testing.T, validator utils.Address) (*HeaderChain, func(*ecdsa.PrivateKey) *testSealer, func()) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	chainDb, err := ldb.New(dir, 0, 0)
+	assert.NoError(t, err)
+
+	genesis := ledger.DefaultGenesis()
+	config := *genesis.Config
+	config.GenesisCandidate = validator.Hex()
+	genesis.Config = &config
+	_, statedb, err := genesis.Commit(ledger.NewChain(chainDb))
+	assert.NoError(t, err)
+
+	engine := dpos.NewDpos(new(feed.TypeMux), chainDb, statedb, nil)
+	hc, err := NewHeaderChain(nil, genesis.Config, chainDb, engine)
+	assert.NoError(t, err)
+	newSealer := func(key *ecdsa.PrivateKey) *testSealer {
+		return &testSealer{
+			engine: dpos.NewDpos(new(feed.TypeMux), chainDb, statedb, func(addr utils.Address, hash []byte) ([]byte, error) {
+				return crypto.Sign(hash, key)
+			}),
+			miner: crypto.PubkeyToAddress(key.PublicKey),
+		}
+	}
+	return hc, newSealer, func() {
+		chainDb.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// testSealer mints the test headers with the key of its miner.
+type testSealer struct {
+	engine *dpos.Dpos
+	miner  utils.Address
+}
+
+// sealTestHeader signs a child of parent, slots intervals after it. The parent must
+// be in the chain.
+func sealTestHeader(t *testing.T, hc *HeaderChain, sealer *testSealer, parent *types.BlockHeader, slots int64, txsRoot utils.Hash) *types.BlockHeader {
+	engine := sealer.engine
+	time := new(big.Int).Add(parent.TimeStamp, big.NewInt(slots*dpos.Option.BlockInterval))
+	header := &types.BlockHeader{
+		PreviousHash:     parent.Hash(),
+		Miner:            sealer.miner,
+		StateRoot:        parent.StateRoot,
+		TransactionsRoot: txsRoot,
+		DposContext:      parent.DposContext,
+		Difficulty:       engine.CalcDifficulty(hc, hc.Config(), time.Uint64(), parent),
+		Height:           new(big.Int).Add(parent.Height, big.NewInt(1)),
+		GasLimit:         parent.GasLimit,
+		TimeStamp:        time,
+	}
+	block, err := engine.Seal(hc, types.NewBlockWithBlockHeader(header), nil, 0, nil)
+	assert.NoError(t, err)
+	return block.BlockHeader()
+}
+
+// makeTestHeaders seals n contiguous headers on top of parent, each one is inserted
+// into the chain to seal the next.
+func makeTestHeaders(t *testing.T, hc *HeaderChain, sealer *testSealer, parent *types.BlockHeader, n int, slots int64) []*types.BlockHeader {
+	headers := make([]*types.BlockHeader, n)
+	for i := range headers {
+		headers[i] = sealTestHeader(t, hc, sealer, parent, slots, types.DeriveRootHash(types.Transactions(nil)))
+		_, err := hc.InsertHeaders(headers[i : i+1])
+		assert.NoError(t, err)
+		parent = headers[i]
+	}
+	return headers
+}
+
+func TestInsertHeadersSeal(t *testing.T) {
+	validatorKey, _ := crypto.GenerateKey()
+	forgerKey, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(validatorKey.PublicKey)
+	source, newSealer, closeSource := newTestHeaderChain(t, validator)
+	defer closeSource()
+	headers := makeTestHeaders(t, source, newSealer(validatorKey), source.Genesis().BlockHeader(), 3, 1)
+
+	hc, _, closeChain := newTestHeaderChain(t, validator)
+	defer closeChain()
+
+	// a header signed by a miner that is not elected
+	forged := sealTestHeader(t, source, newSealer(forgerKey), headers[1], 1, types.DeriveRootHash(types.Transactions(nil)))
+	index, err := hc.InsertHeaders([]*types.BlockHeader{headers[0], headers[1], forged})
+	assert.Equal(t, 2, index)
+	assert.Equal(t, dpos.ErrInvalidBlockValidator, err)
+	assert.False(t, hc.HasHeader(forged.Hash(), forged.Height.Uint64()))
+	assert.Equal(t, headers[1].Hash(), hc.CurrentHeader().Hash())
+
+	// a header whose signature does not match its miner
+	tampered := types.CopyBlockHeader(headers[2])
+	tampered.Miner = crypto.PubkeyToAddress(forgerKey.PublicKey)
+	index, err = hc.InsertHeaders([]*types.BlockHeader{tampered})
+	assert.Equal(t, 0, index)
+	assert.Equal(t, dpos.ErrMismatchSignerAndValidator, err)
+
+	// the headers must extend a known parent by one height
+	orphan := types.CopyBlockHeader(headers[2])
+	orphan.PreviousHash = utils.Hash{1}
+	index, err = hc.InsertHeaders([]*types.BlockHeader{orphan})
+	assert.Equal(t, 0, index)
+	assert.Equal(t, consensus.ErrUnknownAncestor, err)
+
+	skipped := types.CopyBlockHeader(headers[2])
+	skipped.Height = big.NewInt(4)
+	index, err = hc.InsertHeaders([]*types.BlockHeader{skipped})
+	assert.Equal(t, 0, index)
+	assert.Equal(t, errNonContiguous, err)
+
+	index, err = hc.InsertHeaders(headers)
+	assert.NoError(t, err)
+	assert.Equal(t, len(headers), index)
+	assert.Equal(t, headers[2].Hash(), hc.CurrentHeader().Hash())
+}
+
+func TestInsertHeadersReorg(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+	source, newSealer, closeSource := newTestHeaderChain(t, validator)
+	defer closeSource()
+	sealer := newSealer(key)
+	genesis := source.Genesis().BlockHeader()
+	short := makeTestHeaders(t, source, sealer, genesis, 3, 1)
+	lighter := makeTestHeaders(t, source, sealer, genesis, 1, 2)
+	heavy := makeTestHeaders(t, source, sealer, genesis, 2, 3)
+
+	hc, _, closeChain := newTestHeaderChain(t, validator)
+	defer closeChain()
+	_, err := hc.InsertHeaders(short)
+	assert.NoError(t, err)
+
+	// a lighter branch is stored without moving the head
+	_, err = hc.InsertHeaders(lighter)
+	assert.NoError(t, err)
+	assert.True(t, hc.HasHeader(lighter[0].Hash(), 1))
+	assert.Equal(t, short[2].Hash(), hc.CurrentHeader().Hash())
+	assert.Equal(t, short[0].Hash(), hc.GetHeaderByHeight(1).Hash())
+
+	// two headers of slots further apart weigh more than the three of the short branch
+	assert.True(t, new(big.Int).Add(heavy[0].Difficulty, heavy[1].Difficulty).Cmp(
+		new(big.Int).Add(short[0].Difficulty, new(big.Int).Add(short[1].Difficulty, short[2].Difficulty))) > 0)
+	_, err = hc.InsertHeaders(heavy)
+	assert.NoError(t, err)
+	assert.Equal(t, heavy[1].Hash(), hc.CurrentHeader().Hash())
+	assert.Equal(t, heavy[0].Hash(), hc.GetHeaderByHeight(1).Hash())
+	assert.Equal(t, heavy[1].Hash(), hc.GetHeaderByHeight(2).Hash())
+	assert.Nil(t, hc.GetHeaderByHeight(3))
+	assert.Equal(t, heavy[1].Hash(), hc.CurrentBlock().Hash())
+}
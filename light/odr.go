@@ -0,0 +1,200 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"bytes"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	mdb "github.com/UranusBlockStack/uranus/common/db/memorydb"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+// request is an on demand retrieval from a light server. The response is checked
+// against the local header chain before it is accepted, a response failing the
+// check gets the server dropped.
+type request interface {
+	// send asks the peer for the data under the given request id.
+	send(p *peer, reqID uint64) error
+	// deliver verifies and keeps the response, errNotFound lets another server be
+	// asked without penalising this one.
+	deliver(resp interface{}) error
+}
+
+// headersRequest retrieves a batch of the canonical headers of a server, they are
+// verified by the header chain on insertion.
+type headersRequest struct {
+	query   getBlockHeadersData
+	headers []*types.BlockHeader
+}
+
+func (r *headersRequest) send(p *peer, reqID uint64) error {
+	return p.RequestHeadersByNumber(reqID, r.query.Origin, int(r.query.Amount), int(r.query.Skip), r.query.Reverse)
+}
+
+func (r *headersRequest) deliver(resp interface{}) error {
+	packet, ok := resp.(*blockHeadersPacket)
+	if !ok || len(packet.Headers) > int(r.query.Amount) {
+		return errInvalidData
+	}
+	for _, header := range packet.Headers {
+		if header == nil || header.Height == nil || header.TimeStamp == nil || header.Difficulty == nil {
+			return errInvalidData
+		}
+	}
+	r.headers = packet.Headers
+	return nil
+}
+
+// bodyRequest retrieves the body of a block, the transactions are checked against
+// the transaction root of the header.
+type bodyRequest struct {
+	header *types.BlockHeader
+	block  *types.Block
+}
+
+func (r *bodyRequest) send(p *peer, reqID uint64) error {
+	return p.RequestBodies(reqID, []utils.Hash{r.header.Hash()})
+}
+
+func (r *bodyRequest) deliver(resp interface{}) error {
+	packet, ok := resp.(*blockBodiesPacket)
+	if !ok || len(packet.Bodies) > 1 {
+		return errInvalidData
+	}
+	if len(packet.Bodies) == 0 {
+		return errNotFound
+	}
+	body := packet.Bodies[0]
+	if types.DeriveRootHash(types.Transactions(body.Transactions)) != r.header.TransactionsRoot {
+		return errInvalidData
+	}
+	block := types.NewBlockWithBlockHeader(r.header).WithTxs(body.Transactions)
+	// The actions are not kept by the ledger of the servers, they are only trusted
+	// when they match the root of the header.
+	if len(body.Actions) > 0 && types.DeriveRootHash(types.Actions(body.Actions)) == r.header.ActionsRoot {
+		block = block.WithActions(body.Actions)
+	}
+	r.block = block
+	return nil
+}
+
+// receiptsRequest retrieves the receipts of a block, checked against the receipt
+// root of the header.
+type receiptsRequest struct {
+	header   *types.BlockHeader
+	receipts types.Receipts
+}
+
+func (r *receiptsRequest) send(p *peer, reqID uint64) error {
+	return p.RequestReceipts(reqID, []utils.Hash{r.header.Hash()})
+}
+
+func (r *receiptsRequest) deliver(resp interface{}) error {
+	packet, ok := resp.(*receiptsPacket)
+	if !ok || len(packet.Receipts) > 1 {
+		return errInvalidData
+	}
+	if len(packet.Receipts) == 0 {
+		return errNotFound
+	}
+	receipts := make(types.Receipts, len(packet.Receipts[0]))
+	for i, receipt := range packet.Receipts[0] {
+		receipts[i] = (*types.Receipt)(receipt)
+	}
+	if types.DeriveRootHash(receipts) != r.header.ReceiptsRoot {
+		return errInvalidData
+	}
+	r.receipts = receipts
+	return nil
+}
+
+// nodeDataRequest retrieves a trie node or a contract code by its hash. Starting
+// from the state root of a verified header, every node retrieved this way is
+// proven by the hash its parent references.
+type nodeDataRequest struct {
+	hash utils.Hash
+	data []byte
+}
+
+func (r *nodeDataRequest) send(p *peer, reqID uint64) error {
+	return p.RequestNodeData(reqID, []utils.Hash{r.hash})
+}
+
+func (r *nodeDataRequest) deliver(resp interface{}) error {
+	packet, ok := resp.(*nodeDataPacket)
+	if !ok || len(packet.Data) > 1 {
+		return errInvalidData
+	}
+	if len(packet.Data) == 0 {
+		return errNotFound
+	}
+	if crypto.Keccak256Hash(packet.Data[0]) != r.hash {
+		return errInvalidData
+	}
+	r.data = packet.Data[0]
+	return nil
+}
+
+// txLookupRequest locates a transaction in the chain, the transaction is proven
+// by the merkle proof of its index in the transaction trie of its block.
+type txLookupRequest struct {
+	hash  utils.Hash
+	chain *HeaderChain
+	stx   *types.StorageTx
+}
+
+func (r *txLookupRequest) send(p *peer, reqID uint64) error {
+	return p.RequestTxLookups(reqID, []utils.Hash{r.hash})
+}
+
+func (r *txLookupRequest) deliver(resp interface{}) error {
+	packet, ok := resp.(*txLookupsPacket)
+	if !ok || len(packet.Lookups) != 1 || packet.Lookups[0] == nil {
+		return errInvalidData
+	}
+	lookup := packet.Lookups[0]
+	if lookup.BlockHash == (utils.Hash{}) {
+		return errNotFound
+	}
+	// the server may be ahead of the local chain or on another branch
+	header := r.chain.GetHeaderByHeight(lookup.BlockHeight)
+	if header == nil || header.Hash() != lookup.BlockHash {
+		return errNotFound
+	}
+	proofDb := mdb.New()
+	for _, node := range lookup.Proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	key, _ := rlp.EncodeToBytes(uint(lookup.TxIndex))
+	value, err := mtp.VerifyProof(header.TransactionsRoot, key, proofDb)
+	if err != nil || value == nil {
+		return errInvalidData
+	}
+	tx := new(types.Transaction)
+	if err := rlp.Decode(bytes.NewReader(value), tx); err != nil {
+		return errInvalidData
+	}
+	if tx.Hash() != r.hash {
+		return errInvalidData
+	}
+	r.stx = types.NewStorageTx(lookup.BlockHash, lookup.BlockHeight, lookup.TxIndex, tx)
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTxs(n int) types.Transactions {
+	to := utils.Address{1}
+	txs := make(types.Transactions, n)
+	for i := range txs {
+		txs[i] = types.NewTransaction(types.Binary, uint64(i), big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+	}
+	return txs
+}
+
+func TestHeadersRequestDeliver(t *testing.T) {
+	header := &types.BlockHeader{Height: big.NewInt(1), TimeStamp: big.NewInt(1), Difficulty: big.NewInt(1)}
+	req := &headersRequest{query: getBlockHeadersData{Amount: 1}}
+	assert.Equal(t, errInvalidData, req.deliver(&blockBodiesPacket{}))
+	assert.Equal(t, errInvalidData, req.deliver(&blockHeadersPacket{Headers: []*types.BlockHeader{header, header}}))
+	assert.Equal(t, errInvalidData, req.deliver(&blockHeadersPacket{Headers: []*types.BlockHeader{{Height: big.NewInt(1)}}}))
+	assert.NoError(t, req.deliver(&blockHeadersPacket{Headers: []*types.BlockHeader{header}}))
+	assert.Equal(t, []*types.BlockHeader{header}, req.headers)
+}
+
+func TestBodyRequestDeliver(t *testing.T) {
+	txs := newTestTxs(2)
+	actions := []*types.Action{types.NewAction(txs[0].Hash(), utils.Address{2}, big.NewInt(1), big.NewInt(2))}
+	header := &types.BlockHeader{
+		Height:           big.NewInt(1),
+		TransactionsRoot: types.DeriveRootHash(txs),
+		ActionsRoot:      types.DeriveRootHash(types.Actions(actions)),
+	}
+	req := &bodyRequest{header: header}
+	assert.Equal(t, errInvalidData, req.deliver(&receiptsPacket{}))
+	assert.Equal(t, errNotFound, req.deliver(&blockBodiesPacket{}))
+	assert.Equal(t, errInvalidData, req.deliver(&blockBodiesPacket{Bodies: []*blockBody{{}, {}}}))
+	// the transactions must match the root of the header
+	assert.Equal(t, errInvalidData, req.deliver(&blockBodiesPacket{Bodies: []*blockBody{{Transactions: txs[:1]}}}))
+	assert.Nil(t, req.block)
+
+	// the actions are kept only when they match the root of the header
+	other := []*types.Action{types.NewAction(txs[1].Hash(), utils.Address{2}, big.NewInt(1), big.NewInt(2))}
+	assert.NoError(t, req.deliver(&blockBodiesPacket{Bodies: []*blockBody{{Transactions: txs, Actions: other}}}))
+	assert.Equal(t, types.NewBlockWithBlockHeader(header).Hash(), req.block.Hash())
+	assert.Len(t, req.block.Transactions(), 2)
+	assert.Empty(t, req.block.Actions())
+
+	assert.NoError(t, req.deliver(&blockBodiesPacket{Bodies: []*blockBody{{Transactions: txs, Actions: actions}}}))
+	assert.Len(t, req.block.Actions(), 1)
+}
+
+func TestReceiptsRequestDeliver(t *testing.T) {
+	receipts := types.Receipts{types.NewReceipt(nil, false, 21000), types.NewReceipt(nil, true, 42000)}
+	header := &types.BlockHeader{Height: big.NewInt(1), ReceiptsRoot: types.DeriveRootHash(receipts)}
+	storage := make([]*types.ReceiptForStorage, len(receipts))
+	for i, receipt := range receipts {
+		storage[i] = (*types.ReceiptForStorage)(receipt)
+	}
+	req := &receiptsRequest{header: header}
+	assert.Equal(t, errInvalidData, req.deliver(&nodeDataPacket{}))
+	assert.Equal(t, errNotFound, req.deliver(&receiptsPacket{}))
+	assert.Equal(t, errInvalidData, req.deliver(&receiptsPacket{Receipts: [][]*types.ReceiptForStorage{storage[:1]}}))
+	assert.Equal(t, errInvalidData, req.deliver(&receiptsPacket{Receipts: [][]*types.ReceiptForStorage{storage, storage}}))
+	assert.Nil(t, req.receipts)
+
+	assert.NoError(t, req.deliver(&receiptsPacket{Receipts: [][]*types.ReceiptForStorage{storage}}))
+	assert.Equal(t, receipts, req.receipts)
+}
+
+func TestNodeDataRequestDeliver(t *testing.T) {
+	data := []byte("node data")
+	req := &nodeDataRequest{hash: crypto.Keccak256Hash(data)}
+	assert.Equal(t, errInvalidData, req.deliver(&receiptsPacket{}))
+	assert.Equal(t, errNotFound, req.deliver(&nodeDataPacket{}))
+	assert.Equal(t, errInvalidData, req.deliver(&nodeDataPacket{Data: [][]byte{data, data}}))
+	assert.Equal(t, errInvalidData, req.deliver(&nodeDataPacket{Data: [][]byte{[]byte("other data")}}))
+	assert.Nil(t, req.data)
+
+	assert.NoError(t, req.deliver(&nodeDataPacket{Data: [][]byte{data}}))
+	assert.Equal(t, data, req.data)
+}
+
+func TestTxLookupRequestDeliver(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	hc, newSealer, closeChain := newTestHeaderChain(t, crypto.PubkeyToAddress(key.PublicKey))
+	defer closeChain()
+
+	txs := newTestTxs(3)
+	header := sealTestHeader(t, hc, newSealer(key), hc.Genesis().BlockHeader(), 1, types.DeriveRootHash(txs))
+	_, err := hc.InsertHeaders([]*types.BlockHeader{header})
+	assert.NoError(t, err)
+
+	proof, err := proveTx(txs, 1)
+	assert.NoError(t, err)
+	lookup := func(hash utils.Hash, height uint64, index uint64, proof [][]byte) *txLookupsPacket {
+		return &txLookupsPacket{Lookups: []*txLookup{{BlockHash: hash, BlockHeight: height, TxIndex: index, Proof: proof}}}
+	}
+
+	req := &txLookupRequest{hash: txs[1].Hash(), chain: hc}
+	assert.Equal(t, errInvalidData, req.deliver(&nodeDataPacket{}))
+	assert.Equal(t, errInvalidData, req.deliver(&txLookupsPacket{}))
+	assert.Equal(t, errNotFound, req.deliver(lookup(utils.Hash{}, 0, 0, nil)))
+	// a block off the local canonical chain is not trusted
+	assert.Equal(t, errNotFound, req.deliver(lookup(utils.Hash{1}, 1, 1, proof)))
+	assert.Equal(t, errNotFound, req.deliver(lookup(header.Hash(), 2, 1, proof)))
+	// the proof must lead from the root of the header to the transaction
+	assert.Equal(t, errInvalidData, req.deliver(lookup(header.Hash(), 1, 1, proof[:len(proof)-1])))
+	assert.Equal(t, errInvalidData, req.deliver(lookup(header.Hash(), 1, 2, proof)))
+	otherProof, err := proveTx(txs, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, errInvalidData, req.deliver(lookup(header.Hash(), 1, 2, otherProof)))
+	assert.Nil(t, req.stx)
+
+	assert.NoError(t, req.deliver(lookup(header.Hash(), 1, 1, proof)))
+	assert.Equal(t, txs[1].Hash(), req.stx.Tx.Hash())
+	assert.Equal(t, header.Hash(), req.stx.BlockHash)
+	assert.Equal(t, uint64(1), req.stx.TxIndex)
+}
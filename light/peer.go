@@ -0,0 +1,298 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/p2p"
+)
+
+type peer struct {
+	id      string
+	version int
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	serve  bool
+	head   utils.Hash
+	height uint64
+	td     *big.Int
+	lock   sync.RWMutex
+}
+
+func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
+	return &peer{
+		Peer:    p,
+		version: version,
+		rw:      rw,
+		id:      fmt.Sprintf("%x", p.ID().Bytes()[:8]),
+	}
+}
+
+type PeerInfo struct {
+	Version    int      `json:"version"`
+	Serve      bool     `json:"serve"`
+	Difficulty *big.Int `json:"difficulty"`
+	Head       string   `json:"head"`
+	Height     uint64   `json:"height"`
+}
+
+func (p *peer) Info() *PeerInfo {
+	hash, height, td := p.Head()
+
+	return &PeerInfo{
+		Version:    p.version,
+		Serve:      p.serve,
+		Difficulty: td,
+		Head:       hash.Hex(),
+		Height:     height,
+	}
+}
+
+func (p *peer) Head() (hash utils.Hash, height uint64, td *big.Int) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	copy(hash[:], p.head[:])
+	return hash, p.height, new(big.Int).Set(p.td)
+}
+
+func (p *peer) SetHead(hash utils.Hash, height uint64, td *big.Int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	copy(p.head[:], hash[:])
+	p.height = height
+	p.td.Set(td)
+}
+
+func (p *peer) SendAnnounce(hash utils.Hash, height uint64, td *big.Int) error {
+	return p2p.SendMessage(p.rw, AnnounceMsg, &announceData{Hash: hash, Height: height, TD: td})
+}
+
+func (p *peer) SendBlockHeaders(reqID uint64, headers []*types.BlockHeader) error {
+	return p2p.SendMessage(p.rw, BlockHeadersMsg, &blockHeadersPacket{ReqID: reqID, Headers: headers})
+}
+
+func (p *peer) SendBlockBodies(reqID uint64, bodies []*blockBody) error {
+	return p2p.SendMessage(p.rw, BlockBodiesMsg, &blockBodiesPacket{ReqID: reqID, Bodies: bodies})
+}
+
+func (p *peer) SendReceipts(reqID uint64, receipts [][]*types.ReceiptForStorage) error {
+	return p2p.SendMessage(p.rw, ReceiptsMsg, &receiptsPacket{ReqID: reqID, Receipts: receipts})
+}
+
+func (p *peer) SendNodeData(reqID uint64, data [][]byte) error {
+	return p2p.SendMessage(p.rw, NodeDataMsg, &nodeDataPacket{ReqID: reqID, Data: data})
+}
+
+func (p *peer) SendTxLookups(reqID uint64, lookups []*txLookup) error {
+	return p2p.SendMessage(p.rw, TxLookupsMsg, &txLookupsPacket{ReqID: reqID, Lookups: lookups})
+}
+
+func (p *peer) SendTransactions(txs types.Transactions) error {
+	return p2p.SendMessage(p.rw, SendTxMsg, txs)
+}
+
+// RequestHeadersByNumber fetches a batch of headers of the canonical chain of the
+// peer starting at the given height.
+func (p *peer) RequestHeadersByNumber(reqID uint64, origin uint64, amount int, skip int, reverse bool) error {
+	return p2p.SendMessage(p.rw, GetBlockHeadersMsg, &getBlockHeadersPacket{
+		ReqID: reqID,
+		Query: getBlockHeadersData{Origin: origin, Amount: uint64(amount), Skip: uint64(skip), Reverse: reverse},
+	})
+}
+
+func (p *peer) RequestBodies(reqID uint64, hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetBlockBodiesMsg, &hashesPacket{ReqID: reqID, Hashes: hashes})
+}
+
+func (p *peer) RequestReceipts(reqID uint64, hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetReceiptsMsg, &hashesPacket{ReqID: reqID, Hashes: hashes})
+}
+
+func (p *peer) RequestNodeData(reqID uint64, hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetNodeDataMsg, &hashesPacket{ReqID: reqID, Hashes: hashes})
+}
+
+func (p *peer) RequestTxLookups(reqID uint64, hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetTxLookupsMsg, &hashesPacket{ReqID: reqID, Hashes: hashes})
+}
+
+// Handshake exchanges the status of both sides, serve tells the peer whether the
+// local node answers the light requests.
+func (p *peer) Handshake(network uint64, td *big.Int, head utils.Hash, height uint64, genesis utils.Hash, serve bool) error {
+	var status statusData
+	errc := make(chan error, 2)
+	go func() {
+		errc <- p2p.SendMessage(p.rw, StatusMsg, &statusData{
+			ProtocolVersion: uint32(p.version),
+			NetworkID:       network,
+			TD:              td,
+			Head:            head,
+			Height:          height,
+			GenesisBlock:    genesis,
+			Serve:           serve,
+		})
+	}()
+	go func() {
+		errc <- p.readStatus(network, &status, genesis)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return fmt.Errorf("time out")
+		}
+	}
+	p.td, p.head, p.height, p.serve = status.TD, status.Head, status.Height, status.Serve
+	return nil
+}
+
+func (p *peer) readStatus(network uint64, status *statusData, genesis utils.Hash) (err error) {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Code != StatusMsg {
+		return fmt.Errorf("first msg has code %x (!= %x)", msg.Code, StatusMsg)
+	}
+
+	if err := msg.DecodePayload(&status); err != nil {
+		return fmt.Errorf("status msg %v: %v", msg, err)
+	}
+	if status.GenesisBlock != genesis {
+		return fmt.Errorf("genesis %x (!= %x)", status.GenesisBlock[:8], genesis[:8])
+	}
+	if status.NetworkID != network {
+		return fmt.Errorf("network %d (!= %d)", status.NetworkID, network)
+	}
+	if int(status.ProtocolVersion) != p.version {
+		return fmt.Errorf("version %d (!= %d)", status.ProtocolVersion, p.version)
+	}
+	if status.TD == nil {
+		return fmt.Errorf("status msg %v: missing total difficulty", msg)
+	}
+	return nil
+}
+
+type peerSet struct {
+	sync.RWMutex
+
+	peers  map[string]*peer
+	closed bool
+}
+
+func newPeerSet() *peerSet {
+	return &peerSet{
+		peers: make(map[string]*peer),
+	}
+}
+
+func (ps *peerSet) Register(p *peer) error {
+	ps.Lock()
+	defer ps.Unlock()
+
+	if ps.closed {
+		return fmt.Errorf("closed")
+	}
+	if _, ok := ps.peers[p.id]; ok {
+		return fmt.Errorf("registered")
+	}
+	ps.peers[p.id] = p
+
+	return nil
+}
+
+func (ps *peerSet) Unregister(id string) error {
+	ps.Lock()
+	defer ps.Unlock()
+
+	if _, ok := ps.peers[id]; !ok {
+		return fmt.Errorf("unregistered")
+	}
+	delete(ps.peers, id)
+
+	return nil
+}
+
+func (ps *peerSet) Peer(id string) *peer {
+	ps.RLock()
+	defer ps.RUnlock()
+
+	return ps.peers[id]
+}
+
+func (ps *peerSet) Len() int {
+	ps.RLock()
+	defer ps.RUnlock()
+
+	return len(ps.peers)
+}
+
+func (ps *peerSet) AllPeers() []*peer {
+	ps.RLock()
+	defer ps.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
+// BestServer returns the serving peer with the highest total difficulty which is
+// not in the excluded set and whose head reaches the given height.
+func (ps *peerSet) BestServer(height uint64, exclude map[string]bool) *peer {
+	ps.RLock()
+	defer ps.RUnlock()
+
+	var (
+		bestPeer *peer
+		bestTd   *big.Int
+	)
+	for _, p := range ps.peers {
+		if !p.serve || exclude[p.id] {
+			continue
+		}
+		if _, h, td := p.Head(); h >= height && (bestPeer == nil || td.Cmp(bestTd) > 0) {
+			bestPeer, bestTd = p, td
+		}
+	}
+	return bestPeer
+}
+
+func (ps *peerSet) Close() {
+	ps.Lock()
+	defer ps.Unlock()
+
+	for _, p := range ps.peers {
+		p.Disconnect("close")
+	}
+	ps.closed = true
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+// ProtocolName is the name of the light sub-protocol, it runs next to the base
+// protocol of the full nodes, which serve the light clients through it.
+var ProtocolName = "uranuslight"
+
+var protocolVersion uint = 1
+
+// The light message codes follow the ones of the base protocol (1000-1999).
+const (
+	protocolOffset = 2000
+	protocolSize   = 1000
+)
+
+const (
+	StatusMsg          = iota + protocolOffset //2000
+	AnnounceMsg                                //2001
+	GetBlockHeadersMsg                         //2002
+	BlockHeadersMsg                            //2003
+	GetBlockBodiesMsg                          //2004
+	BlockBodiesMsg                             //2005
+	GetReceiptsMsg                             //2006
+	ReceiptsMsg                                //2007
+	GetNodeDataMsg                             //2008
+	NodeDataMsg                                //2009
+	GetTxLookupsMsg                            //2010
+	TxLookupsMsg                               //2011
+	SendTxMsg                                  //2012
+)
+
+const (
+	MaxHeaderFetch  = 192 // Amount of block headers to be fetched per request
+	MaxBodyFetch    = 32  // Amount of block bodies to be fetched per request
+	MaxReceiptFetch = 32  // Amount of block receipts to be fetched per request
+	MaxStateFetch   = 384 // Amount of state trie nodes to be fetched per request
+	MaxTxLookup     = 64  // Amount of transaction lookups to be served per request
+
+	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned data
+	estHeaderRlpSize  = 500             // Approximate size of an RLP encoded block header
+
+	handshakeTimeout = 5 * time.Second
+	requestTimeout   = 5 * time.Second // Time a peer is given to answer a request before trying another one
+)
+
+var (
+	errNoPeers       = errors.New("no light server peers available")
+	errNotFound      = errors.New("data not available on the peer")
+	errInvalidData   = errors.New("invalid data returned by the peer")
+	errUnknownHeader = errors.New("header not in the local chain")
+)
+
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkID       uint64
+	TD              *big.Int
+	Head            utils.Hash
+	Height          uint64
+	GenesisBlock    utils.Hash
+	Serve           bool // Whether the peer serves light clients, only full nodes do
+}
+
+// announceData is sent by the servers to their light peers when their head changes.
+type announceData struct {
+	Hash   utils.Hash
+	Height uint64
+	TD     *big.Int
+}
+
+// getBlockHeadersData represents a block header query along the canonical chain
+// of the server.
+type getBlockHeadersData struct {
+	Origin  uint64 // Height of the first header to retrieve
+	Amount  uint64 // Maximum number of headers to retrieve
+	Skip    uint64 // Blocks to skip between consecutive headers
+	Reverse bool   // Query direction (false = rising towards latest, true = falling towards genesis)
+}
+
+type getBlockHeadersPacket struct {
+	ReqID uint64
+	Query getBlockHeadersData
+}
+
+// hashesPacket is the request of the bodies, receipts, trie nodes and transaction
+// lookups of the given hashes.
+type hashesPacket struct {
+	ReqID  uint64
+	Hashes []utils.Hash
+}
+
+type blockHeadersPacket struct {
+	ReqID   uint64
+	Headers []*types.BlockHeader
+}
+
+// blockBody represents the data content of a single block.
+type blockBody struct {
+	Transactions []*types.Transaction
+	Actions      []*types.Action
+}
+
+type blockBodiesPacket struct {
+	ReqID  uint64
+	Bodies []*blockBody
+}
+
+type receiptsPacket struct {
+	ReqID    uint64
+	Receipts [][]*types.ReceiptForStorage
+}
+
+type nodeDataPacket struct {
+	ReqID uint64
+	Data  [][]byte
+}
+
+// txLookup locates a transaction in the canonical chain of the server, the proof
+// holds the nodes of the transaction trie of the block from its root down to the
+// transaction. An empty block hash means the transaction is unknown.
+type txLookup struct {
+	BlockHash   utils.Hash
+	BlockHeight uint64
+	TxIndex     uint64
+	Proof       [][]byte
+}
+
+type txLookupsPacket struct {
+	ReqID   uint64
+	Lookups []*txLookup
+}
@@ -0,0 +1,343 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core"
+	"github.com/UranusBlockStack/uranus/core/txpool"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+const chainHeadChanSize = 10
+
+// Server answers the requests of the light clients from the local full chain and
+// announces them the new heads.
+type Server struct {
+	networkId  uint64
+	blockchain *core.BlockChain
+	txpool     *txpool.TxPool
+	maxPeers   int
+
+	peers        *peerSet
+	SubProtocols []*p2p.Protocol
+
+	chainCh  chan feed.BlockAndLogsEvent
+	chainSub feed.Subscription
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer creates the light protocol handler of a full node.
+func NewServer(blockchain *core.BlockChain, txpool *txpool.TxPool) *Server {
+	s := &Server{
+		blockchain: blockchain,
+		txpool:     txpool,
+		peers:      newPeerSet(),
+		quit:       make(chan struct{}),
+	}
+	s.SubProtocols = []*p2p.Protocol{{
+		Name:    ProtocolName,
+		Version: protocolVersion,
+		Offset:  protocolOffset,
+		Size:    protocolSize,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			s.wg.Add(1)
+			defer s.wg.Done()
+			return s.handle(newPeer(int(protocolVersion), p, rw))
+		},
+		PeerInfo: func(id discover.NodeID) interface{} {
+			if p := s.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+				return p.Info()
+			}
+			return nil
+		},
+	}}
+	return s
+}
+
+// Start starts announcing the new heads to the light peers.
+func (s *Server) Start(maxPeers int) {
+	s.maxPeers = maxPeers
+	s.chainCh = make(chan feed.BlockAndLogsEvent, chainHeadChanSize)
+	s.chainSub = s.blockchain.SubscribeChainBlockEvent(s.chainCh)
+	go s.announceLoop()
+}
+
+// Stop disconnects the light peers.
+func (s *Server) Stop() {
+	log.Info("Stopping uranus light server")
+	s.chainSub.Unsubscribe()
+	close(s.quit)
+	s.peers.Close()
+	s.wg.Wait()
+	log.Info("uranus light server stopped")
+}
+
+func (s *Server) handle(p *peer) error {
+	if s.peers.Len() >= s.maxPeers && !p.Trusted() {
+		return fmt.Errorf("too many peer")
+	}
+	var (
+		genesis = s.blockchain.GetBlockByHeight(0)
+		head    = s.blockchain.CurrentBlock().BlockHeader()
+		hash    = head.Hash()
+		td      = s.blockchain.GetTd(hash)
+	)
+	if err := p.Handshake(s.networkId, td, hash, head.Height.Uint64(), genesis.Hash(), true); err != nil {
+		log.Debugf("uranus light handshake failed --- %v", err)
+		return err
+	}
+	if err := s.peers.Register(p); err != nil {
+		return err
+	}
+	defer s.peers.Unregister(p.id)
+	log.Debugf("uranus light peer connected name %v", p.Name())
+
+	for {
+		if err := s.handleMsg(p); err != nil {
+			log.Debugf("uranus light message handling failed --- %v", err)
+			return err
+		}
+	}
+}
+
+func (s *Server) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+
+	switch msg.Code {
+	case StatusMsg:
+		return fmt.Errorf("uncontrolled status message")
+
+	case AnnounceMsg:
+		// Only light clients follow the announcements, the full nodes sync through the base protocol
+
+	case GetBlockHeadersMsg:
+		var req getBlockHeadersPacket
+		if err := msg.DecodePayload(&req); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		query := req.Query
+		var (
+			bytes   utils.StorageSize
+			headers []*types.BlockHeader
+		)
+		for len(headers) < int(query.Amount) && len(headers) < MaxHeaderFetch && bytes < softResponseLimit {
+			block := s.blockchain.GetBlockByHeight(query.Origin)
+			if block == nil {
+				break
+			}
+			headers = append(headers, block.BlockHeader())
+			bytes += estHeaderRlpSize
+
+			if query.Reverse {
+				if query.Origin < query.Skip+1 {
+					break
+				}
+				query.Origin -= query.Skip + 1
+			} else {
+				query.Origin += query.Skip + 1
+			}
+		}
+		return p.SendBlockHeaders(req.ReqID, headers)
+
+	case GetBlockBodiesMsg:
+		var req hashesPacket
+		if err := msg.DecodePayload(&req); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		var (
+			bytes  utils.StorageSize
+			bodies []*blockBody
+		)
+		for _, hash := range req.Hashes {
+			if bytes >= softResponseLimit || len(bodies) >= MaxBodyFetch {
+				break
+			}
+			if block := s.blockchain.GetBlockByHash(hash); block != nil {
+				bodies = append(bodies, &blockBody{Transactions: block.Transactions(), Actions: block.Actions()})
+				bytes += block.Size()
+			}
+		}
+		return p.SendBlockBodies(req.ReqID, bodies)
+
+	case GetReceiptsMsg:
+		var req hashesPacket
+		if err := msg.DecodePayload(&req); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		var (
+			bytes    int
+			receipts [][]*types.ReceiptForStorage
+		)
+		for _, hash := range req.Hashes {
+			if bytes >= softResponseLimit || len(receipts) >= MaxReceiptFetch {
+				break
+			}
+			if !s.blockchain.HasBlock(hash) {
+				continue
+			}
+			results := s.blockchain.GetReceipts(hash)
+			storage := make([]*types.ReceiptForStorage, len(results))
+			for i, receipt := range results {
+				storage[i] = (*types.ReceiptForStorage)(receipt)
+				bytes += int(receipt.Size())
+			}
+			receipts = append(receipts, storage)
+		}
+		return p.SendReceipts(req.ReqID, receipts)
+
+	case GetNodeDataMsg:
+		var req hashesPacket
+		if err := msg.DecodePayload(&req); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		var (
+			bytes int
+			data  [][]byte
+		)
+		for _, hash := range req.Hashes {
+			if bytes >= softResponseLimit || len(data) >= MaxStateFetch {
+				break
+			}
+			if entry, err := s.blockchain.TrieNode(hash); err == nil && len(entry) > 0 {
+				data = append(data, entry)
+				bytes += len(entry)
+			}
+		}
+		return p.SendNodeData(req.ReqID, data)
+
+	case GetTxLookupsMsg:
+		var req hashesPacket
+		if err := msg.DecodePayload(&req); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		var lookups []*txLookup
+		for _, hash := range req.Hashes {
+			if len(lookups) >= MaxTxLookup {
+				break
+			}
+			lookups = append(lookups, s.lookupTx(hash))
+		}
+		return p.SendTxLookups(req.ReqID, lookups)
+
+	case SendTxMsg:
+		var txs []*types.Transaction
+		if err := msg.DecodePayload(&txs); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		for i, tx := range txs {
+			if tx == nil {
+				return fmt.Errorf("transaction %d is nil", i)
+			}
+		}
+		s.txpool.AddTxsChan(txs)
+
+	default:
+		return fmt.Errorf("invalid message code %v", msg.Code)
+	}
+	return nil
+}
+
+// lookupTx locates the transaction in the canonical chain and proves it against
+// the transaction root of its block.
+func (s *Server) lookupTx(hash utils.Hash) *txLookup {
+	stx := s.blockchain.GetTransactionByHash(hash)
+	if stx == nil {
+		return &txLookup{}
+	}
+	block := s.blockchain.GetBlockByHash(stx.BlockHash)
+	if block == nil {
+		return &txLookup{}
+	}
+	proof, err := proveTx(block.Transactions(), stx.TxIndex)
+	if err != nil {
+		return &txLookup{}
+	}
+	return &txLookup{
+		BlockHash:   stx.BlockHash,
+		BlockHeight: stx.BlockHeight,
+		TxIndex:     stx.TxIndex,
+		Proof:       proof,
+	}
+}
+
+// proveTx returns the merkle proof of the transaction at index in the transaction
+// trie of txs.
+func proveTx(txs types.Transactions, index uint64) (proofList, error) {
+	trie := new(mtp.Trie)
+	for i := 0; i < txs.Len(); i++ {
+		key, _ := rlp.EncodeToBytes(uint(i))
+		trie.Update(key, txs.GetRlp(i))
+	}
+	key, _ := rlp.EncodeToBytes(uint(index))
+	proof := new(proofList)
+	if err := trie.Prove(key, 0, proof); err != nil {
+		return nil, err
+	}
+	return *proof, nil
+}
+
+// announceLoop announces the new canonical heads to the light clients.
+func (s *Server) announceLoop() {
+	for {
+		select {
+		case ev := <-s.chainCh:
+			var (
+				hash   = ev.Block.Hash()
+				height = ev.Block.Height().Uint64()
+				td     = s.blockchain.GetTd(hash)
+			)
+			if td == nil {
+				continue
+			}
+			for _, p := range s.peers.AllPeers() {
+				if p.serve {
+					continue
+				}
+				if err := p.SendAnnounce(hash, height, td); err != nil {
+					log.Debugf("Failed to announce head to light peer %v: %v", p.id, err)
+				}
+			}
+		case <-s.chainSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// proofList collects the nodes of a merkle proof in their order along the path.
+type proofList [][]byte
+
+// Put implements db.Writer.
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
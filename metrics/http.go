@@ -0,0 +1,47 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+)
+
+// Handler serves the metrics of the default registry in the Prometheus text
+// format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		DefaultRegistry.Write(&buf)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
+
+// StartHTTP serves the metrics at /metrics on the given address.
+func StartHTTP(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	log.Infof("Starting metrics server addr: %v", "http://"+address+"/metrics")
+	go func() {
+		if err := http.ListenAndServe(address, mux); err != nil {
+			log.Errorf("Failure in running metrics server err: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,392 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package metrics implements a small metrics registry which is exposed in the
+// Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the histogram buckets in seconds used for latencies.
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metric is a named family of samples.
+type Metric interface {
+	// Write writes the samples in the Prometheus text format.
+	Write(w io.Writer)
+}
+
+// Registry holds the metrics by name.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]Metric
+}
+
+// DefaultRegistry is the registry served by Handler.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]Metric)}
+}
+
+// Register adds the metric under the given name, replacing any metric of the
+// same name.
+func (r *Registry) Register(name string, m Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = m
+}
+
+// Unregister removes the metric of the given name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.metrics, name)
+}
+
+// Write writes all the metrics sorted by name.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]Metric, len(names))
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.RUnlock()
+
+	for _, m := range metrics {
+		m.Write(w)
+	}
+}
+
+// desc describes a metric family.
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escape(d.help, false))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.typ)
+}
+
+func (d *desc) writeSample(w io.Writer, suffix string, values []string, extra string, v float64) {
+	var pairs []string
+	for i, label := range d.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label, escape(values[i], true)))
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	labels := ""
+	if len(pairs) > 0 {
+		labels = "{" + strings.Join(pairs, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s%s%s %s\n", d.name, suffix, labels, formatFloat(v))
+}
+
+func escape(s string, quote bool) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	if quote {
+		s = strings.Replace(s, `"`, `\"`, -1)
+	}
+	return s
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// value is a float64 updated atomically.
+type value struct {
+	bits uint64
+}
+
+func (v *value) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&v.bits))
+}
+
+func (v *value) store(f float64) {
+	atomic.StoreUint64(&v.bits, math.Float64bits(f))
+}
+
+func (v *value) add(f float64) {
+	for {
+		old := atomic.LoadUint64(&v.bits)
+		if atomic.CompareAndSwapUint64(&v.bits, old, math.Float64bits(math.Float64frombits(old)+f)) {
+			return
+		}
+	}
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	value
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() { c.add(1) }
+
+// Add increments the counter by v, which must not be negative.
+func (c *Counter) Add(v float64) { c.add(v) }
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() float64 { return c.load() }
+
+// Gauge is a value which can go up and down.
+type Gauge struct {
+	value
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) { g.store(v) }
+
+// Add changes the gauge by v.
+func (g *Gauge) Add(v float64) { g.add(v) }
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 { return g.load() }
+
+// Histogram counts the observations in cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe adds a single observation.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w io.Writer, d *desc, values []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		d.writeSample(w, "_bucket", values, fmt.Sprintf("le=\"%s\"", formatFloat(bound)), float64(h.counts[i]))
+	}
+	d.writeSample(w, "_bucket", values, "le=\"+Inf\"", float64(h.count))
+	d.writeSample(w, "_sum", values, "", h.sum)
+	d.writeSample(w, "_count", values, "", float64(h.count))
+}
+
+// vec keeps the children of a labelled metric.
+type vec struct {
+	desc
+	mu       sync.Mutex
+	children map[string]interface{}
+	values   map[string][]string
+	create   func() interface{}
+}
+
+func newVec(name, help, typ string, labels []string, create func() interface{}) *vec {
+	return &vec{
+		desc:     desc{name: name, help: help, typ: typ, labels: labels},
+		children: make(map[string]interface{}),
+		values:   make(map[string][]string),
+		create:   create,
+	}
+}
+
+func (v *vec) with(values []string) interface{} {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s: %d label values for %d labels", v.name, len(values), len(v.labels)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	child, ok := v.children[key]
+	if !ok {
+		child = v.create()
+		v.children[key] = child
+		v.values[key] = append([]string{}, values...)
+	}
+	return child
+}
+
+func (v *vec) each(fn func(values []string, child interface{})) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	children := make([]interface{}, len(keys))
+	values := make([][]string, len(keys))
+	for i, key := range keys {
+		children[i], values[i] = v.children[key], v.values[key]
+	}
+	v.mu.Unlock()
+
+	for i := range keys {
+		fn(values[i], children[i])
+	}
+}
+
+// CounterVec is a family of counters partitioned by labels.
+type CounterVec struct {
+	*vec
+}
+
+// NewCounterVec creates a counter family and registers it in the default
+// registry.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labels, func() interface{} { return new(Counter) })}
+	DefaultRegistry.Register(name, c)
+	return c
+}
+
+// With returns the counter of the given label values.
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.with(values).(*Counter)
+}
+
+// Write implements Metric.
+func (c *CounterVec) Write(w io.Writer) {
+	c.writeHeader(w)
+	c.each(func(values []string, child interface{}) {
+		c.writeSample(w, "", values, "", child.(*Counter).Value())
+	})
+}
+
+// HistogramVec is a family of histograms partitioned by labels.
+type HistogramVec struct {
+	*vec
+}
+
+// NewHistogramVec creates a histogram family and registers it in the default
+// registry.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{newVec(name, help, "histogram", labels, func() interface{} { return newHistogram(buckets) })}
+	DefaultRegistry.Register(name, h)
+	return h
+}
+
+// With returns the histogram of the given label values.
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.with(values).(*Histogram)
+}
+
+// Write implements Metric.
+func (h *HistogramVec) Write(w io.Writer) {
+	h.writeHeader(w)
+	h.each(func(values []string, child interface{}) {
+		child.(*Histogram).write(w, &h.desc, values)
+	})
+}
+
+// NewCounter creates a counter and registers it in the default registry.
+func NewCounter(name, help string) *Counter {
+	return NewCounterVec(name, help).With()
+}
+
+// NewHistogram creates a histogram and registers it in the default registry.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return NewHistogramVec(name, help, buckets).With()
+}
+
+// NewGauge creates a gauge and registers it in the default registry.
+func NewGauge(name, help string) *Gauge {
+	g := new(Gauge)
+	RegisterGaugeFunc(name, help, g.Value)
+	return g
+}
+
+// Collector reads its samples when the metrics are scraped.
+type Collector struct {
+	desc
+	collect func(emit func(v float64, values ...string))
+}
+
+// Write implements Metric.
+func (c *Collector) Write(w io.Writer) {
+	c.writeHeader(w)
+	c.collect(func(v float64, values ...string) {
+		if len(values) != len(c.labels) {
+			panic(fmt.Sprintf("metric %s: %d label values for %d labels", c.name, len(values), len(c.labels)))
+		}
+		c.writeSample(w, "", values, "", v)
+	})
+}
+
+// RegisterCollector registers a gauge family in the default registry whose
+// samples are emitted by collect on each scrape.
+func RegisterCollector(name, help string, labels []string, collect func(emit func(v float64, values ...string))) {
+	DefaultRegistry.Register(name, &Collector{
+		desc:    desc{name: name, help: help, typ: "gauge", labels: labels},
+		collect: collect,
+	})
+}
+
+// RegisterCounterCollector is RegisterCollector for counters.
+func RegisterCounterCollector(name, help string, labels []string, collect func(emit func(v float64, values ...string))) {
+	DefaultRegistry.Register(name, &Collector{
+		desc:    desc{name: name, help: help, typ: "counter", labels: labels},
+		collect: collect,
+	})
+}
+
+// RegisterGaugeFunc registers a gauge in the default registry whose value is
+// read from fn on each scrape.
+func RegisterGaugeFunc(name, help string, fn func() float64) {
+	RegisterCollector(name, help, nil, func(emit func(v float64, values ...string)) {
+		emit(fn())
+	})
+}
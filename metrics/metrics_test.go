@@ -0,0 +1,70 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	DefaultRegistry = NewRegistry()
+
+	bytesIn := NewCounterVec("test_bytes_total", "Bytes received.", "code")
+	bytesIn.With("1").Add(10)
+	bytesIn.With("1").Inc()
+	bytesIn.With("2\"").Add(3)
+
+	latency := NewHistogram("test_seconds", "Latency.", []float64{0.1, 1})
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(5)
+
+	RegisterCollector("test_slots", "Slots.", []string{"validator"}, func(emit func(v float64, values ...string)) {
+		emit(4, "0x01")
+	})
+	NewGauge("test_height", "Height.").Set(42)
+
+	var buf bytes.Buffer
+	DefaultRegistry.Write(&buf)
+	assert.Equal(t, `# HELP test_bytes_total Bytes received.
+# TYPE test_bytes_total counter
+test_bytes_total{code="1"} 11
+test_bytes_total{code="2\""} 3
+# HELP test_height Height.
+# TYPE test_height gauge
+test_height 42
+# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 5.55
+test_seconds_count 3
+# HELP test_slots Slots.
+# TYPE test_slots gauge
+test_slots{validator="0x01"} 4
+`, buf.String())
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, buf.String(), rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+}
@@ -30,6 +30,8 @@ import (
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/p2p"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/p2p/nat"
+	"github.com/UranusBlockStack/uranus/p2p/netutil"
 	"github.com/UranusBlockStack/uranus/params"
 	"github.com/UranusBlockStack/uranus/rpc"
 )
@@ -163,6 +165,36 @@ func (n *Node) Start() error {
 			p2pServer.PrivateKey = nodeKey
 		}
 	}
+	static, err := p2p.ParseNodes(p2pServer.StaticNodeStrs)
+	if err != nil {
+		return fmt.Errorf("invalid p2p static nodes: %v", err)
+	}
+	p2pServer.StaticNodes = append(p2pServer.StaticNodes, static...)
+	trusted, err := p2p.ParseNodes(p2pServer.TrustedNodeStrs)
+	if err != nil {
+		return fmt.Errorf("invalid p2p trusted nodes: %v", err)
+	}
+	p2pServer.TrustedNodes = append(p2pServer.TrustedNodes, trusted...)
+	if p2pServer.StaticNodesFile == "" {
+		p2pServer.StaticNodesFile = n.config.resolvePath("static-nodes.json")
+	}
+	if p2pServer.TrustedNodesFile == "" {
+		p2pServer.TrustedNodesFile = n.config.resolvePath("trusted-nodes.json")
+	}
+	if p2pServer.NAT == nil && p2pServer.NATStr != "" {
+		natif, err := nat.Parse(p2pServer.NATStr)
+		if err != nil {
+			return fmt.Errorf("invalid p2p nat %q: %v", p2pServer.NATStr, err)
+		}
+		p2pServer.NAT = natif
+	}
+	if p2pServer.NetRestrict == nil && p2pServer.NetRestrictStr != "" {
+		list, err := netutil.ParseNetlist(p2pServer.NetRestrictStr)
+		if err != nil {
+			return fmt.Errorf("invalid p2p netrestrict %q: %v", p2pServer.NetRestrictStr, err)
+		}
+		p2pServer.NetRestrict = list
+	}
 	if p2pServer.NodeDatabase == "" {
 		p2pServer.NodeDatabase = n.config.resolvePath("nodes")
 	}
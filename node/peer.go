@@ -170,6 +170,44 @@ func (p *peer) RequestBlocks(hashes []utils.Hash) error {
 	return p2p.SendMessage(p.rw, GetBlocksMsg, hashes)
 }
 
+func (p *peer) SendBlockHeaders(headers []*types.BlockHeader) error {
+	return p2p.SendMessage(p.rw, BlockHeadersMsg, headers)
+}
+
+func (p *peer) SendBlockBodies(bodies []*blockBody) error {
+	return p2p.SendMessage(p.rw, BlockBodiesMsg, bodies)
+}
+
+func (p *peer) SendNodeData(data [][]byte) error {
+	return p2p.SendMessage(p.rw, NodeDataMsg, data)
+}
+
+func (p *peer) SendReceipts(receipts [][]*types.ReceiptForStorage) error {
+	return p2p.SendMessage(p.rw, ReceiptsMsg, receipts)
+}
+
+// RequestHeadersByHash fetches a batch of headers starting at the block of the given hash.
+func (p *peer) RequestHeadersByHash(origin utils.Hash, amount int, skip int, reverse bool) error {
+	return p2p.SendMessage(p.rw, GetBlockHeadersMsg, &getBlockHeadersData{Origin: hashOrNumber{Hash: origin}, Amount: uint64(amount), Skip: uint64(skip), Reverse: reverse})
+}
+
+// RequestHeadersByNumber fetches a batch of headers starting at the given height.
+func (p *peer) RequestHeadersByNumber(origin uint64, amount int, skip int, reverse bool) error {
+	return p2p.SendMessage(p.rw, GetBlockHeadersMsg, &getBlockHeadersData{Origin: hashOrNumber{Number: origin}, Amount: uint64(amount), Skip: uint64(skip), Reverse: reverse})
+}
+
+func (p *peer) RequestBodies(hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetBlockBodiesMsg, hashes)
+}
+
+func (p *peer) RequestNodeData(hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetNodeDataMsg, hashes)
+}
+
+func (p *peer) RequestReceipts(hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetReceiptsMsg, hashes)
+}
+
 func (p *peer) RequestHashesFromNumber(from uint64, count int) error {
 	return p2p.SendMessage(p.rw, GetBlockHashesFromNumberMsg, getBlockHashesFromNumberData{from, uint64(count)})
 }
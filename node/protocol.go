@@ -19,6 +19,7 @@ package node
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"math/rand"
@@ -47,6 +48,14 @@ var baseProtocolVersion uint = 1
 
 var maxMsgSize = 10 * 1024 * 1024
 
+// reputation changes of the peers, a peer is banned once its score drops too low
+const (
+	reputationDelivery = 1   // useful delivery
+	reputationTimeout  = -10 // nothing delivered in time
+	reputationInvalid  = -50 // invalid blocks, headers or confirmations
+	reputationSpam     = -5  // transactions with a bad signature or malformed
+)
+
 const (
 	StatusMsg                   = iota + 1000 //1000
 	NewBlockHashesMsg                         //1001
@@ -58,6 +67,14 @@ const (
 	NewBlockMsg                               //1007
 	GetBlockHashesFromNumberMsg               //1008
 	ConfirmedMsg                              //1009
+	GetNodeDataMsg                            //1010
+	NodeDataMsg                               //1011
+	GetReceiptsMsg                            //1012
+	ReceiptsMsg                               //1013
+	GetBlockHeadersMsg                        //1014
+	BlockHeadersMsg                           //1015
+	GetBlockBodiesMsg                         //1016
+	BlockBodiesMsg                            //1017
 )
 
 type statusData struct {
@@ -83,6 +100,62 @@ type getBlockHashesFromNumberData struct {
 	Amount uint64
 }
 
+// getBlockHeadersData represents a block header query.
+type getBlockHeadersData struct {
+	Origin  hashOrNumber // Block from which to retrieve headers
+	Amount  uint64       // Maximum number of headers to retrieve
+	Skip    uint64       // Blocks to skip between consecutive headers
+	Reverse bool         // Query direction (false = rising towards latest, true = falling towards genesis)
+}
+
+// hashOrNumber is a combined field for specifying an origin block.
+type hashOrNumber struct {
+	Hash   utils.Hash // Block hash from which to retrieve headers (excludes Number)
+	Number uint64     // Block hash from which to retrieve headers (excludes Hash)
+}
+
+// EncodeRLP is a specialized encoder for hashOrNumber to encode only one of the
+// two contained union fields.
+func (hn *hashOrNumber) EncodeRLP(w io.Writer) error {
+	if hn.Hash == (utils.Hash{}) {
+		return rlp.Encode(w, hn.Number)
+	}
+	if hn.Number != 0 {
+		return fmt.Errorf("both origin hash (%x) and number (%d) provided", hn.Hash, hn.Number)
+	}
+	return rlp.Encode(w, hn.Hash)
+}
+
+// DecodeRLP is a specialized decoder for hashOrNumber to decode the contents
+// into either a block hash or a block number.
+func (hn *hashOrNumber) DecodeRLP(s *rlp.Stream) error {
+	_, size, _ := s.Kind()
+	origin, err := s.Raw()
+	if err == nil {
+		switch {
+		case size == 32:
+			err = rlp.DecodeBytes(origin, &hn.Hash)
+		case size <= 8:
+			err = rlp.DecodeBytes(origin, &hn.Number)
+		default:
+			err = fmt.Errorf("invalid input size %d for origin", size)
+		}
+	}
+	return err
+}
+
+// blockBody represents the data content of a single block.
+type blockBody struct {
+	Transactions []*types.Transaction
+	Actions      []*types.Action
+}
+
+// validatorVerifier is implemented by the consensus engines able to check the seal of a
+// header without the state of its parent.
+type validatorVerifier interface {
+	VerifyValidator(chain consensus.IChainReader, header *types.BlockHeader) error
+}
+
 type ProtocolManager struct {
 	networkId   uint64
 	txpool      *txpool.TxPool
@@ -105,9 +178,10 @@ type ProtocolManager struct {
 	eventMux      *feed.TypeMux
 
 	acceptTxs uint32
+	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
 }
 
-func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, txpool *txpool.TxPool, blockchain *core.BlockChain, chaindb db.Database, engine consensus.Engine) (*ProtocolManager, error) {
+func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, mode protocols.SyncMode, txpool *txpool.TxPool, blockchain *core.BlockChain, chaindb db.Database, engine consensus.Engine) (*ProtocolManager, error) {
 	manager := &ProtocolManager{
 		eventMux:    mux,
 		txpool:      txpool,
@@ -120,6 +194,14 @@ func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, txpool *t
 		quitSync:    make(chan struct{}),
 		acceptTxs:   1,
 	}
+	// Fast sync only makes sense on an empty chain, the state of the local head is kept otherwise
+	if mode == protocols.FastSync {
+		if blockchain.CurrentBlock().Height().Uint64() > 0 {
+			log.Warn("Blockchain not empty, fast sync disabled")
+		} else {
+			manager.fastSync = 1
+		}
+	}
 
 	manager.SubProtocols = make([]*p2p.Protocol, 0)
 	manager.SubProtocols = append(manager.SubProtocols, &p2p.Protocol{
@@ -152,6 +234,14 @@ func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, txpool *t
 	validator := func(header *types.BlockHeader) error {
 		return engine.VerifySeal(blockchain, header)
 	}
+	// The validator of a header is elected by the epoch trie of an earlier block, the
+	// downloader serves the ancestors not imported yet and retrieves the missing nodes.
+	headerVerifier := func(chain consensus.IChainReader, header *types.BlockHeader) error {
+		if verifier, ok := engine.(validatorVerifier); ok {
+			return verifier.VerifyValidator(chain, header)
+		}
+		return engine.VerifySeal(chain, header)
+	}
 
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().Height().Uint64()
@@ -161,8 +251,8 @@ func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, txpool *t
 		return manager.blockchain.InsertChain(blocks)
 	}
 
-	manager.downloader = protocols.NewDownloader(manager.eventMux, manager.blockchain.HasBlock, manager.blockchain.GetBlockByHash, manager.blockchain.CurrentBlock, manager.blockchain.GetTd, inserter, manager.removePeer)
-	manager.fetcher = protocols.NewFetcher(manager.blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.downloader = protocols.NewDownloader(manager.eventMux, chaindb, manager.blockchain.HasBlock, manager.blockchain.GetBlockByHash, manager.blockchain.CurrentBlock, manager.blockchain.GetTd, blockchain, headerVerifier, inserter, manager.blockchain.InsertReceiptChain, manager.blockchain.FastSyncCommitHead, manager.dropPeer)
+	manager.fetcher = protocols.NewFetcher(manager.blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.dropPeer)
 	return manager, nil
 }
 
@@ -181,6 +271,29 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
+// dropPeer lowers the reputation of a misbehaving peer and disconnects it.
+func (pm *ProtocolManager) dropPeer(id string, err error) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		if delta := reputationDelta(err); delta != 0 {
+			peer.AdjustReputation(delta, err.Error())
+		}
+	}
+	pm.removePeer(id)
+}
+
+// reputationDelta returns the reputation change of a peer dropped for err, the
+// errors depending on the local chain, as the unknown ancestors, the future blocks
+// or the pruned states, don't prove the peer wrong.
+func reputationDelta(err error) int {
+	switch {
+	case protocols.IsTimeout(err):
+		return reputationTimeout
+	case protocols.IsInvalid(err), consensus.IsInvalid(err):
+		return reputationInvalid
+	}
+	return 0
+}
+
 func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.maxPeers = maxPeers
 
@@ -217,7 +330,7 @@ func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *p
 }
 
 func (pm *ProtocolManager) handle(p *peer) error {
-	if pm.peers.Len() >= pm.maxPeers {
+	if pm.peers.Len() >= pm.maxPeers && !p.Trusted() {
 		return fmt.Errorf("too many peer")
 	}
 	log.Debugf("uranus peer connected name %v", p.Name())
@@ -239,7 +352,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 	defer pm.removePeer(p.id)
 
-	if err := pm.downloader.RegisterPeer(p.id, p.version, p.head, p.RequestHashes, p.RequestHashesFromNumber, p.RequestBlocks); err != nil {
+	if err := pm.downloader.RegisterPeer(p.id, p.version, p.head, p.RequestHashes, p.RequestHashesFromNumber, p.RequestBlocks, p.RequestHeadersByNumber, p.RequestBodies, p.RequestReceipts, p.RequestNodeData); err != nil {
 		return err
 	}
 	pm.syncTransactions(p)
@@ -388,7 +501,206 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			block.ReceivedAt = msg.ReceivedAt
 		}
 		if blocks := pm.fetcher.Filter(blocks); len(blocks) > 0 {
-			pm.downloader.DeliverBlocks(p.id, blocks)
+			if err := pm.downloader.DeliverBlocks(p.id, blocks); err == nil {
+				p.AdjustReputation(reputationDelivery, "blocks delivered")
+			}
+		}
+
+	case GetBlockHeadersMsg:
+		var query getBlockHeadersData
+		if err := msg.DecodePayload(&query); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		hashMode := query.Origin.Hash != (utils.Hash{})
+		var (
+			bytes   utils.StorageSize
+			headers []*types.BlockHeader
+			unknown bool
+		)
+		for !unknown && len(headers) < int(query.Amount) && bytes < softResponseLimit && len(headers) < protocols.MaxHeaderFetch {
+			var origin *types.BlockHeader
+			if hashMode {
+				origin = pm.blockchain.GetHeader(query.Origin.Hash)
+			} else if block := pm.blockchain.GetBlockByHeight(query.Origin.Number); block != nil {
+				origin = block.BlockHeader()
+			}
+			if origin == nil {
+				break
+			}
+			headers = append(headers, origin)
+			bytes += estHeaderRlpSize
+
+			switch {
+			case hashMode && query.Reverse:
+				// Hash based traversal towards the genesis block
+				for i := 0; i < int(query.Skip)+1; i++ {
+					if header := pm.blockchain.GetHeader(query.Origin.Hash); header != nil && header.Height.Sign() > 0 {
+						query.Origin.Hash = header.PreviousHash
+					} else {
+						unknown = true
+						break
+					}
+				}
+			case hashMode && !query.Reverse:
+				// Hash based traversal towards the leaf block, only along the canonical chain
+				next := origin.Height.Uint64() + query.Skip + 1
+				if canon := pm.blockchain.GetBlockByHeight(origin.Height.Uint64()); canon == nil || canon.Hash() != query.Origin.Hash {
+					unknown = true
+				} else if block := pm.blockchain.GetBlockByHeight(next); block != nil {
+					query.Origin.Hash = block.Hash()
+				} else {
+					unknown = true
+				}
+			case query.Reverse:
+				// Number based traversal towards the genesis block
+				if query.Origin.Number >= query.Skip+1 {
+					query.Origin.Number -= query.Skip + 1
+				} else {
+					unknown = true
+				}
+			case !query.Reverse:
+				// Number based traversal towards the leaf block
+				query.Origin.Number += query.Skip + 1
+			}
+		}
+		return p.SendBlockHeaders(headers)
+
+	case BlockHeadersMsg:
+		var headers []*types.BlockHeader
+		if err := msg.DecodePayload(&headers); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverHeaders(p.id, headers); err != nil {
+			log.Debugf("Failed to deliver headers: %v", err)
+		} else {
+			p.AdjustReputation(reputationDelivery, "headers delivered")
+		}
+
+	case GetBlockBodiesMsg:
+		buf := bytes.NewBuffer(msg.Payload)
+		msgStream := rlp.NewStream(buf, uint64(buf.Len()))
+		if _, err := msgStream.List(); err != nil {
+			return err
+		}
+		var (
+			hash   utils.Hash
+			bytes  utils.StorageSize
+			bodies []*blockBody
+		)
+		for bytes < softResponseLimit && len(bodies) < protocols.MaxBlockFetch {
+			err := msgStream.Decode(&hash)
+			if err == rlp.EOL {
+				break
+			} else if err != nil {
+				return fmt.Errorf("msg %v: %v", msg, err)
+			}
+			if block := pm.blockchain.GetBlockByHash(hash); block != nil {
+				bodies = append(bodies, &blockBody{Transactions: block.Transactions(), Actions: block.Actions()})
+				bytes += block.Size()
+			}
+		}
+		return p.SendBlockBodies(bodies)
+
+	case BlockBodiesMsg:
+		var bodies []*blockBody
+		if err := msg.DecodePayload(&bodies); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		transactions := make([][]*types.Transaction, len(bodies))
+		actions := make([][]*types.Action, len(bodies))
+		for i, body := range bodies {
+			transactions[i] = body.Transactions
+			actions[i] = body.Actions
+		}
+		if err := pm.downloader.DeliverBodies(p.id, transactions, actions); err != nil {
+			log.Debugf("Failed to deliver bodies: %v", err)
+		} else {
+			p.AdjustReputation(reputationDelivery, "bodies delivered")
+		}
+
+	case GetNodeDataMsg:
+		buf := bytes.NewBuffer(msg.Payload)
+		msgStream := rlp.NewStream(buf, uint64(buf.Len()))
+		if _, err := msgStream.List(); err != nil {
+			return err
+		}
+		var (
+			hash  utils.Hash
+			bytes int
+			data  [][]byte
+		)
+		for bytes < softResponseLimit && len(data) < protocols.MaxStateFetch {
+			err := msgStream.Decode(&hash)
+			if err == rlp.EOL {
+				break
+			} else if err != nil {
+				return fmt.Errorf("msg %v: %v", msg, err)
+			}
+			if entry, err := pm.blockchain.TrieNode(hash); err == nil {
+				data = append(data, entry)
+				bytes += len(entry)
+			}
+		}
+		return p.SendNodeData(data)
+
+	case NodeDataMsg:
+		var data [][]byte
+		if err := msg.DecodePayload(&data); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverNodeData(p.id, data); err != nil {
+			log.Debugf("Failed to deliver node state data: %v", err)
+		} else {
+			p.AdjustReputation(reputationDelivery, "node state data delivered")
+		}
+
+	case GetReceiptsMsg:
+		buf := bytes.NewBuffer(msg.Payload)
+		msgStream := rlp.NewStream(buf, uint64(buf.Len()))
+		if _, err := msgStream.List(); err != nil {
+			return err
+		}
+		var (
+			hash     utils.Hash
+			bytes    int
+			receipts [][]*types.ReceiptForStorage
+		)
+		for bytes < softResponseLimit && len(receipts) < protocols.MaxReceiptFetch {
+			err := msgStream.Decode(&hash)
+			if err == rlp.EOL {
+				break
+			} else if err != nil {
+				return fmt.Errorf("msg %v: %v", msg, err)
+			}
+			if !pm.blockchain.HasBlock(hash) {
+				continue
+			}
+			results := pm.blockchain.GetReceipts(hash)
+			storage := make([]*types.ReceiptForStorage, len(results))
+			for i, receipt := range results {
+				storage[i] = (*types.ReceiptForStorage)(receipt)
+				bytes += int(receipt.Size())
+			}
+			receipts = append(receipts, storage)
+		}
+		return p.SendReceipts(receipts)
+
+	case ReceiptsMsg:
+		var storage [][]*types.ReceiptForStorage
+		if err := msg.DecodePayload(&storage); err != nil {
+			return fmt.Errorf("msg %v: %v", msg, err)
+		}
+		receipts := make([]types.Receipts, len(storage))
+		for i, list := range storage {
+			receipts[i] = make(types.Receipts, len(list))
+			for j, receipt := range list {
+				receipts[i][j] = (*types.Receipt)(receipt)
+			}
+		}
+		if err := pm.downloader.DeliverReceipts(p.id, receipts); err != nil {
+			log.Debugf("Failed to deliver receipts: %v", err)
+		} else {
+			p.AdjustReputation(reputationDelivery, "receipts delivered")
 		}
 
 	case NewBlockHashesMsg:
@@ -454,11 +766,27 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.DecodePayload(&txs); err != nil {
 			return fmt.Errorf("msg %v: %v", msg, err)
 		}
-		if pm.txpool.AddTxsChan(txs) {
-			for i, tx := range txs {
-				if tx == nil {
-					return fmt.Errorf("transaction %d is nil", i)
-				}
+		var (
+			valid  = txs[:0]
+			spam   error
+			signer = types.NewSigner(pm.chainconfig.ChainID)
+			height = new(big.Int).Add(pm.blockchain.CurrentBlock().Height(), big.NewInt(1))
+		)
+		for i, tx := range txs {
+			if tx == nil {
+				return fmt.Errorf("transaction %d is nil", i)
+			}
+			if err := checkTx(signer, pm.chainconfig, height, tx); err != nil {
+				spam = err
+				continue
+			}
+			valid = append(valid, tx)
+		}
+		if spam != nil {
+			p.AdjustReputation(reputationSpam, fmt.Sprintf("invalid transactions: %v", spam))
+		}
+		if len(valid) > 0 && pm.txpool.AddTxsChan(valid) {
+			for _, tx := range valid {
 				p.MarkTransaction(tx.Hash())
 			}
 		}
@@ -467,6 +795,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.DecodePayload(&confirmed); err != nil {
 			return fmt.Errorf("msg %v: %v", msg, err)
 		}
+		if !confirmed.IsValidate() {
+			p.AdjustReputation(reputationInvalid, "invalid confirmed signature")
+			return fmt.Errorf("invalid confirmed signature of %v", confirmed.Address)
+		}
 		pm.eventMux.Post(confirmed)
 		pm.BroadcastConfirmed(&confirmed)
 	default:
@@ -475,6 +807,17 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	return nil
 }
 
+// checkTx checks the signature and the fields of a transaction relayed by a peer,
+// height is the one of the next block. A batch payment ahead of its fork isn't
+// the fault of the peer, the pool drops it.
+func checkTx(signer types.Signer, config *params.ChainConfig, height *big.Int, tx *types.Transaction) error {
+	if err := tx.Validate(config, height); err != nil && err != types.ErrBatchPaymentDisabled {
+		return err
+	}
+	_, err := tx.Sender(signer)
+	return err
+}
+
 func (pm *ProtocolManager) BroadcastConfirmed(confirmed *types.Confirmed) {
 	for _, peer := range pm.peers.PeersWithoutConfirmed(confirmed.Hash()) {
 		peer.SendConfirmed(confirmed)
@@ -678,9 +1021,15 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		return
 	}
 
-	if err := pm.downloader.Synchronise(peer.id, peer.head, peer.td); err != nil {
+	mode := protocols.FullSync
+	if atomic.LoadUint32(&pm.fastSync) == 1 {
+		mode = protocols.FastSync
+	}
+	if err := pm.downloader.Synchronise(peer.id, peer.head, peer.td, mode); err != nil {
 		return
 	}
+	// Fast sync is done once, the chain is fully synced from the pivot on
+	atomic.StoreUint32(&pm.fastSync, 0)
 	atomic.StoreUint32(&pm.acceptTxs, 1)
 	if head := pm.blockchain.CurrentBlock(); head.Height().Uint64() > 0 {
 		go pm.BroadcastBlock(head, false)
@@ -689,6 +1038,7 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 
 const (
 	softResponseLimit   = 2 * 1024 * 1024
+	estHeaderRlpSize    = 500
 	txChanSize          = 4096
 	minDesiredPeerCount = 5
 	forceSyncCycle      = 10 * time.Second
@@ -0,0 +1,119 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/core/validator"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBlockHeadersDataEncoding(t *testing.T) {
+	queries := []*getBlockHeadersData{
+		{Origin: hashOrNumber{Hash: utils.Hash{1}}, Amount: 192},
+		{Origin: hashOrNumber{Hash: utils.Hash{2}}, Amount: 3, Skip: 4, Reverse: true},
+		{Origin: hashOrNumber{Number: 0}, Amount: 1},
+		{Origin: hashOrNumber{Number: 1<<64 - 1}, Amount: 192, Skip: 1},
+	}
+	for _, query := range queries {
+		data, err := rlp.EncodeToBytes(query)
+		assert.NoError(t, err)
+		decoded := new(getBlockHeadersData)
+		assert.NoError(t, rlp.DecodeBytes(data, decoded))
+		assert.Equal(t, query, decoded)
+	}
+	// the origin is either a hash or a number
+	_, err := rlp.EncodeToBytes(&getBlockHeadersData{Origin: hashOrNumber{Hash: utils.Hash{1}, Number: 1}})
+	assert.Error(t, err)
+
+	data, err := rlp.EncodeToBytes([]interface{}{make([]byte, 16), uint64(1), uint64(0), false})
+	assert.NoError(t, err)
+	assert.Error(t, rlp.DecodeBytes(data, new(getBlockHeadersData)))
+}
+
+func TestBlockBodyEncoding(t *testing.T) {
+	to := utils.Address{1}
+	tx := types.NewTransaction(types.Binary, 1, big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+	action := types.NewAction(tx.Hash(), to, big.NewInt(1), big.NewInt(2))
+	bodies := []*blockBody{
+		{Transactions: []*types.Transaction{tx}, Actions: []*types.Action{action}},
+		{Transactions: []*types.Transaction{}, Actions: []*types.Action{}},
+	}
+	data, err := rlp.EncodeToBytes(bodies)
+	assert.NoError(t, err)
+
+	var decoded []*blockBody
+	assert.NoError(t, rlp.DecodeBytes(data, &decoded))
+	assert.Len(t, decoded, 2)
+	assert.Equal(t, types.DeriveRootHash(types.Transactions(bodies[0].Transactions)), types.DeriveRootHash(types.Transactions(decoded[0].Transactions)))
+	assert.Equal(t, types.DeriveRootHash(types.Actions(bodies[0].Actions)), types.DeriveRootHash(types.Actions(decoded[0].Actions)))
+	assert.Empty(t, decoded[1].Transactions)
+	assert.Empty(t, decoded[1].Actions)
+}
+
+func TestReputationDelta(t *testing.T) {
+	tests := []struct {
+		err   error
+		delta int
+	}{
+		{dpos.ErrInvalidBlockValidator, reputationInvalid},
+		{validator.ErrBlockTime, reputationInvalid},
+		{validator.ErrTxsRootHash(utils.Hash{1}, utils.Hash{2}), reputationInvalid},
+		{validator.ErrReceiptRootHash(utils.Hash{1}, utils.Hash{2}), reputationInvalid},
+		{validator.ErrFutureBlock, 0},
+		{validator.ErrUnknownAncestor, 0},
+		{validator.ErrPrunedAncestor, 0},
+		{&mtp.MissingNodeError{NodeHash: utils.Hash{1}}, 0},
+		{errors.New("local failure"), 0},
+	}
+	for i, test := range tests {
+		assert.Equal(t, test.delta, reputationDelta(test.err), "test %d: %v", i, test.err)
+	}
+}
+
+func TestCheckTx(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		config = params.TestChainConfig
+		signer = types.NewSigner(config.ChainID)
+		height = big.NewInt(1)
+		to     = utils.Address{1}
+	)
+	newTx := func() *types.Transaction {
+		return types.NewTransaction(types.Binary, 0, big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+	}
+
+	signed := newTx()
+	assert.NoError(t, signed.SignTx(signer, key))
+	assert.NoError(t, checkTx(signer, config, height, signed))
+
+	assert.Error(t, checkTx(signer, config, height, newTx()))
+
+	other := newTx()
+	assert.NoError(t, other.SignTx(types.NewSigner(new(big.Int).Add(config.ChainID, big.NewInt(1))), key))
+	assert.Error(t, checkTx(signer, config, height, other))
+}
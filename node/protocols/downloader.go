@@ -26,8 +26,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mtp"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/feed"
 	set "gopkg.in/fatih/set.v0"
@@ -37,40 +40,98 @@ var (
 	MinHashFetch    = 512
 	MaxHashFetch    = 512
 	MaxBlockFetch   = 128
+	MaxHeaderFetch  = 192
+	MaxReceiptFetch = 256
+	MaxStateFetch   = 384
 	hashTTL         = 5 * time.Second
+	headerTTL       = 5 * time.Second
 	blockSoftTTL    = 3 * time.Second
 	blockHardTTL    = 3 * blockSoftTTL
+	receiptTTL      = 5 * time.Second
+	stateTTL        = 5 * time.Second
 	crossCheckCycle = time.Second
 
 	maxQueuedHashes = 256 * 1024
 	maxBannedHashes = 4096
 	maxBlockProcess = 256
+
+	fsMinFullBlocks = 64 // Number of blocks to retrieve fully even in fast sync
+	fsMaxStateStall = 8  // Number of state deliveries without progress before giving up
+)
+
+// SyncMode represents the synchronisation mode of the downloader.
+type SyncMode int
+
+const (
+	FullSync  SyncMode = iota // Synchronise the entire blockchain history from full blocks
+	FastSync                  // Quickly download the headers, bodies and receipts, full sync only at the chain head
+	LightSync                 // Download the headers only, the rest is retrieved on demand by the light client
 )
 
+func (mode SyncMode) String() string {
+	switch mode {
+	case FullSync:
+		return "full"
+	case FastSync:
+		return "fast"
+	case LightSync:
+		return "light"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSyncMode returns the sync mode of the given name.
+func ParseSyncMode(name string) (SyncMode, error) {
+	switch name {
+	case "", "full":
+		return FullSync, nil
+	case "fast":
+		return FastSync, nil
+	case "light":
+		return LightSync, nil
+	default:
+		return FullSync, fmt.Errorf("unknown sync mode %q, want \"full\", \"fast\" or \"light\"", name)
+	}
+}
+
 var (
-	errBusy             = errors.New("busy")
-	errUnknownPeer      = errors.New("peer is unknown or unhealthy")
-	errBadPeer          = errors.New("action from bad peer ignored")
-	errStallingPeer     = errors.New("peer is stalling")
-	errBannedHead       = errors.New("peer head hash already banned")
-	errNoPeers          = errors.New("no peers to keep download active")
-	errPendingQueue     = errors.New("pending items in queue")
-	errTimeout          = errors.New("timeout")
-	errEmptyHashSet     = errors.New("empty hash set by peer")
-	errPeersUnavailable = errors.New("no peers available or all peers tried for block download process")
-	errAlreadyInPool    = errors.New("hash already in pool")
-	errInvalidChain     = errors.New("retrieved hash chain is invalid")
-	errCrossCheckFailed = errors.New("block cross-check failed")
-	errCancelHashFetch  = errors.New("hash fetching canceled (requested)")
-	errCancelBlockFetch = errors.New("block downloading canceled (requested)")
-	errNoSyncActive     = errors.New("no sync active")
+	errBusy              = errors.New("busy")
+	errUnknownPeer       = errors.New("peer is unknown or unhealthy")
+	errBadPeer           = errors.New("action from bad peer ignored")
+	errStallingPeer      = errors.New("peer is stalling")
+	errBannedHead        = errors.New("peer head hash already banned")
+	errNoPeers           = errors.New("no peers to keep download active")
+	errPendingQueue      = errors.New("pending items in queue")
+	errTimeout           = errors.New("timeout")
+	errEmptyHashSet      = errors.New("empty hash set by peer")
+	errPeersUnavailable  = errors.New("no peers available or all peers tried for block download process")
+	errAlreadyInPool     = errors.New("hash already in pool")
+	errInvalidChain      = errors.New("retrieved hash chain is invalid")
+	errCrossCheckFailed  = errors.New("block cross-check failed")
+	errCancelHashFetch   = errors.New("hash fetching canceled (requested)")
+	errCancelBlockFetch  = errors.New("block downloading canceled (requested)")
+	errCancelHeaderFetch = errors.New("header fetching canceled (requested)")
+	errInvalidHeader     = errors.New("retrieved header is invalid")
+	errNoSyncActive      = errors.New("no sync active")
+	errCancelReceipts    = errors.New("receipt downloading canceled (requested)")
+	errCancelStateFetch  = errors.New("state data download canceled (requested)")
+	errInvalidReceipts   = errors.New("retrieved receipts are invalid")
+	errStateStalled      = errors.New("state data download stalled")
 )
 
 type hashCheckFn func(utils.Hash) bool
 type blockRetrievalFn func(utils.Hash) *types.Block
 type headRetrievalFn func() *types.Block
 type chainInsertFn func(types.Blocks) (int, error)
-type peerDropFn func(id string)
+type receiptChainInsertFn func(types.Blocks, []types.Receipts) (int, error)
+type headCommitFn func(utils.Hash) error
+
+// headerVerifierFn verifies the seal of a header, its ancestors are read from chain.
+type headerVerifierFn func(chain consensus.IChainReader, header *types.BlockHeader) error
+
+// peerDropFn disconnects a misbehaving peer, err tells the reason.
+type peerDropFn func(id string, err error)
 type getTdFn func(utils.Hash) *big.Int
 
 type blockPack struct {
@@ -83,6 +144,27 @@ type hashPack struct {
 	hashes []utils.Hash
 }
 
+type headerPack struct {
+	peerID  string
+	headers []*types.BlockHeader
+}
+
+type bodyPack struct {
+	peerID       string
+	transactions [][]*types.Transaction
+	actions      [][]*types.Action
+}
+
+type receiptPack struct {
+	peerID   string
+	receipts []types.Receipts
+}
+
+type statePack struct {
+	peerID string
+	states [][]byte
+}
+
 type crossCheck struct {
 	expire time.Time
 	parent utils.Hash
@@ -103,21 +185,39 @@ type Downloader struct {
 	importDone  int
 	importLock  sync.Mutex
 
-	hasBlock    hashCheckFn
-	getBlock    blockRetrievalFn
-	headBlock   headRetrievalFn
-	insertChain chainInsertFn
-	dropPeer    peerDropFn
-	gettd       getTdFn
+	stateDb db.Database
+
+	hasBlock       hashCheckFn
+	getBlock       blockRetrievalFn
+	headBlock      headRetrievalFn
+	chain          consensus.IChainReader
+	verifyHeader   headerVerifierFn
+	insertChain    chainInsertFn
+	insertReceipts receiptChainInsertFn
+	commitHead     headCommitFn
+	dropPeer       peerDropFn
+	gettd          getTdFn
 
 	synchronising int32
 	processing    int32
 	notified      int32
 
+	mode  SyncMode // Synchronisation mode of the running sync
+	pivot uint64   // Fast sync pivot block height, zero if the whole sync is full
+
+	skeleton     map[utils.Hash]*types.BlockHeader // Verified headers not imported yet
+	skeletonLock sync.RWMutex
+	stateLock    sync.Mutex // Serialises the trie downloads sharing the node data deliveries
+
 	newPeerCh chan *peer
 	hashCh    chan hashPack
 	blockCh   chan blockPack
+	headerCh  chan headerPack
+	bodyCh    chan bodyPack
+	receiptCh chan receiptPack
+	stateCh   chan statePack
 	processCh chan bool
+	pivotCh   chan error
 
 	cancelCh   chan struct{}
 	cancelLock sync.RWMutex
@@ -128,21 +228,31 @@ type Block struct {
 	OriginPeer string
 }
 
-func NewDownloader(mux *feed.TypeMux, hasBlock hashCheckFn, getBlock blockRetrievalFn, headBlock headRetrievalFn, gettd getTdFn, insertChain chainInsertFn, dropPeer peerDropFn) *Downloader {
+func NewDownloader(mux *feed.TypeMux, stateDb db.Database, hasBlock hashCheckFn, getBlock blockRetrievalFn, headBlock headRetrievalFn, gettd getTdFn, chain consensus.IChainReader, verifyHeader headerVerifierFn, insertChain chainInsertFn, insertReceipts receiptChainInsertFn, commitHead headCommitFn, dropPeer peerDropFn) *Downloader {
 	downloader := &Downloader{
-		mux:         mux,
-		queue:       newQueue(),
-		peers:       newPeerSet(),
-		hasBlock:    hasBlock,
-		getBlock:    getBlock,
-		headBlock:   headBlock,
-		gettd:       gettd,
-		insertChain: insertChain,
-		dropPeer:    dropPeer,
-		newPeerCh:   make(chan *peer, 1),
-		hashCh:      make(chan hashPack, 1),
-		blockCh:     make(chan blockPack, 1),
-		processCh:   make(chan bool, 1),
+		mux:            mux,
+		queue:          newQueue(),
+		peers:          newPeerSet(),
+		stateDb:        stateDb,
+		hasBlock:       hasBlock,
+		getBlock:       getBlock,
+		headBlock:      headBlock,
+		gettd:          gettd,
+		chain:          chain,
+		verifyHeader:   verifyHeader,
+		insertChain:    insertChain,
+		insertReceipts: insertReceipts,
+		commitHead:     commitHead,
+		dropPeer:       dropPeer,
+		newPeerCh:      make(chan *peer, 1),
+		hashCh:         make(chan hashPack, 1),
+		blockCh:        make(chan blockPack, 1),
+		headerCh:       make(chan headerPack, 1),
+		bodyCh:         make(chan bodyPack, 1),
+		receiptCh:      make(chan receiptPack, 1),
+		stateCh:        make(chan statePack, 1),
+		processCh:      make(chan bool, 1),
+		skeleton:       make(map[utils.Hash]*types.BlockHeader),
 	}
 	downloader.banned = set.New(set.ThreadSafe)
 	return downloader
@@ -167,17 +277,36 @@ func (d *Downloader) Stats() (pending int, cached int, importing int, estimate t
 	return
 }
 
+// IsTimeout reports whether the peer was dropped for not delivering in time
+// rather than for delivering invalid data.
+func IsTimeout(err error) bool {
+	switch err {
+	case errTimeout, errStallingPeer, errEmptyHashSet, errPeersUnavailable:
+		return true
+	}
+	return false
+}
+
+// IsInvalid reports whether the peer was dropped for delivering invalid data.
+func IsInvalid(err error) bool {
+	switch err {
+	case errBadPeer, errBannedHead, errInvalidChain, errCrossCheckFailed, errInvalidHeader, errInvalidReceipts:
+		return true
+	}
+	return false
+}
+
 func (d *Downloader) Synchronising() bool {
 	return atomic.LoadInt32(&d.synchronising) > 0
 }
 
-func (d *Downloader) RegisterPeer(id string, version int, head utils.Hash, getRelHashes relativeHashFetcherFn, getAbsHashes absoluteHashFetcherFn, getBlocks blockFetcherFn) error {
+func (d *Downloader) RegisterPeer(id string, version int, head utils.Hash, getRelHashes relativeHashFetcherFn, getAbsHashes absoluteHashFetcherFn, getBlocks blockFetcherFn, getHeaders headerFetcherFn, getBodies bodyFetcherFn, getReceipts receiptFetcherFn, getNodeData stateFetcherFn) error {
 	if d.banned.Has(head) {
 		log.Infof("Register rejected, head hash banned: %v", id)
 		return errBannedHead
 	}
 	log.Infof("Registering peer %v", id)
-	if err := d.peers.Register(newPeer(id, head, getRelHashes, getAbsHashes, getBlocks)); err != nil {
+	if err := d.peers.Register(newPeer(id, head, getRelHashes, getAbsHashes, getBlocks, getHeaders, getBodies, getReceipts, getNodeData)); err != nil {
 		log.Infof("Register failed: %v", err)
 		return err
 	}
@@ -193,10 +322,10 @@ func (d *Downloader) UnregisterPeer(id string) error {
 	return nil
 }
 
-func (d *Downloader) Synchronise(id string, head utils.Hash, td *big.Int) error {
-	log.Infof("Attempting synchronisation: %v, head 0x%x, TD %v", id, head[:4], td)
+func (d *Downloader) Synchronise(id string, head utils.Hash, td *big.Int, mode SyncMode) error {
+	log.Infof("Attempting synchronisation: %v, head 0x%x, TD %v, mode %v", id, head[:4], td, mode)
 
-	err := d.synchronise(id, head, td)
+	err := d.synchronise(id, head, td, mode)
 	switch err {
 	case nil:
 		log.Infof("Synchronisation completed")
@@ -204,9 +333,9 @@ func (d *Downloader) Synchronise(id string, head utils.Hash, td *big.Int) error
 	case errBusy:
 		log.Debugf("Synchronisation already in progress")
 
-	case errTimeout, errBadPeer, errStallingPeer, errBannedHead, errEmptyHashSet, errPeersUnavailable, errInvalidChain, errCrossCheckFailed:
+	case errTimeout, errBadPeer, errStallingPeer, errBannedHead, errEmptyHashSet, errPeersUnavailable, errInvalidChain, errCrossCheckFailed, errInvalidHeader, errInvalidReceipts:
 		log.Errorf("Removing peer %v: %v", id, err)
-		d.dropPeer(id)
+		d.dropPeer(id, err)
 
 	case errPendingQueue:
 		log.Errorf("Synchronisation aborted: %v", err)
@@ -217,7 +346,7 @@ func (d *Downloader) Synchronise(id string, head utils.Hash, td *big.Int) error
 	return err
 }
 
-func (d *Downloader) synchronise(id string, hash utils.Hash, td *big.Int) error {
+func (d *Downloader) synchronise(id string, hash utils.Hash, td *big.Int, mode SyncMode) error {
 	if !atomic.CompareAndSwapInt32(&d.synchronising, 0, 1) {
 		return errBusy
 	}
@@ -238,10 +367,16 @@ func (d *Downloader) synchronise(id string, hash utils.Hash, td *big.Int) error
 	d.peers.Reset()
 	d.checks = make(map[utils.Hash]*crossCheck)
 
+	d.skeletonLock.Lock()
+	d.skeleton = make(map[utils.Hash]*types.BlockHeader)
+	d.skeletonLock.Unlock()
+
 	d.cancelLock.Lock()
 	d.cancelCh = make(chan struct{})
 	d.cancelLock.Unlock()
 
+	d.mode = mode
+
 	p := d.peers.Peer(id)
 	if p == nil {
 		return errUnknownPeer
@@ -269,20 +404,49 @@ func (d *Downloader) syncWithPeer(p *peer, hash utils.Hash, td *big.Int) (err er
 		log.Info("Synchronisation terminated", "elapsed", time.Since(start))
 	}(time.Now())
 
+	// the errors are returned as is, Synchronise drops the peer by them
 	number, err := d.findAncestor(p)
 	if err != nil {
-		return fmt.Errorf("findAncestor %v", err)
+		log.Infof("%v: common ancestor not found: %v", p.id, err)
+		return err
+	}
+	d.pivot = 0
+	if d.mode == FastSync {
+		height, err := d.fetchHeight(p)
+		if err != nil {
+			log.Infof("%v: remote chain height unknown: %v", p.id, err)
+			return err
+		}
+		// the blocks close to the head are imported fully, as the peers still keep their state
+		if height > number+uint64(fsMinFullBlocks) {
+			d.pivot = height - uint64(fsMinFullBlocks)
+			d.pivotCh = make(chan error, 1)
+			log.Infof("%v: fast syncing up to pivot #%d", p.id, d.pivot)
+		}
 	}
 	errc := make(chan error, 2)
-	go func() { errc <- d.fetchHashes(p, td, number+1) }()
+	go func() { errc <- d.fetchHeaders(p, td, number+1) }()
 	go func() { errc <- d.fetchBlocks(number + 1) }()
 
 	if err := <-errc; err != nil {
 		d.cancel()
 		<-errc
-		return fmt.Errorf("fetchBlocks or fetchHashes %v", err)
+		log.Infof("%v: header or block fetching failed: %v", p.id, err)
+		return err
+	}
+	if err := <-errc; err != nil {
+		return err
+	}
+	if d.pivot == 0 {
+		return nil
+	}
+	// the state of the pivot is downloaded by the block processing, wait for it
+	select {
+	case err := <-d.pivotCh:
+		return err
+	case <-d.cancelCh:
+		return errCancelStateFetch
 	}
-	return <-errc
 }
 
 func (d *Downloader) cancel() {
@@ -397,56 +561,104 @@ func (d *Downloader) findAncestor(p *peer) (uint64, error) {
 	return start, nil
 }
 
-func (d *Downloader) fetchHashes(p *peer, td *big.Int, from uint64) error {
-	log.Infof("%v: downloading hashes from #%d", p.id, from)
+// fetchHeight retrieves the head block of the peer to learn the height of its chain.
+func (d *Downloader) fetchHeight(p *peer) (uint64, error) {
+	log.Infof("%v: retrieving remote chain height", p.id)
+
+	go p.getBlocks([]utils.Hash{p.head})
+	timeout := time.After(blockHardTTL)
+
+	for {
+		select {
+		case <-d.cancelCh:
+			return 0, errCancelBlockFetch
+
+		case blockPack := <-d.blockCh:
+			if blockPack.peerID != p.id {
+				log.Infof("Received blocks from incorrect peer(%s)", blockPack.peerID)
+				break
+			}
+			for _, block := range blockPack.blocks {
+				if block.Hash() == p.head {
+					return block.Height().Uint64(), nil
+				}
+			}
+			log.Infof("%v: head block not delivered", p.id)
+			return 0, errBadPeer
+
+		case <-d.hashCh:
+
+		case <-timeout:
+			log.Infof("%v: head block timeout", p.id)
+			return 0, errTimeout
+		}
+	}
+}
+
+// fetchHeaders downloads the header skeleton of the chain from the sync peer, the
+// headers are checked to link up and their seal is verified before their bodies are
+// scheduled for retrieval.
+func (d *Downloader) fetchHeaders(p *peer, td *big.Int, from uint64) error {
+	log.Infof("%v: downloading headers from #%d", p.id, from)
 
 	timeout := time.NewTimer(0)
 	<-timeout.C
 	defer timeout.Stop()
 
-	getHashes := func(from uint64) {
-		log.Infof("%v: fetching %d hashes from #%d", p.id, MaxHashFetch, from)
+	getHeaders := func(from uint64) {
+		log.Infof("%v: fetching %d headers from #%d", p.id, MaxHeaderFetch, from)
 
-		go p.getAbsHashes(from, MaxHashFetch)
-		timeout.Reset(hashTTL)
+		go p.getHeaders(from, MaxHeaderFetch, 0, false)
+		timeout.Reset(headerTTL)
 	}
-	getHashes(from)
-	gotHashes := false
+	getHeaders(from)
+	gotHeaders := false
 
+	var last *types.BlockHeader
 	for {
 		select {
 		case <-d.cancelCh:
-			return errCancelHashFetch
+			return errCancelHeaderFetch
 
-		case hashPack := <-d.hashCh:
-			if hashPack.peerID != p.id {
-				log.Infof("Received hashes from incorrect peer(%s)", hashPack.peerID)
+		case headerPack := <-d.headerCh:
+			if headerPack.peerID != p.id {
+				log.Infof("Received headers from incorrect peer(%s)", headerPack.peerID)
 				break
 			}
 			timeout.Stop()
 
-			if len(hashPack.hashes) == 0 {
-				log.Infof("%v: no available hashes", p.id)
+			headers := headerPack.headers
+			if len(headers) == 0 {
+				log.Infof("%v: no available headers", p.id)
 
 				select {
 				case d.processCh <- false:
 				case <-d.cancelCh:
 				}
 
-				if !gotHashes && td.Cmp(d.gettd(d.headBlock().Hash())) > 0 {
+				if !gotHeaders && td.Cmp(d.gettd(d.headBlock().Hash())) > 0 {
 					return errStallingPeer
 				}
 				return nil
 			}
-			gotHashes = true
+			gotHeaders = true
 
-			log.Infof("%v: inserting %d hashes from #%d", p.id, len(hashPack.hashes), from)
+			if err := d.verifyHeaders(headers, from, last); err != nil {
+				log.Infof("%v: invalid headers from #%d: %v", p.id, from, err)
+				if consensus.IsInvalid(err) {
+					return errInvalidHeader
+				}
+				return err
+			}
+			log.Infof("%v: scheduling %d headers from #%d", p.id, len(headers), from)
 
-			inserts := d.queue.Insert(hashPack.hashes, true)
-			if len(inserts) != len(hashPack.hashes) {
-				log.Errorf("%v: stale hashes", p.id)
+			inserts := d.queue.Insert(headers, true)
+			if len(inserts) != len(headers) {
+				log.Errorf("%v: stale headers", p.id)
 				return errBadPeer
 			}
+			last = headers[len(headers)-1]
+
 			cont := d.queue.Pending() < maxQueuedHashes
 			select {
 			case d.processCh <- cont:
@@ -455,16 +667,97 @@ func (d *Downloader) fetchHashes(p *peer, td *big.Int, from uint64) error {
 			if !cont {
 				return nil
 			}
-			from += uint64(len(hashPack.hashes))
-			getHashes(from)
+			from += uint64(len(headers))
+			getHeaders(from)
+
+		case <-d.blockCh:
 
 		case <-timeout.C:
-			log.Infof("%v: hash request timed out", p.id)
+			log.Infof("%v: header request timed out", p.id)
 			return errTimeout
 		}
 	}
 }
 
+// verifyHeaders checks that the headers are contiguous from the given height, that
+// they extend the last scheduled header or the local chain, and verifies their seal
+// against the validators elected by their ancestors, scheduled or imported.
+func (d *Downloader) verifyHeaders(headers []*types.BlockHeader, from uint64, last *types.BlockHeader) error {
+	for i, header := range headers {
+		if header.Height == nil || header.Height.Uint64() != from+uint64(i) {
+			return consensus.NewInvalidError("header #%v delivered, want #%d", header.Height, from+uint64(i))
+		}
+		switch {
+		case i > 0:
+			if header.PreviousHash != headers[i-1].Hash() {
+				return consensus.NewInvalidError("header #%d does not link to its predecessor", from+uint64(i))
+			}
+		case last != nil:
+			if header.PreviousHash != last.Hash() {
+				return consensus.NewInvalidError("header #%d does not link to the skeleton", from)
+			}
+		default:
+			if !d.hasBlock(header.PreviousHash) {
+				return consensus.NewInvalidError("header #%d has unknown parent %x", from, header.PreviousHash)
+			}
+		}
+		if err := d.verifySeal(header); err != nil {
+			if consensus.IsInvalid(err) {
+				return consensus.NewInvalidError("header #%d: %v", from+uint64(i), err)
+			}
+			return fmt.Errorf("header #%d: %v", from+uint64(i), err)
+		}
+		d.skeletonLock.Lock()
+		d.skeleton[header.Hash()] = header
+		d.skeletonLock.Unlock()
+	}
+	return nil
+}
+
+// verifySeal verifies the seal of a header whose ancestors are verified, the epoch
+// trie nodes electing its validator are downloaded when missing.
+func (d *Downloader) verifySeal(header *types.BlockHeader) error {
+	var missing utils.Hash
+	for {
+		err := d.verifyHeader(&skeletonChain{d.chain, d}, header)
+		node, ok := err.(*mtp.MissingNodeError)
+		if !ok || node.NodeHash == missing {
+			return err
+		}
+		missing = node.NodeHash
+		if err := d.syncTrie(missing); err != nil {
+			return err
+		}
+	}
+}
+
+// skeletonChain serves the headers verified by the running sync ahead of the local
+// chain, so the seal of a header is verified before its parent is imported.
+type skeletonChain struct {
+	consensus.IChainReader
+	d *Downloader
+}
+
+func (c *skeletonChain) GetHeader(hash utils.Hash) *types.BlockHeader {
+	c.d.skeletonLock.RLock()
+	header := c.d.skeleton[hash]
+	c.d.skeletonLock.RUnlock()
+	if header != nil {
+		return header
+	}
+	return c.IChainReader.GetHeader(hash)
+}
+
+func (c *skeletonChain) GetBlockByHash(hash utils.Hash) *types.Block {
+	c.d.skeletonLock.RLock()
+	header := c.d.skeleton[hash]
+	c.d.skeletonLock.RUnlock()
+	if header != nil {
+		return types.NewBlockWithBlockHeader(header)
+	}
+	return c.IChainReader.GetBlockByHash(hash)
+}
+
 func (d *Downloader) fetchBlocks(from uint64) error {
 	log.Infof("Downloading blocks from #%d", from)
 	defer log.Infof("Block download terminated from #%d", from)
@@ -482,21 +775,21 @@ func (d *Downloader) fetchBlocks(from uint64) error {
 		case <-d.cancelCh:
 			return errCancelBlockFetch
 
-		case blockPack := <-d.blockCh:
+		case bodyPack := <-d.bodyCh:
 
-			if peer := d.peers.Peer(blockPack.peerID); peer != nil {
-				err := d.queue.Deliver(blockPack.peerID, blockPack.blocks)
+			if peer := d.peers.Peer(bodyPack.peerID); peer != nil {
+				err := d.queue.Deliver(bodyPack.peerID, bodyPack.transactions, bodyPack.actions)
 				switch err {
 				case nil:
-					if len(blockPack.blocks) == 0 {
+					if len(bodyPack.transactions) == 0 {
 						peer.Demote()
 						peer.SetIdle()
-						log.Infof("%s: no blocks delivered", peer.id)
+						log.Infof("%s: no bodies delivered", peer.id)
 						break
 					}
 					peer.Promote()
 					peer.SetIdle()
-					log.Infof("%s: delivered %d blocks", peer.id, len(blockPack.blocks))
+					log.Infof("%s: delivered %d bodies", peer.id, len(bodyPack.transactions))
 					go d.process()
 
 				case errInvalidChain:
@@ -523,6 +816,8 @@ func (d *Downloader) fetchBlocks(from uint64) error {
 			default:
 			}
 
+		case <-d.blockCh:
+
 		case cont := <-d.processCh:
 			if !cont {
 				finished = true
@@ -585,7 +880,11 @@ func (d *Downloader) banBlocks(peerID string, head utils.Hash) error {
 	if request == nil {
 		return nil
 	}
-	if err := peer.Fetch(request); err != nil {
+	hashes := make([]utils.Hash, 0, len(request.Hashes))
+	for hash := range request.Hashes {
+		hashes = append(hashes, hash)
+	}
+	if err := peer.getBlocks(hashes); err != nil {
 		return err
 	}
 	timeout := time.After(blockHardTTL)
@@ -678,22 +977,139 @@ func (d *Downloader) process() {
 				return
 			}
 			max := int(math.Min(float64(len(blocks)), float64(maxBlockProcess)))
+			// the blocks up to the pivot are imported without being executed
+			fast := 0
+			for fast < max && blocks[fast].RawBlock.Height().Uint64() <= d.pivot {
+				fast++
+			}
+			if fast > 0 {
+				max = fast
+			}
 			raw := make(types.Blocks, 0, max)
 			for _, block := range blocks[:max] {
 				raw = append(raw, block.RawBlock)
 			}
-			index, err := d.insertChain(raw)
+			var (
+				index int
+				err   error
+			)
+			if fast > 0 {
+				index, err = d.importFastBlocks(blocks[0].OriginPeer, raw)
+			} else {
+				index, err = d.insertChain(raw)
+			}
 			if err != nil {
-				d.dropPeer(blocks[index].OriginPeer)
+				d.dropPeer(blocks[index].OriginPeer, err)
 				log.Errorf("downloading canceled: insertChain %v %v %v", raw[0].Height(), raw[0].Hash().String(), err)
 				d.cancel()
 				return
 			}
+			// the imported headers are served by the local chain from now on
+			d.skeletonLock.Lock()
+			for _, block := range raw {
+				delete(d.skeleton, block.Hash())
+			}
+			d.skeletonLock.Unlock()
 			blocks = blocks[max:]
 		}
 	}
 }
 
+// importFastBlocks writes the blocks along with their receipts retrieved from the
+// given peer, and switches the chain head to the pivot block once its state has been
+// downloaded.
+func (d *Downloader) importFastBlocks(id string, blocks types.Blocks) (int, error) {
+	p := d.peers.Peer(id)
+	if p == nil {
+		return 0, errUnknownPeer
+	}
+	receipts, err := d.fetchReceipts(p, blocks)
+	if err != nil {
+		return 0, err
+	}
+	if index, err := d.insertFastBlocks(blocks, receipts); err != nil {
+		return index, err
+	}
+	last := blocks[len(blocks)-1]
+	if last.Height().Uint64() != d.pivot {
+		return len(blocks), nil
+	}
+	err = d.syncState(last.BlockHeader())
+	if err == nil {
+		err = d.commitHead(last.Hash())
+	}
+	select {
+	case d.pivotCh <- err:
+	default:
+	}
+	if err != nil {
+		return len(blocks) - 1, err
+	}
+	return len(blocks), nil
+}
+
+// insertFastBlocks writes the blocks with their receipts, the epoch trie nodes the
+// seal of a block is verified against are downloaded when missing.
+func (d *Downloader) insertFastBlocks(blocks types.Blocks, receipts []types.Receipts) (int, error) {
+	var (
+		done    int
+		missing utils.Hash
+	)
+	for {
+		index, err := d.insertReceipts(blocks[done:], receipts[done:])
+		done += index
+		node, ok := err.(*mtp.MissingNodeError)
+		if !ok || node.NodeHash == missing {
+			return done, err
+		}
+		missing = node.NodeHash
+		if err := d.syncTrie(missing); err != nil {
+			return done, err
+		}
+	}
+}
+
+// fetchReceipts retrieves the receipts of the given blocks from the peer and checks
+// them against the receipts root of the blocks.
+func (d *Downloader) fetchReceipts(p *peer, blocks types.Blocks) ([]types.Receipts, error) {
+	log.Infof("%v: fetching %d receipts from #%d", p.id, len(blocks), blocks[0].Height())
+
+	hashes := make([]utils.Hash, 0, len(blocks))
+	for _, block := range blocks {
+		hashes = append(hashes, block.Hash())
+	}
+	go p.getReceipts(hashes)
+	timeout := time.After(receiptTTL)
+
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCancelReceipts
+
+		case receiptPack := <-d.receiptCh:
+			if receiptPack.peerID != p.id {
+				log.Infof("Received receipts from incorrect peer(%s)", receiptPack.peerID)
+				break
+			}
+			if len(receiptPack.receipts) != len(blocks) {
+				log.Infof("%v: receipts of %d blocks delivered, want %d", p.id, len(receiptPack.receipts), len(blocks))
+				return nil, errInvalidReceipts
+			}
+			for i, block := range blocks {
+				if types.DeriveRootHash(receiptPack.receipts[i]) != block.ReceiptsRoot() {
+					log.Infof("%v: invalid receipts of block #%d", p.id, block.Height())
+					return nil, errInvalidReceipts
+				}
+			}
+			return receiptPack.receipts, nil
+
+		case <-timeout:
+			log.Infof("%v: receipt request timed out", p.id)
+			return nil, errTimeout
+		}
+	}
+}
+
 func (d *Downloader) DeliverBlocks(id string, blocks []*types.Block) error {
 	if atomic.LoadInt32(&d.synchronising) == 0 {
 		return errNoSyncActive
@@ -728,6 +1144,74 @@ func (d *Downloader) DeliverHashes(id string, hashes []utils.Hash) error {
 	}
 }
 
+func (d *Downloader) DeliverHeaders(id string, headers []*types.BlockHeader) error {
+	if atomic.LoadInt32(&d.synchronising) == 0 {
+		return errNoSyncActive
+	}
+	d.cancelLock.RLock()
+	cancel := d.cancelCh
+	d.cancelLock.RUnlock()
+
+	select {
+	case d.headerCh <- headerPack{id, headers}:
+		return nil
+
+	case <-cancel:
+		return errNoSyncActive
+	}
+}
+
+func (d *Downloader) DeliverBodies(id string, transactions [][]*types.Transaction, actions [][]*types.Action) error {
+	if atomic.LoadInt32(&d.synchronising) == 0 {
+		return errNoSyncActive
+	}
+	d.cancelLock.RLock()
+	cancel := d.cancelCh
+	d.cancelLock.RUnlock()
+
+	select {
+	case d.bodyCh <- bodyPack{id, transactions, actions}:
+		return nil
+
+	case <-cancel:
+		return errNoSyncActive
+	}
+}
+
+func (d *Downloader) DeliverReceipts(id string, receipts []types.Receipts) error {
+	if atomic.LoadInt32(&d.synchronising) == 0 {
+		return errNoSyncActive
+	}
+	d.cancelLock.RLock()
+	cancel := d.cancelCh
+	d.cancelLock.RUnlock()
+
+	select {
+	case d.receiptCh <- receiptPack{id, receipts}:
+		return nil
+
+	case <-cancel:
+		return errNoSyncActive
+	}
+}
+
+func (d *Downloader) DeliverNodeData(id string, data [][]byte) error {
+	if atomic.LoadInt32(&d.synchronising) == 0 {
+		return errNoSyncActive
+	}
+	d.cancelLock.RLock()
+	cancel := d.cancelCh
+	d.cancelLock.RUnlock()
+
+	select {
+	case d.stateCh <- statePack{id, data}:
+		return nil
+
+	case <-cancel:
+		return errNoSyncActive
+	}
+}
+
 type DoneEvent struct{}
 type StartEvent struct{}
 type FailedEvent struct{ Err error }
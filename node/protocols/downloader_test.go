@@ -0,0 +1,422 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package protocols
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mdb "github.com/UranusBlockStack/uranus/common/db/memorydb"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/stretchr/testify/assert"
+)
+
+// testChain is the chain served by a fake peer, every block has a receipt and shares
+// the state and the dpos tries of the genesis.
+type testChain struct {
+	stateDb  *mdb.Database
+	blocks   []*types.Block
+	receipts map[utils.Hash]types.Receipts
+	byHash   map[utils.Hash]*types.Block
+}
+
+func newTestChain(n int) *testChain {
+	stateDb := mdb.New()
+	genesis, _, err := ledger.DefaultGenesis().Commit(ledger.NewChain(stateDb))
+	if err != nil {
+		panic(err)
+	}
+	tc := &testChain{
+		stateDb:  stateDb,
+		blocks:   []*types.Block{genesis},
+		receipts: make(map[utils.Hash]types.Receipts),
+		byHash:   map[utils.Hash]*types.Block{genesis.Hash(): genesis},
+	}
+	for i := 1; i <= n; i++ {
+		parent := tc.blocks[i-1]
+		header := parent.BlockHeader()
+		header.PreviousHash = parent.Hash()
+		header.Height = big.NewInt(int64(i))
+		header.TimeStamp = new(big.Int).Add(parent.Time(), big.NewInt(1))
+		header.Difficulty = big.NewInt(1)
+		receipts := types.Receipts{types.NewReceipt(nil, false, uint64(i))}
+		block := types.NewBlock(header, nil, nil, receipts)
+
+		tc.blocks = append(tc.blocks, block)
+		tc.receipts[block.Hash()] = receipts
+		tc.byHash[block.Hash()] = block
+	}
+	return tc
+}
+
+func (tc *testChain) head() *types.Block {
+	return tc.blocks[len(tc.blocks)-1]
+}
+
+// testPeer serves a test chain to the downloader, the receipts may be replaced to
+// make the peer misbehave.
+type testPeer struct {
+	id       string
+	dl       *Downloader
+	chain    *testChain
+	receipts func(hash utils.Hash) types.Receipts
+}
+
+func (p *testPeer) register() error {
+	getRelHashes := func(utils.Hash) error { return nil }
+	getAbsHashes := func(from uint64, count int) error {
+		hashes := []utils.Hash{}
+		for i := from; i < from+uint64(count) && i < uint64(len(p.chain.blocks)); i++ {
+			hashes = append(hashes, p.chain.blocks[i].Hash())
+		}
+		return p.dl.DeliverHashes(p.id, hashes)
+	}
+	getBlocks := func(hashes []utils.Hash) error {
+		blocks := []*types.Block{}
+		for _, hash := range hashes {
+			if block, ok := p.chain.byHash[hash]; ok {
+				blocks = append(blocks, block)
+			}
+		}
+		return p.dl.DeliverBlocks(p.id, blocks)
+	}
+	getHeaders := func(from uint64, amount int, skip int, reverse bool) error {
+		headers := []*types.BlockHeader{}
+		for i := from; i < from+uint64(amount) && i < uint64(len(p.chain.blocks)); i++ {
+			headers = append(headers, p.chain.blocks[i].BlockHeader())
+		}
+		return p.dl.DeliverHeaders(p.id, headers)
+	}
+	getBodies := func(hashes []utils.Hash) error {
+		txs, actions := [][]*types.Transaction{}, [][]*types.Action{}
+		for _, hash := range hashes {
+			if block, ok := p.chain.byHash[hash]; ok {
+				txs = append(txs, block.Transactions())
+				actions = append(actions, block.Actions())
+			}
+		}
+		return p.dl.DeliverBodies(p.id, txs, actions)
+	}
+	getReceipts := func(hashes []utils.Hash) error {
+		receipts := []types.Receipts{}
+		for _, hash := range hashes {
+			receipts = append(receipts, p.receipts(hash))
+		}
+		return p.dl.DeliverReceipts(p.id, receipts)
+	}
+	getNodeData := func(hashes []utils.Hash) error {
+		data := [][]byte{}
+		for _, hash := range hashes {
+			if node, err := p.chain.stateDb.Get(hash.Bytes()); err == nil {
+				data = append(data, node)
+			}
+		}
+		return p.dl.DeliverNodeData(p.id, data)
+	}
+	p.receipts = func(hash utils.Hash) types.Receipts { return p.chain.receipts[hash] }
+	return p.dl.RegisterPeer(p.id, 0, p.chain.head().Hash(), getRelHashes, getAbsHashes, getBlocks, getHeaders, getBodies, getReceipts, getNodeData)
+}
+
+// testLocal is the local chain of the downloader. Like the dpos seal, a header is
+// verified against the epoch trie of the dpos context of its parent, and is forged
+// if mined by the forger.
+type testLocal struct {
+	stateDb *mdb.Database
+	blocks  map[utils.Hash]*types.Block
+	tds     map[utils.Hash]*big.Int
+	head    *types.Block
+	pivot   utils.Hash
+	dropped map[string]error
+	lock    sync.Mutex
+}
+
+var (
+	testForger    = utils.Address{0: 0xff}
+	errTestForged = consensus.NewInvalidError("forged seal")
+)
+
+func (local *testLocal) Config() *params.ChainConfig { return params.TestChainConfig }
+
+func (local *testLocal) CurrentBlock() *types.Block {
+	local.lock.Lock()
+	defer local.lock.Unlock()
+	return local.head
+}
+
+func (local *testLocal) GetHeader(hash utils.Hash) *types.BlockHeader {
+	if block := local.GetBlockByHash(hash); block != nil {
+		return block.BlockHeader()
+	}
+	return nil
+}
+
+func (local *testLocal) GetBlockByHeight(height uint64) *types.Block {
+	local.lock.Lock()
+	defer local.lock.Unlock()
+	for _, block := range local.blocks {
+		if block.Height().Uint64() == height {
+			return block
+		}
+	}
+	return nil
+}
+
+func (local *testLocal) GetBlockByHash(hash utils.Hash) *types.Block {
+	local.lock.Lock()
+	defer local.lock.Unlock()
+	return local.blocks[hash]
+}
+
+func (local *testLocal) verifyHeader(chain consensus.IChainReader, header *types.BlockHeader) error {
+	parent := chain.GetHeader(header.PreviousHash)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if _, err := types.NewEpochTrie(parent.DposContext.EpochHash, mtp.NewDatabase(local.stateDb)); err != nil {
+		return err
+	}
+	if header.Miner == testForger {
+		return errTestForged
+	}
+	return nil
+}
+
+func newTestDownloader(genesis *types.Block) (*Downloader, *testLocal) {
+	local := &testLocal{
+		stateDb: mdb.New(),
+		blocks:  map[utils.Hash]*types.Block{genesis.Hash(): genesis},
+		tds:     map[utils.Hash]*big.Int{genesis.Hash(): genesis.Difficulty()},
+		head:    genesis,
+		dropped: make(map[string]error),
+	}
+	write := func(block *types.Block) error {
+		ptd, ok := local.tds[block.PreviousHash()]
+		if !ok {
+			return fmt.Errorf("unknown parent of block #%d", block.Height())
+		}
+		local.blocks[block.Hash()] = block
+		local.tds[block.Hash()] = new(big.Int).Add(ptd, block.Difficulty())
+		return nil
+	}
+	hasBlock := func(hash utils.Hash) bool {
+		return local.GetBlockByHash(hash) != nil
+	}
+	getTd := func(hash utils.Hash) *big.Int {
+		local.lock.Lock()
+		defer local.lock.Unlock()
+		return local.tds[hash]
+	}
+	insertChain := func(blocks types.Blocks) (int, error) {
+		local.lock.Lock()
+		defer local.lock.Unlock()
+		for i, block := range blocks {
+			if err := write(block); err != nil {
+				return i, err
+			}
+			local.head = block
+		}
+		return len(blocks), nil
+	}
+	insertReceipts := func(blocks types.Blocks, receipts []types.Receipts) (int, error) {
+		local.lock.Lock()
+		defer local.lock.Unlock()
+		for i, block := range blocks {
+			if types.DeriveRootHash(receipts[i]) != block.ReceiptsRoot() {
+				return i, errInvalidReceipts
+			}
+			if err := write(block); err != nil {
+				return i, err
+			}
+		}
+		return len(blocks), nil
+	}
+	commitHead := func(hash utils.Hash) error {
+		local.lock.Lock()
+		defer local.lock.Unlock()
+		block := local.blocks[hash]
+		if _, err := state.New(block.StateRoot(), state.NewDatabase(local.stateDb)); err != nil {
+			return err
+		}
+		if _, err := types.NewDposContextFromProto(mtp.NewDatabase(local.stateDb), block.BlockHeader().DposContext); err != nil {
+			return err
+		}
+		local.head, local.pivot = block, hash
+		return nil
+	}
+	dropPeer := func(id string, err error) {
+		local.lock.Lock()
+		defer local.lock.Unlock()
+		local.dropped[id] = err
+	}
+	dl := NewDownloader(new(feed.TypeMux), local.stateDb, hasBlock, local.GetBlockByHash, local.CurrentBlock, getTd, local, local.verifyHeader, insertChain, insertReceipts, commitHead, dropPeer)
+	return dl, local
+}
+
+// waitHead waits for the downloader to import the given block.
+func (local *testLocal) waitHead(hash utils.Hash) bool {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		local.lock.Lock()
+		head := local.head.Hash()
+		local.lock.Unlock()
+		if head == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFastSync(t *testing.T) {
+	chain := newTestChain(fsMinFullBlocks + 36)
+	dl, local := newTestDownloader(chain.blocks[0])
+	defer dl.Terminate()
+	p := &testPeer{id: "peer", dl: dl, chain: chain}
+	assert.NoError(t, p.register())
+
+	head := chain.head()
+	assert.NoError(t, dl.Synchronise(p.id, head.Hash(), big.NewInt(int64(len(chain.blocks))), FastSync))
+	assert.True(t, local.waitHead(head.Hash()))
+
+	local.lock.Lock()
+	defer local.lock.Unlock()
+	assert.Equal(t, chain.blocks[36].Hash(), local.pivot)
+	for _, block := range chain.blocks {
+		assert.Contains(t, local.blocks, block.Hash())
+	}
+	assert.Empty(t, local.dropped)
+}
+
+func TestFastSyncBadReceipts(t *testing.T) {
+	chain := newTestChain(fsMinFullBlocks + 36)
+	dl, local := newTestDownloader(chain.blocks[0])
+	defer dl.Terminate()
+	p := &testPeer{id: "peer", dl: dl, chain: chain}
+	assert.NoError(t, p.register())
+	p.receipts = func(hash utils.Hash) types.Receipts {
+		return types.Receipts{types.NewReceipt(nil, true, 0)}
+	}
+
+	head := chain.head()
+	assert.Error(t, dl.Synchronise(p.id, head.Hash(), big.NewInt(int64(len(chain.blocks))), FastSync))
+
+	local.lock.Lock()
+	defer local.lock.Unlock()
+	assert.Equal(t, errInvalidReceipts, local.dropped[p.id])
+	assert.Len(t, local.blocks, 1)
+	assert.Equal(t, utils.Hash{}, local.pivot)
+}
+
+func TestSyncForgedHeader(t *testing.T) {
+	chain := newTestChain(10)
+	forged := chain.blocks[5].BlockHeader()
+	forged.Miner = testForger
+	chain.blocks[5] = types.NewBlock(forged, nil, nil, chain.receipts[chain.blocks[5].Hash()])
+
+	dl, local := newTestDownloader(chain.blocks[0])
+	defer dl.Terminate()
+	p := &testPeer{id: "peer", dl: dl, chain: chain}
+	assert.NoError(t, p.register())
+
+	head := chain.head()
+	assert.Equal(t, errInvalidHeader, dl.Synchronise(p.id, head.Hash(), big.NewInt(int64(len(chain.blocks))), FullSync))
+
+	local.lock.Lock()
+	defer local.lock.Unlock()
+	assert.Equal(t, errInvalidHeader, local.dropped[p.id])
+	assert.Len(t, local.blocks, 1)
+}
+
+func TestVerifyHeaders(t *testing.T) {
+	chain := newTestChain(6)
+	dl, local := newTestDownloader(chain.blocks[0])
+	headers := make([]*types.BlockHeader, len(chain.blocks))
+	for i, block := range chain.blocks {
+		headers[i] = block.BlockHeader()
+	}
+
+	// the headers must be contiguous and link to their predecessor
+	assert.Error(t, dl.verifyHeaders(headers[2:4], 1, nil))
+	assert.Error(t, dl.verifyHeaders([]*types.BlockHeader{headers[1], headers[3]}, 1, nil))
+	// the first header links to the local chain or to the last scheduled header
+	assert.Error(t, dl.verifyHeaders(headers[2:4], 2, nil))
+	assert.Error(t, dl.verifyHeaders(headers[3:5], 3, headers[1]))
+
+	// the seal is verified once the epoch trie of the parent is downloaded
+	p := &testPeer{id: "peer", dl: dl, chain: chain}
+	assert.NoError(t, p.register())
+	atomic.StoreInt32(&dl.synchronising, 1)
+	dl.cancelCh = make(chan struct{})
+	assert.NoError(t, dl.verifyHeaders(headers[1:4], 1, nil))
+
+	// the ancestors not imported yet are served by the skeleton
+	skeleton := &skeletonChain{local, dl}
+	assert.Equal(t, headers[3].Hash(), skeleton.GetHeader(headers[3].Hash()).Hash())
+	assert.Equal(t, headers[0].Hash(), skeleton.GetBlockByHash(headers[0].Hash()).Hash())
+	assert.Nil(t, local.GetHeader(headers[3].Hash()))
+
+	forged := types.CopyBlockHeader(headers[5])
+	forged.Miner = testForger
+	assert.NoError(t, dl.verifyHeaders(headers[4:5], 4, headers[3]))
+	assert.Error(t, dl.verifyHeaders([]*types.BlockHeader{forged}, 5, headers[4]))
+	assert.Nil(t, skeleton.GetHeader(forged.Hash()))
+}
+
+func TestQueueDeliver(t *testing.T) {
+	sender := utils.Address{1}
+	tx := types.NewTransaction(types.Binary, 0, big.NewInt(1), 21000, big.NewInt(1), nil, &sender)
+	action := types.NewAction(tx.Hash(), sender, big.NewInt(1), big.NewInt(2))
+	parent := &types.BlockHeader{Height: big.NewInt(0), TimeStamp: big.NewInt(0), Difficulty: big.NewInt(1)}
+	withTx := types.NewBlock(&types.BlockHeader{PreviousHash: parent.Hash(), Height: big.NewInt(1), TimeStamp: big.NewInt(1), Difficulty: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	withAction := types.NewBlock(&types.BlockHeader{PreviousHash: withTx.Hash(), Height: big.NewInt(2), TimeStamp: big.NewInt(2), Difficulty: big.NewInt(1)}, []*types.Transaction{tx}, []*types.Action{action}, nil)
+
+	q := newQueue()
+	q.Prepare(1)
+	q.Insert([]*types.BlockHeader{withTx.BlockHeader(), withAction.BlockHeader()}, true)
+	p := newPeer("peer", utils.Hash{}, nil, nil, nil, nil, nil, nil, nil)
+
+	// the bodies share the transactions, the actions tell them apart
+	assert.Len(t, q.Reserve(p, 2).Hashes, 2)
+	assert.NoError(t, q.Deliver(p.id, [][]*types.Transaction{{tx}, {tx}}, [][]*types.Action{{action}, nil}))
+	blocks := q.TakeBlocks()
+	assert.Len(t, blocks, 2)
+	assert.Equal(t, withTx.Hash(), blocks[0].RawBlock.Hash())
+	assert.Empty(t, blocks[0].RawBlock.Actions())
+	assert.Equal(t, withAction.Hash(), blocks[1].RawBlock.Hash())
+	assert.Equal(t, types.Actions{action}, types.Actions(blocks[1].RawBlock.Actions()))
+
+	// a body whose actions do not match the header is rejected and rescheduled
+	q = newQueue()
+	q.Prepare(1)
+	q.Insert([]*types.BlockHeader{withAction.BlockHeader()}, true)
+	assert.NotNil(t, q.Reserve(p, 1))
+	assert.Equal(t, errStaleDelivery, q.Deliver(p.id, [][]*types.Transaction{{tx}}, nil))
+	assert.Empty(t, q.TakeBlocks())
+	assert.Equal(t, 1, q.Pending())
+
+	// the deliveries need a request
+	assert.Equal(t, errNoFetchesPending, q.Deliver(p.id, [][]*types.Transaction{{tx}}, [][]*types.Action{{action}}))
+}
@@ -30,6 +30,10 @@ import (
 type relativeHashFetcherFn func(utils.Hash) error
 type absoluteHashFetcherFn func(uint64, int) error
 type blockFetcherFn func([]utils.Hash) error
+type headerFetcherFn func(uint64, int, int, bool) error
+type bodyFetcherFn func([]utils.Hash) error
+type receiptFetcherFn func([]utils.Hash) error
+type stateFetcherFn func([]utils.Hash) error
 
 type peer struct {
 	id           string
@@ -42,9 +46,13 @@ type peer struct {
 	getRelHashes relativeHashFetcherFn
 	getAbsHashes absoluteHashFetcherFn
 	getBlocks    blockFetcherFn
+	getHeaders   headerFetcherFn
+	getBodies    bodyFetcherFn
+	getReceipts  receiptFetcherFn
+	getNodeData  stateFetcherFn
 }
 
-func newPeer(id string, head utils.Hash, getRelHashes relativeHashFetcherFn, getAbsHashes absoluteHashFetcherFn, getBlocks blockFetcherFn) *peer {
+func newPeer(id string, head utils.Hash, getRelHashes relativeHashFetcherFn, getAbsHashes absoluteHashFetcherFn, getBlocks blockFetcherFn, getHeaders headerFetcherFn, getBodies bodyFetcherFn, getReceipts receiptFetcherFn, getNodeData stateFetcherFn) *peer {
 	return &peer{
 		id:           id,
 		head:         head,
@@ -52,6 +60,10 @@ func newPeer(id string, head utils.Hash, getRelHashes relativeHashFetcherFn, get
 		getRelHashes: getRelHashes,
 		getAbsHashes: getAbsHashes,
 		getBlocks:    getBlocks,
+		getHeaders:   getHeaders,
+		getBodies:    getBodies,
+		getReceipts:  getReceipts,
+		getNodeData:  getNodeData,
 		ignored:      set.New(set.ThreadSafe),
 	}
 }
@@ -72,7 +84,7 @@ func (p *peer) Fetch(request *fetchRequest) error {
 	for hash := range request.Hashes {
 		hashes = append(hashes, hash)
 	}
-	go p.getBlocks(hashes)
+	go p.getBodies(hashes)
 
 	return nil
 }
@@ -41,6 +41,7 @@ type queue struct {
 	hashPool    map[utils.Hash]int
 	hashQueue   *prque.Prque
 	hashCounter int
+	headerPool  map[utils.Hash]*types.BlockHeader // Verified headers waiting for their bodies
 
 	pendPool map[string]*fetchRequest
 
@@ -54,6 +55,7 @@ func newQueue() *queue {
 	return &queue{
 		hashPool:   make(map[utils.Hash]int),
 		hashQueue:  prque.New(),
+		headerPool: make(map[utils.Hash]*types.BlockHeader),
 		pendPool:   make(map[string]*fetchRequest),
 		blockPool:  make(map[utils.Hash]uint64),
 		blockCache: make([]*Block, blockCacheLimit),
@@ -67,6 +69,7 @@ func (q *queue) Reset() {
 	q.hashPool = make(map[utils.Hash]int)
 	q.hashQueue.Reset()
 	q.hashCounter = 0
+	q.headerPool = make(map[utils.Hash]*types.BlockHeader)
 
 	q.pendPool = make(map[string]*fetchRequest)
 
@@ -120,21 +123,24 @@ func (q *queue) Has(hash utils.Hash) bool {
 	return false
 }
 
-func (q *queue) Insert(hashes []utils.Hash, fifo bool) []utils.Hash {
+// Insert schedules the retrieval of the bodies of the given verified headers.
+func (q *queue) Insert(headers []*types.BlockHeader, fifo bool) []*types.BlockHeader {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	inserts := make([]utils.Hash, 0, len(hashes))
-	for _, hash := range hashes {
+	inserts := make([]*types.BlockHeader, 0, len(headers))
+	for _, header := range headers {
+		hash := header.Hash()
 		if old, ok := q.hashPool[hash]; ok {
 			_ = old
 			log.Warnf("Hash %x already scheduled at index %v", hash, old)
 			continue
 		}
 		q.hashCounter = q.hashCounter + 1
-		inserts = append(inserts, hash)
+		inserts = append(inserts, header)
 
 		q.hashPool[hash] = q.hashCounter
+		q.headerPool[hash] = header
 		if fifo {
 			q.hashQueue.Push(hash, -float32(q.hashCounter)) // Lowest gets schedules first
 		} else {
@@ -260,7 +266,10 @@ func (q *queue) Expire(timeout time.Duration) []string {
 	return peers
 }
 
-func (q *queue) Deliver(id string, blocks []*types.Block) (err error) {
+// Deliver assembles the blocks of the requested headers from the delivered bodies, a
+// body is matched to its header by both the transactions and the actions roots, the
+// bodies matching no requested header are rejected.
+func (q *queue) Deliver(id string, txLists [][]*types.Transaction, actionLists [][]*types.Action) (err error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -270,18 +279,34 @@ func (q *queue) Deliver(id string, blocks []*types.Block) (err error) {
 	}
 	delete(q.pendPool, id)
 
-	if len(blocks) == 0 {
+	if len(txLists) == 0 {
 		for hash := range request.Hashes {
 			request.Peer.ignored.Add(hash)
 		}
 	}
 	errs := make([]error, 0)
-	for _, block := range blocks {
-		hash := block.Hash()
-		if _, ok := request.Hashes[hash]; !ok {
-			errs = append(errs, fmt.Errorf("non-requested block %x", hash))
+	for i, txs := range txLists {
+		var actions []*types.Action
+		if i < len(actionLists) {
+			actions = actionLists[i]
+		}
+		var (
+			hash       utils.Hash
+			header     *types.BlockHeader
+			txRoot     = types.DeriveRootHash(types.Transactions(txs))
+			actionRoot = types.DeriveRootHash(types.Actions(actions))
+		)
+		for requested := range request.Hashes {
+			if h := q.headerPool[requested]; h.TransactionsRoot == txRoot && h.ActionsRoot == actionRoot {
+				hash, header = requested, h
+				break
+			}
+		}
+		if header == nil {
+			errs = append(errs, fmt.Errorf("non-requested body with transactions root %x, actions root %x", txRoot, actionRoot))
 			continue
 		}
+		block := types.NewBlockWithBlockHeader(header).WithTxs(txs).WithActions(actions)
 		index := int(int64(block.Height().Uint64()) - int64(q.blockOffset))
 		if index >= len(q.blockCache) || index < 0 {
 			return errInvalidChain
@@ -292,13 +317,14 @@ func (q *queue) Deliver(id string, blocks []*types.Block) (err error) {
 		}
 		delete(request.Hashes, hash)
 		delete(q.hashPool, hash)
+		delete(q.headerPool, hash)
 		q.blockPool[hash] = block.Height().Uint64()
 	}
 	for hash, index := range request.Hashes {
 		q.hashQueue.Push(hash, float32(index))
 	}
 	if len(errs) != 0 {
-		if len(errs) == len(blocks) {
+		if len(errs) == len(txLists) {
 			return errStaleDelivery
 		}
 		return fmt.Errorf("multiple failures: %v", errs)
@@ -0,0 +1,150 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package protocols
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+// syncState downloads the state trie and the five dpos tries of the pivot block from
+// the peers, the retrieved nodes are written straight to the state database.
+func (d *Downloader) syncState(header *types.BlockHeader) error {
+	log.Infof("Downloading state of pivot #%d, root %x", header.Height, header.StateRoot)
+
+	sched := state.NewStateSync(header.StateRoot, d.stateDb)
+	if header.DposContext != nil {
+		for _, root := range header.DposContext.Roots() {
+			sched.AddSubTrie(root, 0, utils.Hash{}, nil)
+		}
+	}
+	nodes, err := d.syncTries(sched)
+	if err != nil {
+		return err
+	}
+	log.Infof("State of pivot #%d downloaded, %d nodes", header.Height, nodes)
+	return nil
+}
+
+// syncTrie downloads the trie below the given node, like the epoch trie electing the
+// validators of the blocks imported without their state.
+func (d *Downloader) syncTrie(root utils.Hash) error {
+	nodes, err := d.syncTries(mtp.NewSync(root, d.stateDb, nil))
+	if err != nil {
+		return err
+	}
+	log.Debugf("Trie %x downloaded, %d nodes", root, nodes)
+	return nil
+}
+
+// syncTries retrieves the nodes missing from the scheduler from random peers until
+// it is complete, and returns the number of nodes downloaded.
+func (d *Downloader) syncTries(sched *mtp.Sync) (int, error) {
+	d.stateLock.Lock()
+	defer d.stateLock.Unlock()
+
+	var (
+		retry  []utils.Hash // hashes requested but not delivered
+		stalls int
+		nodes  int
+	)
+	for sched.Pending() > 0 {
+		peers := d.peers.AllPeers()
+		if len(peers) == 0 {
+			return nodes, errNoPeers
+		}
+		p := peers[rand.Intn(len(peers))]
+
+		hashes := retry
+		if len(hashes) < MaxStateFetch {
+			hashes = append(hashes, sched.Missing(MaxStateFetch-len(hashes))...)
+		}
+		if len(hashes) == 0 {
+			return nodes, errStateStalled
+		}
+		requested := make(map[utils.Hash]struct{}, len(hashes))
+		for _, hash := range hashes {
+			requested[hash] = struct{}{}
+		}
+		go p.getNodeData(hashes)
+		timeout := time.After(stateTTL)
+
+	wait:
+		for {
+			select {
+			case <-d.cancelCh:
+				return nodes, errCancelStateFetch
+
+			case statePack := <-d.stateCh:
+				if statePack.peerID != p.id {
+					log.Infof("Received node data from incorrect peer(%s)", statePack.peerID)
+					break
+				}
+				results := make([]mtp.SyncResult, 0, len(statePack.states))
+				for _, data := range statePack.states {
+					hash := crypto.Keccak256Hash(data)
+					if _, ok := requested[hash]; ok {
+						results = append(results, mtp.SyncResult{Hash: hash, Data: data})
+						delete(requested, hash)
+					}
+				}
+				if _, index, err := sched.Process(results); err != nil {
+					return nodes, fmt.Errorf("state node %x: %v", results[index].Hash, err)
+				}
+				batch := d.stateDb.NewBatch()
+				if _, err := sched.Commit(batch); err != nil {
+					return nodes, err
+				}
+				if err := batch.Write(); err != nil {
+					return nodes, err
+				}
+				nodes += len(results)
+				if len(results) == 0 {
+					p.Demote()
+					stalls++
+				} else {
+					p.Promote()
+					stalls = 0
+				}
+				log.Debugf("%v: delivered %d state nodes, %d downloaded, %d pending", p.id, len(results), nodes, sched.Pending())
+				break wait
+
+			case <-timeout:
+				log.Infof("%v: node data request timed out", p.id)
+				p.Demote()
+				stalls++
+				break wait
+			}
+		}
+		if stalls >= fsMaxStateStall {
+			return nodes, errStateStalled
+		}
+		retry = make([]utils.Hash, 0, len(requested))
+		for hash := range requested {
+			retry = append(retry, hash)
+		}
+	}
+	return nodes, nil
+}
@@ -31,13 +31,17 @@ import (
 	"io"
 	mrand "math/rand"
 	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/UranusBlockStack/uranus/common/crypto"
 	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/metrics"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
 	"github.com/UranusBlockStack/uranus/p2p/ecies"
+	"github.com/golang/snappy"
 	"golang.org/x/crypto/sha3"
 )
 
@@ -54,6 +58,18 @@ const (
 	encAuthRespLen   = authRespLen + eciesOverhead
 	handshakeTimeout = 5 * time.Second
 	discWriteTimeout = 1 * time.Second
+
+	// baseProtocolVersion is the version of the protocol handshake, the
+	// message payloads are snappy compressed from snappyProtocolVersion on.
+	baseProtocolVersion   = 1
+	snappyProtocolVersion = 1
+)
+
+var errPlainMessageTooLarge = errors.New("message length >= 16MB")
+
+var (
+	inboundBytes  = metrics.NewCounterVec("uranus_p2p_inbound_bytes_total", "Bytes of the frames read from the peers by message code.", "code")
+	outboundBytes = metrics.NewCounterVec("uranus_p2p_outbound_bytes_total", "Bytes of the frames written to the peers by message code.", "code")
 )
 
 type conn struct {
@@ -64,6 +80,19 @@ type conn struct {
 	cont      chan error
 	rmu, wmu  sync.Mutex
 	rw        *connFrameRW
+	trusted   int32
+}
+
+func (c *conn) isTrusted() bool {
+	return atomic.LoadInt32(&c.trusted) == 1
+}
+
+func (c *conn) setTrusted(trusted bool) {
+	var v int32
+	if trusted {
+		v = 1
+	}
+	atomic.StoreInt32(&c.trusted, v)
 }
 
 func (c *conn) ReadMsg() (*Message, error) {
@@ -509,6 +538,10 @@ type connFrameRW struct {
 	macCipher  cipher.Block
 	egressMAC  hash.Hash
 	ingressMAC hash.Hash
+
+	snappy bool // compress the payloads, enabled once both sides support it
+
+	protocols []*Protocol // running protocols, set once the handshake is done
 }
 
 func newRLPXFrameRW(conn io.ReadWriter, s secrets) *connFrameRW {
@@ -535,8 +568,16 @@ func newRLPXFrameRW(conn io.ReadWriter, s secrets) *connFrameRW {
 func (rw *connFrameRW) WriteMsg(msg *Message) error {
 	ptype, _ := rlp.EncodeToBytes(msg.Code)
 
+	data := msg.Payload
+	if rw.snappy {
+		if uint32(len(data)) > maxUint24 {
+			return errPlainMessageTooLarge
+		}
+		data = snappy.Encode(nil, data)
+	}
+
 	headbuf := make([]byte, 32)
-	fsize := uint32(len(ptype)) + uint32(len(msg.Payload))
+	fsize := uint32(len(ptype)) + uint32(len(data))
 	if fsize > maxUint24 {
 		return fmt.Errorf("message size overflows uint24 %v > %v", fsize, maxUint24)
 	}
@@ -553,7 +594,7 @@ func (rw *connFrameRW) WriteMsg(msg *Message) error {
 	if _, err := tee.Write(ptype); err != nil {
 		return err
 	}
-	payload := bytes.NewReader(msg.Payload)
+	payload := bytes.NewReader(data)
 	if _, err := io.Copy(tee, payload); err != nil {
 		return err
 	}
@@ -566,8 +607,11 @@ func (rw *connFrameRW) WriteMsg(msg *Message) error {
 
 	fmacseed := rw.egressMAC.Sum(nil)
 	mac := updateMAC(rw.egressMAC, rw.macCipher, fmacseed)
-	_, err := rw.conn.Write(mac)
-	return err
+	if _, err := rw.conn.Write(mac); err != nil {
+		return err
+	}
+	outboundBytes.With(strconv.FormatUint(msg.Code, 10)).Add(float64(fsize))
+	return nil
 }
 
 func (rw *connFrameRW) ReadMsg() (msg *Message, err error) {
@@ -610,7 +654,21 @@ func (rw *connFrameRW) ReadMsg() (msg *Message, err error) {
 	}
 	msg.Payload = make([]byte, content.Len())
 	io.ReadFull(content, msg.Payload)
+	inboundBytes.With(rw.codeLabel(msg.Code)).Add(float64(fsize))
 
+	if rw.snappy {
+		// check the decompressed size before inflating the payload
+		size, err := snappy.DecodedLen(msg.Payload)
+		if err != nil {
+			return msg, err
+		}
+		if size > int(maxUint24) {
+			return msg, errPlainMessageTooLarge
+		}
+		if msg.Payload, err = snappy.Decode(nil, msg.Payload); err != nil {
+			return msg, err
+		}
+	}
 	return msg, nil
 }
 
@@ -638,3 +696,18 @@ var (
 	zeroHeader = []byte{0xC2, 0x80, 0x80}
 	zero16     = make([]byte, 16)
 )
+
+// codeLabel returns the metric label of a message code read from the peer, the
+// codes out of the base and the running protocols are counted as other so that
+// the peer can't grow the label set.
+func (rw *connFrameRW) codeLabel(code uint64) string {
+	if code <= quitMsg {
+		return strconv.FormatUint(code, 10)
+	}
+	for _, proto := range rw.protocols {
+		if code >= proto.Offset && code < proto.Offset+proto.Size {
+			return strconv.FormatUint(code, 10)
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/sha3"
+)
+
+// newTestFrameRWs returns the two ends of a framed connection.
+func newTestFrameRWs(snappyA, snappyB bool) (*connFrameRW, *connFrameRW, func()) {
+	fd1, fd2 := net.Pipe()
+	key := bytes.Repeat([]byte{0x01}, 16)
+	mac := bytes.Repeat([]byte{0x02}, 16)
+	egress, ingress := sha3.NewLegacyKeccak256(), sha3.NewLegacyKeccak256()
+	egress.Write([]byte("a"))
+	ingress.Write([]byte("b"))
+	egress2, ingress2 := sha3.NewLegacyKeccak256(), sha3.NewLegacyKeccak256()
+	egress2.Write([]byte("b"))
+	ingress2.Write([]byte("a"))
+
+	a := newRLPXFrameRW(fd1, secrets{AES: key, MAC: mac, EgressMAC: egress, IngressMAC: ingress})
+	b := newRLPXFrameRW(fd2, secrets{AES: key, MAC: mac, EgressMAC: egress2, IngressMAC: ingress2})
+	a.snappy, b.snappy = snappyA, snappyB
+	return a, b, func() { fd1.Close(); fd2.Close() }
+}
+
+func TestFrameRWSnappy(t *testing.T) {
+	a, b, closeFn := newTestFrameRWs(true, true)
+	defer closeFn()
+
+	payload := bytes.Repeat([]byte("uranus"), 1000)
+	go a.WriteMsg(&Message{Code: 8, Payload: payload})
+	msg, err := b.ReadMsg()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), msg.Code)
+	assert.Equal(t, payload, msg.Payload)
+}
+
+func TestFrameRWPlain(t *testing.T) {
+	// compression is only enabled when both sides support it, an old peer
+	// reads the payload as it was written
+	a, b, closeFn := newTestFrameRWs(false, false)
+	defer closeFn()
+
+	payload := []byte{0xc3, 0x01, 0x02, 0x03}
+	go a.WriteMsg(&Message{Code: 3, Payload: payload})
+	msg, err := b.ReadMsg()
+	assert.NoError(t, err)
+	assert.Equal(t, payload, msg.Payload)
+}
+
+func TestFrameRWSnappyBomb(t *testing.T) {
+	a, b, closeFn := newTestFrameRWs(false, true)
+	defer closeFn()
+
+	// a snappy block claiming a decompressed size above the limit
+	bomb := snappy.Encode(nil, make([]byte, maxUint24+1))
+	go a.WriteMsg(&Message{Code: 1, Payload: bomb})
+	_, err := b.ReadMsg()
+	assert.Equal(t, errPlainMessageTooLarge, err)
+}
+
+func TestFrameRWCodeLabel(t *testing.T) {
+	rw := &connFrameRW{protocols: []*Protocol{{Name: "test", Offset: 1000, Size: 10}}}
+
+	assert.Equal(t, "0", rw.codeLabel(handshakeMsg))
+	assert.Equal(t, "3", rw.codeLabel(quitMsg))
+	assert.Equal(t, "1000", rw.codeLabel(1000))
+	assert.Equal(t, "1009", rw.codeLabel(1009))
+	assert.Equal(t, "other", rw.codeLabel(4))
+	assert.Equal(t, "other", rw.codeLabel(1010))
+	assert.Equal(t, "other", rw.codeLabel(1<<64-1))
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/p2p/netutil"
 )
 
 // Dialer is used to connect to node in the network
@@ -63,6 +64,7 @@ type DialerManager struct {
 	dialerLimit int                                // max dialers
 	ntab        *discover.Table                    // discover nodes
 	bootnodes   map[discover.NodeID]*discover.Node // nodes default or static
+	netrestrict *netutil.Netlist                   // network whitelist
 
 	dialing map[discover.NodeID]*discover.Node
 	lookup  map[discover.NodeID]*discover.Node
@@ -71,10 +73,11 @@ type DialerManager struct {
 }
 
 // NewDialerManager
-func NewDialerManager(maxdialers int, bootnodes []*discover.Node, ntab *discover.Table) *DialerManager {
+func NewDialerManager(maxdialers int, bootnodes []*discover.Node, ntab *discover.Table, netrestrict *netutil.Netlist) *DialerManager {
 	dm := &DialerManager{
 		dialerLimit: maxdialers,
 		ntab:        ntab,
+		netrestrict: netrestrict,
 		bootnodes:   make(map[discover.NodeID]*discover.Node),
 		dialing:     make(map[discover.NodeID]*discover.Node),
 		lookup:      make(map[discover.NodeID]*discover.Node),
@@ -95,6 +98,10 @@ func (dm *DialerManager) canDial(n *discover.Node, peers map[discover.NodeID]*Pe
 		return errors.New("connected")
 	case dm.ntab != nil && n.ID == dm.ntab.Self().ID:
 		return errors.New("self")
+	case dm.ntab != nil && dm.ntab.BannedUntil(n.ID).After(time.Now()):
+		return errors.New("banned")
+	case dm.netrestrict != nil && !n.Incomplete() && !dm.netrestrict.Contains(n.IP):
+		return errors.New("not contained in netrestrict whitelist")
 	}
 	return nil
 }
@@ -208,6 +215,9 @@ func (t *DialerTask) Do(srv *Server) error {
 		t.dest = resolved
 		log.Debugf("Resolved node %s, addr %#v", t.dest.ID, &net.TCPAddr{IP: t.dest.IP, Port: int(t.dest.TCP)})
 	}
+	if srv.NetRestrict != nil && !srv.NetRestrict.Contains(t.dest.IP) {
+		return fmt.Errorf("node %s ip %v is not whitelisted", t.dest.ID, t.dest.IP)
+	}
 
 	fd, err := srv.dialer.Dial(t.dest)
 	if err != nil {
@@ -61,6 +61,10 @@ var (
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
 	nodeDBDiscoverPong      = nodeDBDiscoverRoot + ":lastpong"
 	nodeDBDiscoverFindFails = nodeDBDiscoverRoot + ":findfail"
+
+	nodeDBReputationRoot  = ":reputation"
+	nodeDBReputationScore = nodeDBReputationRoot + ":score"
+	nodeDBReputationBan   = nodeDBReputationRoot + ":ban"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -261,6 +265,10 @@ func (db *nodeDB) expireNodes() error {
 				continue
 			}
 		}
+		// Keep the node as long as it is banned
+		if db.bannedUntil(id).After(time.Now()) {
+			continue
+		}
 		// Otherwise delete all associated information
 		db.deleteNode(id)
 	}
@@ -303,6 +311,48 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// reputation retrieves the reputation score of a remote node.
+func (db *nodeDB) reputation(id NodeID) int {
+	return int(db.fetchInt64(makeKey(id, nodeDBReputationScore)))
+}
+
+// updateReputation updates the reputation score of a remote node.
+func (db *nodeDB) updateReputation(id NodeID, score int) error {
+	return db.storeInt64(makeKey(id, nodeDBReputationScore), int64(score))
+}
+
+// bannedUntil retrieves the time until a remote node is banned.
+func (db *nodeDB) bannedUntil(id NodeID) time.Time {
+	return time.Unix(db.fetchInt64(makeKey(id, nodeDBReputationBan)), 0)
+}
+
+// updateBannedUntil updates the time until a remote node is banned, a zero
+// time lifts the ban.
+func (db *nodeDB) updateBannedUntil(id NodeID, until time.Time) error {
+	if until.IsZero() {
+		return db.lvl.Delete(makeKey(id, nodeDBReputationBan), nil)
+	}
+	return db.storeInt64(makeKey(id, nodeDBReputationBan), until.Unix())
+}
+
+// bans retrieves the nodes banned after the given time.
+func (db *nodeDB) bans(now time.Time) map[NodeID]time.Time {
+	bans := make(map[NodeID]time.Time)
+	it := db.lvl.NewIterator(util.BytesPrefix(nodeDBItemPrefix), nil)
+	defer it.Release()
+
+	for it.Next() {
+		id, field := splitKey(it.Key())
+		if field != nodeDBReputationBan {
+			continue
+		}
+		if until := db.bannedUntil(id); until.After(now) {
+			bans[id] = until
+		}
+	}
+	return bans
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
@@ -215,6 +215,45 @@ var nodeDBSeedQueryNodes = []struct {
 	},
 }
 
+func TestNodeDBReputation(t *testing.T) {
+	id := MustHexID("0x1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
+
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	if score := db.reputation(id); score != 0 {
+		t.Errorf("reputation: non-existing object: %v", score)
+	}
+	if err := db.updateReputation(id, -42); err != nil {
+		t.Errorf("reputation: failed to update: %v", err)
+	}
+	if score := db.reputation(id); score != -42 {
+		t.Errorf("reputation: value mismatch: have %v, want %v", score, -42)
+	}
+
+	now := time.Now()
+	if bans := db.bans(now); len(bans) != 0 {
+		t.Errorf("bans: non-existing object: %v", bans)
+	}
+	until := now.Add(time.Hour)
+	if err := db.updateBannedUntil(id, until); err != nil {
+		t.Errorf("ban: failed to update: %v", err)
+	}
+	if bans := db.bans(now); len(bans) != 1 || bans[id].Unix() != until.Unix() {
+		t.Errorf("bans: value mismatch: have %v, want %v", bans, until)
+	}
+	// expired bans are not reported
+	if bans := db.bans(until.Add(time.Second)); len(bans) != 0 {
+		t.Errorf("bans: expired ban reported: %v", bans)
+	}
+	if err := db.updateBannedUntil(id, time.Time{}); err != nil {
+		t.Errorf("ban: failed to lift: %v", err)
+	}
+	if bans := db.bans(now); len(bans) != 0 {
+		t.Errorf("bans: lifted ban reported: %v", bans)
+	}
+}
+
 func TestNodeDBSeedQuery(t *testing.T) {
 	db, _ := newNodeDB("", Version, nodeDBSeedQueryNodes[1].node.ID)
 	defer db.close()
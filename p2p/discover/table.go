@@ -420,6 +420,32 @@ loop:
 	close(tab.closed)
 }
 
+// Reputation returns the reputation score of the given node.
+func (tab *Table) Reputation(id NodeID) int {
+	return tab.db.reputation(id)
+}
+
+// SetReputation stores the reputation score of the given node.
+func (tab *Table) SetReputation(id NodeID, score int) error {
+	return tab.db.updateReputation(id, score)
+}
+
+// BannedUntil returns the time until the given node is banned.
+func (tab *Table) BannedUntil(id NodeID) time.Time {
+	return tab.db.bannedUntil(id)
+}
+
+// SetBannedUntil bans the given node until the given time, a zero time lifts
+// the ban.
+func (tab *Table) SetBannedUntil(id NodeID, until time.Time) error {
+	return tab.db.updateBannedUntil(id, until)
+}
+
+// Bans returns the nodes which are currently banned.
+func (tab *Table) Bans() map[NodeID]time.Time {
+	return tab.db.bans(time.Now())
+}
+
 // doRefresh performs a lookup for a random target to keep buckets
 // full. seed nodes are inserted if the table is empty (initial
 // bootstrap or discarded faulty peers).
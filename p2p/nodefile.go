@@ -0,0 +1,122 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+// ParseNodes parses a list of enode URLs.
+func ParseNodes(urls []string) ([]*discover.Node, error) {
+	nodes := make([]*discover.Node, 0, len(urls))
+	for _, url := range urls {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enode %q: %v", url, err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// nodeFile is a JSON file holding a list of enode URLs, it keeps the static
+// and trusted nodes managed through the admin API across restarts.
+type nodeFile struct {
+	path  string
+	nodes map[discover.NodeID]*discover.Node
+}
+
+// loadNodeFile reads the enode URLs from the given path, a missing file yields
+// an empty list.
+func loadNodeFile(path string) (*nodeFile, error) {
+	f := &nodeFile{path: path, nodes: make(map[discover.NodeID]*discover.Node)}
+	if path == "" {
+		return f, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var urls []string
+	if err := json.Unmarshal(data, &urls); err != nil {
+		return nil, fmt.Errorf("invalid node file %s: %v", path, err)
+	}
+	nodes, err := ParseNodes(urls)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node file %s: %v", path, err)
+	}
+	for _, node := range nodes {
+		f.nodes[node.ID] = node
+	}
+	return f, nil
+}
+
+// list returns the nodes of the file.
+func (f *nodeFile) list() []*discover.Node {
+	nodes := make([]*discover.Node, 0, len(f.nodes))
+	for _, node := range f.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// add inserts the node and writes the file, it does nothing if the node is
+// already known.
+func (f *nodeFile) add(node *discover.Node) error {
+	if old, ok := f.nodes[node.ID]; ok && old.String() == node.String() {
+		return nil
+	}
+	f.nodes[node.ID] = node
+	return f.save()
+}
+
+// remove deletes the node and writes the file.
+func (f *nodeFile) remove(id discover.NodeID) error {
+	if _, ok := f.nodes[id]; !ok {
+		return nil
+	}
+	delete(f.nodes, id)
+	return f.save()
+}
+
+func (f *nodeFile) save() error {
+	if f.path == "" {
+		return nil
+	}
+	urls := make([]string, 0, len(f.nodes))
+	for _, node := range f.nodes {
+		urls = append(urls, node.String())
+	}
+	sort.Strings(urls)
+	data, err := json.MarshalIndent(urls, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.path, data, 0644)
+}
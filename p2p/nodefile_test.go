@@ -0,0 +1,57 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testEnode = "enode://c97cc4700c1fd9232de8c63196cb7bf273683f52da48922043af851353c5fad40fc142bd06f333f32e54b677916d12e54fc30f383aaead9b36d5c3079de88f9a@127.0.0.1:7090"
+
+func TestNodeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uranus-nodefile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "static-nodes.json")
+
+	f, err := loadNodeFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, f.list(), 0)
+
+	nodes, err := ParseNodes([]string{testEnode})
+	assert.NoError(t, err)
+	assert.NoError(t, f.add(nodes[0]))
+
+	// reloaded from disk
+	f, err = loadNodeFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, f.list(), 1)
+	assert.Equal(t, testEnode, f.list()[0].String())
+
+	assert.NoError(t, f.remove(nodes[0].ID))
+	f, err = loadNodeFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, f.list(), 0)
+
+	_, err = ParseNodes([]string{"enode://invalid"})
+	assert.Error(t, err)
+}
@@ -48,6 +48,7 @@ type Peer struct {
 	quit       chan string
 	closed     chan struct{}
 	wg         sync.WaitGroup
+	srv        *Server
 }
 
 // ID return the node id
@@ -64,6 +65,20 @@ func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x(%v)", p.rw.id[:8], p.RemoteAddr())
 }
 
+// Trusted reports whether the peer is a trusted node, the trusted peers are not
+// limited by the maximum number of peers.
+func (p *Peer) Trusted() bool {
+	return p.rw.isTrusted()
+}
+
+// AdjustReputation changes the reputation score of the peer by delta, the peer
+// is banned once its score drops too low.
+func (p *Peer) AdjustReputation(delta int, reason string) {
+	if p.srv != nil {
+		p.srv.AdjustReputation(p.ID(), delta, reason)
+	}
+}
+
 // Protocols return supported subprotocols of the remote peer.
 func (p *Peer) Protocols() []*ProtocolKey {
 	return p.rw.protocols
@@ -98,6 +113,7 @@ func NewPeer(conn *conn, protocols []*Protocol) *Peer {
 					in:       make(chan *Message),
 					w:        conn,
 				}
+				conn.rw.protocols = append(conn.rw.protocols, protocol)
 			}
 		}
 	}
@@ -229,6 +245,7 @@ func (p *Peer) PeerInfo() *PeerInfo {
 	info := &PeerInfo{
 		ID:        p.ID().String(),
 		Name:      p.Name(),
+		Trusted:   p.Trusted(),
 		Protocols: make(map[string]interface{}),
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
@@ -0,0 +1,158 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+const (
+	// MaxReputation bounds the credit a peer can earn with useful deliveries.
+	MaxReputation = 100
+	// BanReputation is the score at which a peer gets banned.
+	BanReputation = -100
+	// DefaultBanDuration is how long a peer stays banned by default.
+	DefaultBanDuration = 24 * time.Hour
+)
+
+var (
+	errBanned       = errors.New("banned")
+	errNoDiscovery  = errors.New("discovery is disabled")
+	errBanDuration  = errors.New("ban duration must be positive")
+	errNotConnected = errors.New("not connected")
+)
+
+// BanInfo describes a banned node.
+type BanInfo struct {
+	ID         string    `json:"id"`
+	Until      time.Time `json:"until"`
+	Reputation int       `json:"reputation"`
+}
+
+// Reputation returns the reputation score of the given node.
+func (srv *Server) Reputation(id discover.NodeID) int {
+	if srv.ntab == nil {
+		return 0
+	}
+	return srv.ntab.Reputation(id)
+}
+
+// AdjustReputation changes the reputation score of the given node by delta, the
+// node is banned for DefaultBanDuration once its score drops to BanReputation.
+func (srv *Server) AdjustReputation(id discover.NodeID, delta int, reason string) {
+	if srv.ntab == nil {
+		return
+	}
+	srv.repMu.Lock()
+	defer srv.repMu.Unlock()
+
+	score := srv.ntab.Reputation(id) + delta
+	if score > MaxReputation {
+		score = MaxReputation
+	}
+	log.Debugf("Peer %x reputation %d (%+d): %s", id[:8], score, delta, reason)
+	if score > BanReputation {
+		if err := srv.ntab.SetReputation(id, score); err != nil {
+			log.Warnf("Failed to store reputation of peer %x: %v", id[:8], err)
+		}
+		return
+	}
+	log.Infof("Banning peer %x for %v: %s", id[:8], DefaultBanDuration, reason)
+	srv.ban(id, time.Now().Add(DefaultBanDuration))
+}
+
+// Ban bans the given node for the given duration and disconnects it.
+func (srv *Server) Ban(id discover.NodeID, duration time.Duration) error {
+	if srv.ntab == nil {
+		return errNoDiscovery
+	}
+	if duration <= 0 {
+		return errBanDuration
+	}
+	srv.repMu.Lock()
+	defer srv.repMu.Unlock()
+	return srv.ban(id, time.Now().Add(duration))
+}
+
+// ban resets the reputation score of the node and stores its ban, the caller
+// must hold repMu.
+func (srv *Server) ban(id discover.NodeID, until time.Time) error {
+	if err := srv.ntab.SetReputation(id, 0); err != nil {
+		return err
+	}
+	if err := srv.ntab.SetBannedUntil(id, until); err != nil {
+		return err
+	}
+	go srv.disconnect(id, "banned")
+	return nil
+}
+
+// Unban lifts the ban of the given node and resets its reputation score.
+func (srv *Server) Unban(id discover.NodeID) error {
+	if srv.ntab == nil {
+		return errNoDiscovery
+	}
+	srv.repMu.Lock()
+	defer srv.repMu.Unlock()
+
+	if err := srv.ntab.SetBannedUntil(id, time.Time{}); err != nil {
+		return err
+	}
+	return srv.ntab.SetReputation(id, 0)
+}
+
+// Bans returns the nodes which are currently banned.
+func (srv *Server) Bans() []*BanInfo {
+	if srv.ntab == nil {
+		return nil
+	}
+	infos := []*BanInfo{}
+	for id, until := range srv.ntab.Bans() {
+		infos = append(infos, &BanInfo{ID: id.String(), Until: until, Reputation: srv.ntab.Reputation(id)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Until.Before(infos[j].Until) })
+	return infos
+}
+
+// isBanned reports whether the given node is banned.
+func (srv *Server) isBanned(id discover.NodeID) bool {
+	if srv.ntab == nil {
+		return false
+	}
+	return srv.ntab.BannedUntil(id).After(time.Now())
+}
+
+// disconnect drops the connection to the given node if any.
+func (srv *Server) disconnect(id discover.NodeID, reason string) error {
+	var p *Peer
+	select {
+	case srv.peerOp <- func(peers map[discover.NodeID]*Peer) { p = peers[id] }:
+		<-srv.peerOpDone
+	case <-srv.quit:
+		return errServerExit
+	}
+	if p == nil {
+		return errNotConnected
+	}
+	p.Disconnect(reason)
+	return nil
+}
@@ -30,7 +30,10 @@ import (
 	"time"
 
 	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/metrics"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/p2p/nat"
+	"github.com/UranusBlockStack/uranus/p2p/netutil"
 )
 
 const (
@@ -55,6 +58,28 @@ type Config struct {
 	ListenAddr     string `mapstructure:"p2p-listenaddr"`
 	Protocols      []*Protocol
 	NodeDatabase   string `mapstructure:"p2p-nodes"`
+
+	// NATStr describes the port mapping mechanism, e.g. "any", "upnp",
+	// "pmp" or "extip:<IP>", an empty value disables it.
+	NATStr string `mapstructure:"p2p-nat"`
+	NAT    nat.Interface
+
+	// NetRestrictStr is a comma separated list of CIDR masks, peers are only
+	// dialed and accepted when their IP is contained in one of them.
+	NetRestrictStr string `mapstructure:"p2p-netrestrict"`
+	NetRestrict    *netutil.Netlist
+
+	// StaticNodes are always kept connected, TrustedNodes are accepted even
+	// when the maximum number of peers is reached.
+	StaticNodeStrs  []string `mapstructure:"p2p-staticnodes"`
+	StaticNodes     []*discover.Node
+	TrustedNodeStrs []string `mapstructure:"p2p-trustednodes"`
+	TrustedNodes    []*discover.Node
+
+	// StaticNodesFile and TrustedNodesFile persist the nodes added through the
+	// admin API.
+	StaticNodesFile  string
+	TrustedNodesFile string
 }
 
 type peerOpFunc func(map[discover.NodeID]*Peer)
@@ -82,6 +107,13 @@ type Server struct {
 	peerOpDone    chan struct{}
 	addnode       chan *discover.Node
 	removenode    chan *discover.Node
+	addtrusted    chan *discover.Node
+	removetrusted chan *discover.Node
+
+	staticFile  *nodeFile
+	trustedFile *nodeFile
+
+	repMu sync.Mutex // serializes the reputation updates
 }
 
 func (srv *Server) Start() (err error) {
@@ -105,6 +137,15 @@ func (srv *Server) Start() (err error) {
 	srv.peerOpDone = make(chan struct{})
 	srv.addnode = make(chan *discover.Node)
 	srv.removenode = make(chan *discover.Node)
+	srv.addtrusted = make(chan *discover.Node)
+	srv.removetrusted = make(chan *discover.Node)
+	if srv.staticFile, err = loadNodeFile(srv.StaticNodesFile); err != nil {
+		return err
+	}
+	if srv.trustedFile, err = loadNodeFile(srv.TrustedNodesFile); err != nil {
+		return err
+	}
+	srv.loadLegacyPeers()
 	srv.dialer = &TCPDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 
 	var (
@@ -121,6 +162,17 @@ func (srv *Server) Start() (err error) {
 		return err
 	}
 	realaddr = conn.LocalAddr().(*net.UDPAddr)
+	if srv.NAT != nil {
+		if !realaddr.IP.IsLoopback() {
+			srv.mapPort("udp", realaddr.Port, "uranus discovery")
+		}
+		// announce the external address of the node
+		if ext, err := srv.NAT.ExternalIP(); err == nil {
+			realaddr = &net.UDPAddr{IP: ext, Port: realaddr.Port}
+		} else {
+			log.Warnf("Failed to retrieve external IP via %v: %v", srv.NAT, err)
+		}
+	}
 
 	// node table
 
@@ -130,6 +182,7 @@ func (srv *Server) Start() (err error) {
 		Bootnodes:    srv.BootNodes,
 		Unhandled:    make(chan discover.ReadPacket, 100),
 		NodeDBPath:   srv.Config.NodeDatabase,
+		NetRestrict:  srv.NetRestrict,
 	}
 	ntab, err := discover.ListenUDP(conn, cfg)
 	if err != nil {
@@ -137,9 +190,12 @@ func (srv *Server) Start() (err error) {
 	}
 	srv.ntab = ntab
 
-	dialerTasks := NewDialerManager(srv.MaxPeers, srv.BootNodes, srv.ntab)
+	dialnodes := append([]*discover.Node{}, srv.BootNodes...)
+	dialnodes = append(dialnodes, srv.StaticNodes...)
+	dialnodes = append(dialnodes, srv.staticFile.list()...)
+	dialerTasks := NewDialerManager(srv.MaxPeers, dialnodes, srv.ntab, srv.NetRestrict)
 
-	srv.ourHandshake = &ProtoHandshake{Version: 0, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
+	srv.ourHandshake = &ProtoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
 	for _, p := range srv.Protocols {
 		srv.ourHandshake.Protocols = append(srv.ourHandshake.Protocols, p.Key())
 	}
@@ -151,12 +207,26 @@ func (srv *Server) Start() (err error) {
 		laddr := listener.Addr().(*net.TCPAddr)
 		srv.ListenAddr = laddr.String()
 		srv.listener = listener
+		if srv.NAT != nil && !laddr.IP.IsLoopback() {
+			srv.mapPort("tcp", laddr.Port, "uranus p2p")
+		}
 		srv.wg.Add(1)
 		go srv.listenLoop()
 	}
 
-	os.Mkdir(filepath.Join(srv.Config.NodeDatabase, "addPeer"), 0775)
-	dir, _ := ioutil.ReadDir(filepath.Join(srv.Config.NodeDatabase, "addPeer"))
+	metrics.RegisterGaugeFunc("uranus_p2p_peers", "Number of connected peers.", func() float64 { return float64(srv.PeerCount()) })
+
+	srv.wg.Add(1)
+	go srv.run(dialerTasks)
+	srv.running = true
+	return nil
+}
+
+// loadLegacyPeers moves the peers added by older versions, which were kept as
+// files in the addPeer directory of the node database, to the static nodes file.
+func (srv *Server) loadLegacyPeers() {
+	legacy := filepath.Join(srv.Config.NodeDatabase, "addPeer")
+	dir, _ := ioutil.ReadDir(legacy)
 	for _, finfo := range dir {
 		if finfo.IsDir() {
 			continue
@@ -165,13 +235,23 @@ func (srv *Server) Start() (err error) {
 		if err != nil {
 			continue
 		}
-		srv.BootNodes = append(srv.BootNodes, node)
+		if err := srv.staticFile.add(node); err != nil {
+			log.Warnf("Failed to persist static node %v: %v", node, err)
+			continue
+		}
+		os.Remove(filepath.Join(legacy, finfo.Name()))
 	}
+	os.Remove(legacy)
+}
 
+// mapPort keeps a mapping of the given local port on the NAT device, the
+// mapping is refreshed periodically and removed when the server stops.
+func (srv *Server) mapPort(protocol string, port int, name string) {
 	srv.wg.Add(1)
-	go srv.run(dialerTasks)
-	srv.running = true
-	return nil
+	go func() {
+		defer srv.wg.Done()
+		nat.Map(srv.NAT, srv.quit, protocol, port, port, name)
+	}()
 }
 
 func (srv *Server) Stop() {
@@ -212,6 +292,8 @@ func (srv *Server) PeerCount() int {
 	return count
 }
 
+// AddPeer connects to the given node and keeps the connection, the node is
+// persisted as a static node.
 func (srv *Server) AddPeer(node *discover.Node) {
 	select {
 	case srv.addnode <- node:
@@ -219,6 +301,8 @@ func (srv *Server) AddPeer(node *discover.Node) {
 	}
 }
 
+// RemovePeer disconnects from the given node and removes it from the static
+// nodes.
 func (srv *Server) RemovePeer(node *discover.Node) {
 	select {
 	case srv.removenode <- node:
@@ -226,6 +310,23 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// AddTrustedPeer allows the given node to connect even if the maximum number
+// of peers is reached, the node is persisted as a trusted node.
+func (srv *Server) AddTrustedPeer(node *discover.Node) {
+	select {
+	case srv.addtrusted <- node:
+	case <-srv.quit:
+	}
+}
+
+// RemoveTrustedPeer removes the given node from the trusted nodes.
+func (srv *Server) RemoveTrustedPeer(node *discover.Node) {
+	select {
+	case srv.removetrusted <- node:
+	case <-srv.quit:
+	}
+}
+
 func (srv *Server) Self() *discover.Node {
 	srv.Lock()
 	defer srv.Unlock()
@@ -260,6 +361,7 @@ func (srv *Server) run(dialstate dialer) {
 	var (
 		maxActiveDialTasks = 16
 		peers              = make(map[discover.NodeID]*Peer)
+		trusted            = make(map[discover.NodeID]bool)
 		taskdone           = make(chan Task, maxActiveDialTasks)
 		runningTasks       []Task
 		queuedTasks        []Task
@@ -290,6 +392,13 @@ func (srv *Server) run(dialstate dialer) {
 		}
 	}
 
+	for _, n := range srv.TrustedNodes {
+		trusted[n.ID] = true
+	}
+	for _, n := range srv.trustedFile.list() {
+		trusted[n.ID] = true
+	}
+
 	go func() {
 		ticker := time.NewTicker(time.Second * 30)
 		for {
@@ -323,14 +432,14 @@ running:
 			delTask(t)
 		case c := <-srv.posthandshake:
 			select {
-			case c.cont <- srv.encHandshakeChecks(peers, c):
+			case c.cont <- srv.encHandshakeChecks(peers, trusted, c):
 			case <-srv.quit:
 				break running
 			}
 		case n := <-srv.addnode:
 			log.Debugf("Adding static node %v", n.String())
-			if file, err := os.Create(filepath.Join(srv.NodeDatabase, "addPeer", strings.TrimPrefix(n.String(), "enode://"))); err == nil {
-				defer file.Close()
+			if err := srv.staticFile.add(n); err != nil {
+				log.Warnf("Failed to persist static node %v: %v", n.String(), err)
 			}
 			dialstate.AddStatic(n)
 		case n := <-srv.removenode:
@@ -338,12 +447,33 @@ running:
 			if p, ok := peers[n.ID]; ok {
 				p.Disconnect("remove")
 			}
-			os.Remove(filepath.Join(srv.NodeDatabase, "addPeer", strings.TrimPrefix(n.String(), "enode://")))
+			if err := srv.staticFile.remove(n.ID); err != nil {
+				log.Warnf("Failed to persist static node %v: %v", n.String(), err)
+			}
 			dialstate.RemoveStatic(n)
+		case n := <-srv.addtrusted:
+			log.Debugf("Adding trusted node %v", n.String())
+			trusted[n.ID] = true
+			if err := srv.trustedFile.add(n); err != nil {
+				log.Warnf("Failed to persist trusted node %v: %v", n.String(), err)
+			}
+			if p, ok := peers[n.ID]; ok {
+				p.rw.setTrusted(true)
+			}
+		case n := <-srv.removetrusted:
+			log.Debugf("Removing trusted node %v", n.String())
+			delete(trusted, n.ID)
+			if err := srv.trustedFile.remove(n.ID); err != nil {
+				log.Warnf("Failed to persist trusted node %v: %v", n.String(), err)
+			}
+			if p, ok := peers[n.ID]; ok {
+				p.rw.setTrusted(false)
+			}
 		case c := <-srv.addpeer:
-			err := srv.protoHandshakeChecks(peers, c)
+			err := srv.protoHandshakeChecks(peers, trusted, c)
 			if err == nil {
 				p := NewPeer(c, srv.Protocols)
+				p.srv = srv
 				go srv.runPeer(p)
 				peers[c.id] = p
 			}
@@ -370,12 +500,15 @@ running:
 	}
 }
 
-func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn) error {
-	return srv.encHandshakeChecks(peers, c)
+func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, trusted map[discover.NodeID]bool, c *conn) error {
+	return srv.encHandshakeChecks(peers, trusted, c)
 }
 
-func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn) error {
+func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, trusted map[discover.NodeID]bool, c *conn) error {
+	c.setTrusted(trusted[c.id])
 	switch {
+	case !c.isTrusted() && srv.isBanned(c.id):
+		return errBanned
 	case peers[c.id] != nil:
 		return errors.New("already connected")
 	case c.id == srv.Self().ID:
@@ -417,6 +550,14 @@ func (srv *Server) listenLoop() {
 			break
 		}
 
+		// reject the connections from outside of the whitelist
+		if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok && srv.NetRestrict != nil && !srv.NetRestrict.Contains(tcp.IP) {
+			log.Debugf("Rejected inbound connection from %v: not whitelisted", fd.RemoteAddr())
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
+
 		go func() {
 			srv.SetupConn(fd, nil)
 			slots <- struct{}{}
@@ -444,6 +585,9 @@ func (srv *Server) setupConn(c *conn, dest *discover.Node) error {
 	if !running {
 		return errServerExit
 	}
+	if dest != nil && srv.isBanned(dest.ID) {
+		return errBanned
+	}
 	var err error
 	if c.id, err = c.doEncHandshake(srv.PrivateKey, dest); err != nil {
 		return err
@@ -464,6 +608,8 @@ func (srv *Server) setupConn(c *conn, dest *discover.Node) error {
 	if phs.ID != c.id {
 		return fmt.Errorf("unexpected identity")
 	}
+	// the older peers neither compress nor expect compressed payloads
+	c.rw.snappy = phs.Version >= snappyProtocolVersion
 	c.protocols, c.name = phs.Protocols, phs.Name
 	err = srv.checkpoint(c, srv.addpeer)
 	if err != nil {
@@ -533,6 +679,7 @@ type PeerInfo struct {
 	ID      string   `json:"id"`
 	Name    string   `json:"name"`
 	Caps    []string `json:"caps"`
+	Trusted bool     `json:"trusted"`
 	Network struct {
 		LocalAddress  string `json:"localAddress"`
 		RemoteAddress string `json:"remoteAddress"`
@@ -36,6 +36,21 @@ type ChainConfig struct {
 	MinStartQuantity    *big.Int `json:"startQuantity"`
 	MaxVotes            int64    `json:"votes"`
 	DelayDuration       int64    `json:"refund"`
+
+	// MinGasPrice is the floor of the congestion-adjusted minimum gas price
+	// carried by every block header, nil disables the dynamic pricing.
+	MinGasPrice *big.Int `json:"minGasPrice,omitempty"`
+	// BurnGasFee burns the minimum gas price part of the fees instead of
+	// paying it to the validator.
+	BurnGasFee bool `json:"burnGasFee,omitempty"`
+	// BatchPaymentBlock is the height from which a Binary transaction may pay
+	// several recipients at once, nil disables the batch payments.
+	BatchPaymentBlock *big.Int `json:"batchPaymentBlock,omitempty"`
+}
+
+// IsBatchPayment returns whether the batch payments are enabled at height.
+func (c *ChainConfig) IsBatchPayment(height *big.Int) bool {
+	return c.BatchPaymentBlock != nil && height != nil && c.BatchPaymentBlock.Cmp(height) <= 0
 }
 
 // String implements fmt.Stringer.
@@ -56,6 +71,7 @@ var TestChainConfig = &ChainConfig{
 	BlockInterval:       int64(3000 * time.Millisecond),
 	BlockRepeat:         12,
 	MaxValidatorSize:    3,
+	BatchPaymentBlock:   big.NewInt(0),
 }
 var DefaultChainConfig = &ChainConfig{
 	ChainID:             big.NewInt(1),
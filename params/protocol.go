@@ -31,11 +31,25 @@ const (
 	MinGasLimit uint64 = 1000000
 	//GenesisGasLimit Gas limit of the Genesis block.
 	GenesisGasLimit uint64 = 5000000
+	// MinGasPriceElasticity The gas limit of a block over this divisor is the gas target of the minimum gas price.
+	MinGasPriceElasticity uint64 = 2
+	// MinGasPriceChangeDenominator The bound divisor of the minimum gas price change between blocks.
+	MinGasPriceChangeDenominator uint64 = 8
 	// TxGas Per transaction not creating a contract. NOTE: Not payable on data of calls between transactions.
 	TxGas uint64 = 21000
 	// TxGasContractCreation Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
 	TxGasContractCreation uint64 = 53000
 
+	// TxRecipientGas Per recipient of a batch payment after the first one.
+	TxRecipientGas uint64 = 9000
+	// MaxPaymentRecipients Maximum number of recipients of a batch payment.
+	MaxPaymentRecipients int = 1024
+
+	// MaxMultisigOwners Maximum number of owners of a multisig account.
+	MaxMultisigOwners int = 32
+	// TxSignatureGas Per owner signature of a multisig transaction, each one is recovered.
+	TxSignatureGas uint64 = 3000
+
 	// TxDataZeroGas Per byte of data attached to a transaction that equals zero. NOTE: Not payable on data of calls between transactions.
 	TxDataZeroGas uint64 = 4
 
@@ -105,6 +105,11 @@ func (c *jsonServerCodec) WriteResponse(r *Response, x interface{}) error {
 	return c.encode(resp)
 }
 
+// WriteNotification writes a message that doesn't belong to any request.
+func (c *jsonServerCodec) WriteNotification(method string, params interface{}) error {
+	return c.encode(serverNotification{Method: method, Params: params})
+}
+
 func (c *jsonServerCodec) Close() error {
 	return c.c.Close()
 }
@@ -0,0 +1,79 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/metrics"
+)
+
+var callDuration = metrics.NewHistogramVec("uranus_rpc_duration_seconds", "Latency of the RPC calls by Service.Method.", metrics.DefaultBuckets, "method")
+
+// servers are the RPC servers whose call counters are exported.
+var servers struct {
+	sync.Mutex
+	list []*Server
+}
+
+func init() {
+	metrics.RegisterCounterCollector("uranus_rpc_calls_total", "Number of RPC calls by Service.Method.", []string{"method"}, collectCalls)
+}
+
+// trackServer exports the call counters of the server.
+func trackServer(server *Server) {
+	servers.Lock()
+	defer servers.Unlock()
+	servers.list = append(servers.list, server)
+}
+
+// trackCall starts timing a call of the given method, the returned function
+// records its latency.
+func trackCall(method string) func() {
+	start := time.Now()
+	return func() {
+		callDuration.With(method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// collectCalls emits the calls of each method summed over the servers.
+func collectCalls(emit func(v float64, values ...string)) {
+	servers.Lock()
+	list := append([]*Server{}, servers.list...)
+	servers.Unlock()
+
+	calls := make(map[string]uint)
+	for _, server := range list {
+		server.mu.RLock()
+		for _, s := range server.serviceMap {
+			for name, mtype := range s.method {
+				calls[s.name+"."+name] += mtype.NumCalls()
+			}
+		}
+		server.mu.RUnlock()
+	}
+	methods := make([]string, 0, len(calls))
+	for method := range calls {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		emit(float64(calls[method]), method)
+	}
+}
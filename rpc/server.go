@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,11 +37,14 @@ import (
 // because Typeof takes an empty interface value. This is annoying.
 var typeOfError = reflect.TypeOf((*error)(nil)).Elem()
 
+var typeOfContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 type methodType struct {
 	sync.Mutex // protects counters
 	method     reflect.Method
 	ArgType    reflect.Type
 	ReplyType  reflect.Type
+	hasCtx     bool // method's first argument is a context.Context
 	numCalls   uint
 }
 
@@ -82,7 +86,9 @@ type Server struct {
 
 // NewServer returns a new Server.
 func NewServer() *Server {
-	return &Server{serviceMap: make(map[string]*service)}
+	server := &Server{serviceMap: make(map[string]*service)}
+	trackServer(server)
+	return server
 }
 
 // DefaultServer is the default instance of *Server.
@@ -110,6 +116,8 @@ func isExportedOrBuiltinType(t reflect.Type) bool {
 //	- two arguments, both of exported type
 //	- the second argument is a pointer
 //	- one return value, of type error
+// A context.Context may precede the two arguments, it carries the Notifier
+// of connections that support subscriptions.
 // It returns an error if the receiver is not an exported type or has
 // no suitable methods. It also logs the error using package log.
 // The client accesses each method using a string of the form "Type.Method",
@@ -184,14 +192,20 @@ func suitableMethods(typ reflect.Type, reportErr bool) map[string]*methodType {
 			continue
 		}
 		// Method needs three ins: receiver, *args, *reply.
-		if mtype.NumIn() != 3 {
+		// Or four ins: receiver, context, *args, *reply.
+		hasCtx := mtype.NumIn() == 4 && mtype.In(1) == typeOfContext
+		if mtype.NumIn() != 3 && !hasCtx {
 			if reportErr {
 				log.Error("method ", mname, " has wrong number of ins: ", mtype.NumIn())
 			}
 			continue
 		}
+		offset := 1
+		if hasCtx {
+			offset = 2
+		}
 		// First arg need not be a pointer.
-		argType := mtype.In(1)
+		argType := mtype.In(offset)
 		if !isExportedOrBuiltinType(argType) {
 			if reportErr {
 				log.Error(mname, " argument type not exported: ", argType)
@@ -199,7 +213,7 @@ func suitableMethods(typ reflect.Type, reportErr bool) map[string]*methodType {
 			continue
 		}
 		// Second arg must be a pointer.
-		replyType := mtype.In(2)
+		replyType := mtype.In(offset + 1)
 		if replyType.Kind() != reflect.Ptr {
 			if reportErr {
 				log.Error("method ", mname, " reply type not a pointer: ", replyType)
@@ -227,7 +241,7 @@ func suitableMethods(typ reflect.Type, reportErr bool) map[string]*methodType {
 			}
 			continue
 		}
-		methods[mname] = &methodType{method: method, ArgType: argType, ReplyType: replyType}
+		methods[mname] = &methodType{method: method, ArgType: argType, ReplyType: replyType, hasCtx: hasCtx}
 	}
 	return methods
 }
@@ -259,13 +273,24 @@ func (m *methodType) NumCalls() (n uint) {
 	return n
 }
 
-func (s *service) call(server *Server, sending *sync.Mutex, mtype *methodType, req *Request, argv, replyv reflect.Value, codec ServerCodec) {
+func (s *service) call(server *Server, sending *sync.Mutex, closed chan struct{}, mtype *methodType, req *Request, argv, replyv reflect.Value, codec ServerCodec) {
 	mtype.Lock()
 	mtype.numCalls++
 	mtype.Unlock()
+	done := trackCall(s.name + "." + mtype.method.Name)
 	function := mtype.method.Func
 	// Invoke the method, providing a new value for the reply.
-	returnValues := function.Call([]reflect.Value{s.rcvr, argv, replyv})
+	var returnValues []reflect.Value
+	if mtype.hasCtx {
+		ctx := context.Background()
+		if nw, ok := codec.(notificationWriter); ok && closed != nil {
+			ctx = context.WithValue(ctx, notifierKey{}, &Notifier{method: s.name + ".Subscription", codec: nw, sending: sending, closed: closed})
+		}
+		returnValues = function.Call([]reflect.Value{s.rcvr, reflect.ValueOf(ctx), argv, replyv})
+	} else {
+		returnValues = function.Call([]reflect.Value{s.rcvr, argv, replyv})
+	}
+	done()
 	// The return value for the method is an error.
 	errInter := returnValues[0].Interface()
 	errmsg := ""
@@ -294,6 +319,12 @@ type serverResponse struct {
 	Error  interface{}      `json:"error"`
 }
 
+// serverNotification is a message without id pushed to the client.
+type serverNotification struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
 // ServeConn runs the server on a single connection.
 // ServeConn blocks, serving the connection until the client hangs up.
 // The caller typically invokes ServeConn in a go statement.
@@ -308,6 +339,9 @@ func (server *Server) ServeConn(conn Conn) {
 // decode requests and encode responses.
 func (server *Server) ServeCodec(codec ServerCodec) {
 	sending := new(sync.Mutex)
+	// closed notifies the subscriptions of this connection that it went away.
+	closed := make(chan struct{})
+	defer close(closed)
 	for {
 		service, mtype, req, argv, replyv, keepReading, err := server.readRequest(codec)
 		if err != nil {
@@ -321,7 +355,7 @@ func (server *Server) ServeCodec(codec ServerCodec) {
 			}
 			continue
 		}
-		go service.call(server, sending, mtype, req, argv, replyv, codec)
+		go service.call(server, sending, closed, mtype, req, argv, replyv, codec)
 	}
 	codec.Close()
 }
@@ -343,7 +377,7 @@ func (server *Server) ServeRequest(codec ServerCodec) error {
 		}
 		return err
 	}
-	service.call(server, sending, mtype, req, argv, replyv, codec)
+	service.call(server, sending, nil, mtype, req, argv, replyv, codec)
 	return nil
 }
 
@@ -0,0 +1,77 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrNotificationsUnsupported is returned when the connection doesn't support notifications
+	ErrNotificationsUnsupported = errors.New("notifications not supported")
+	// ErrNotifierClosed is returned when the connection of the notifier is closed
+	ErrNotifierClosed = errors.New("notifier closed")
+)
+
+type notifierKey struct{}
+
+// notificationWriter is implemented by server codecs that can push messages
+// to the client outside of the request/response cycle.
+type notificationWriter interface {
+	WriteNotification(method string, params interface{}) error
+}
+
+// Notification is the message pushed to the client for an active subscription.
+type Notification struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+// Notifier is tied to a RPC connection that supports subscriptions.
+// Server callbacks use the notifier to send notifications.
+type Notifier struct {
+	method  string // notification method, "Service.Subscription"
+	codec   notificationWriter
+	sending *sync.Mutex   // shared with the responses of the connection
+	closed  chan struct{} // closed when the connection is closed
+}
+
+// NotifierFromContext returns the Notifier value stored in ctx, if any.
+func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
+	n, ok := ctx.Value(notifierKey{}).(*Notifier)
+	return n, ok
+}
+
+// Notify sends a notification for the given subscription to the client.
+func (n *Notifier) Notify(id string, data interface{}) error {
+	select {
+	case <-n.closed:
+		return ErrNotifierClosed
+	default:
+	}
+
+	n.sending.Lock()
+	defer n.sending.Unlock()
+	return n.codec.WriteNotification(n.method, &Notification{Subscription: id, Result: data})
+}
+
+// Closed returns a channel that is closed when the RPC connection is closed.
+func (n *Notifier) Closed() <-chan struct{} {
+	return n.closed
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+type NotifyService struct{}
+
+func (s *NotifyService) Echo(ctx context.Context, arg string, reply *string) error {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return ErrNotificationsUnsupported
+	}
+	*reply = "sub"
+	go notifier.Notify("sub", arg)
+	return nil
+}
+
+func TestNotification(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("Notify", new(NotifyService)); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	srv, cli := net.Pipe()
+	defer cli.Close()
+	go server.ServeConn(srv)
+
+	cli.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(cli).Encode(map[string]interface{}{"id": 1, "method": "Notify.Echo", "params": []string{"hello"}}); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	var (
+		dec          = json.NewDecoder(cli)
+		gotResponse  bool
+		notification struct {
+			Method string
+			Params Notification
+		}
+	)
+	for i := 0; i < 2; i++ {
+		var msg map[string]json.RawMessage
+		if err := dec.Decode(&msg); err != nil {
+			t.Fatalf("read message failed: %v", err)
+		}
+		if _, ok := msg["id"]; ok {
+			gotResponse = true
+			continue
+		}
+		json.Unmarshal(msg["method"], &notification.Method)
+		json.Unmarshal(msg["params"], &notification.Params)
+	}
+	if !gotResponse {
+		t.Fatal("missing response")
+	}
+	if notification.Method != "Notify.Subscription" || notification.Params.Subscription != "sub" || notification.Params.Result != "hello" {
+		t.Fatalf("unexpected notification %+v", notification)
+	}
+}
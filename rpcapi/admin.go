@@ -16,7 +16,11 @@
 
 package rpcapi
 
-import "github.com/UranusBlockStack/uranus/p2p"
+import (
+	"time"
+
+	"github.com/UranusBlockStack/uranus/p2p"
+)
 
 // AdminAPI exposes methods for the RPC interface
 type AdminAPI struct {
@@ -42,6 +46,51 @@ func (api *AdminAPI) RemovePeer(url string, reply *bool) error {
 	return err
 }
 
+// AddTrustedPeer allows a remote node to always connect, even if slots are full
+func (api *AdminAPI) AddTrustedPeer(url string, reply *bool) error {
+	err := api.b.AddTrustedPeer(url)
+	*reply = err == nil
+	return err
+}
+
+// RemoveTrustedPeer removes a remote node from the trusted peer set, but it
+// does not disconnect it automatically.
+func (api *AdminAPI) RemoveTrustedPeer(url string, reply *bool) error {
+	err := api.b.RemoveTrustedPeer(url)
+	*reply = err == nil
+	return err
+}
+
+// BanPeerArgs represents the arguments to ban a remote node.
+type BanPeerArgs struct {
+	URL      string  // enode URL or node ID
+	Duration *uint64 // seconds, default 24 hours
+}
+
+// BanPeer disconnects a remote node and refuses its connections for a while
+func (api *AdminAPI) BanPeer(args BanPeerArgs, reply *bool) error {
+	duration := p2p.DefaultBanDuration
+	if args.Duration != nil {
+		duration = time.Duration(*args.Duration) * time.Second
+	}
+	err := api.b.BanPeer(args.URL, duration)
+	*reply = err == nil
+	return err
+}
+
+// UnbanPeer lifts the ban of a remote node and resets its reputation
+func (api *AdminAPI) UnbanPeer(url string, reply *bool) error {
+	err := api.b.UnbanPeer(url)
+	*reply = err == nil
+	return err
+}
+
+// Bans retrieves the remote nodes which are currently banned.
+func (api *AdminAPI) Bans(ignore string, reply *[]*p2p.BanInfo) (err error) {
+	*reply, err = api.b.Bans()
+	return err
+}
+
 // Peers retrieves all the information we know about each individual peer at the protocol granularity.
 func (api *AdminAPI) Peers(ignore string, reply *[]*p2p.PeerInfo) (err error) {
 	*reply, err = api.b.Peers()
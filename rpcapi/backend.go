@@ -19,35 +19,48 @@ package rpcapi
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core"
 	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/txpool"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/feed"
 	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/params"
 	"github.com/UranusBlockStack/uranus/wallet"
 )
 
 // Backend for rpc api
 type Backend interface {
-	// blockchain backend
+	// blockchain backend, BlockChain returns nil on a light node
 	BlockChain() *core.BlockChain
+	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
 	BlockByHeight(ctx context.Context, height BlockHeight) (*types.Block, error)
 	BlockByHash(ctx context.Context, blockHash utils.Hash) (*types.Block, error)
+	HeaderByHeight(ctx context.Context, height BlockHeight) (*types.BlockHeader, error)
+	HeaderByHash(ctx context.Context, blockHash utils.Hash) (*types.BlockHeader, error)
 	GetReceipts(ctx context.Context, blockHash utils.Hash) (types.Receipts, error)
 	GetReceipt(ctx context.Context, txHash utils.Hash) (*types.Receipt, error)
 	GetLogs(ctx context.Context, blockHash utils.Hash) ([][]*types.Log, error)
 	GetTd(blockHash utils.Hash) *big.Int
 	GetTransaction(txHash utils.Hash) *types.StorageTx
+	StateAt(ctx context.Context, root utils.Hash) (*state.StateDB, error)
+	SubscribeChainBlockEvent(ch chan<- feed.BlockAndLogsEvent) feed.Subscription
+	SubscribeRemovedLogsEvent(ch chan<- feed.RemovedLogsEvent) feed.Subscription
 	// txpool backend
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
 	GetPoolTransactions() (types.Transactions, error)
 	GetPoolTransaction(txHash utils.Hash) *types.Transaction
 	GetPoolNonce(ctx context.Context, addr utils.Address) (uint64, error)
+	GetPoolTxStatus(hashes []utils.Hash) []txpool.TxStatus
+	TxPoolPriceBump() uint64
 	TxPoolStats() (pending int, queued int)
 	TxPoolContent() (map[utils.Address]types.Transactions, map[utils.Address]types.Transactions)
+	SubscribeNewTxsEvent(ch chan<- feed.NewTxsEvent) feed.Subscription
 	// wallet backend
 	NewAccount(passphrase string) (wallet.Account, error)
 	Delete(address utils.Address, passphrase string) error
@@ -56,6 +69,8 @@ type Backend interface {
 	Accounts() (wallet.Accounts, error)
 	ImportRawKey(privkey string, passphrase string) (utils.Address, error)
 	ExportRawKey(addr utils.Address, passphrase string) (string, error)
+	Unlock(addr utils.Address, passphrase string, duration time.Duration) error
+	Lock(addr utils.Address)
 	// forecast backend
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 	// evm
@@ -64,6 +79,11 @@ type Backend interface {
 	// p2p
 	AddPeer(url string) error
 	RemovePeer(url string) error
+	AddTrustedPeer(url string) error
+	RemoveTrustedPeer(url string) error
+	BanPeer(url string, duration time.Duration) error
+	UnbanPeer(url string) error
+	Bans() ([]*p2p.BanInfo, error)
 	Peers() ([]*p2p.PeerInfo, error)
 	NodeInfo() (*p2p.NodeInfo, error)
 
@@ -74,4 +94,5 @@ type Backend interface {
 
 	GetConfirmedBlockNumber() (*big.Int, error)
 	GetBFTConfirmedBlockNumber() (*big.Int, error)
+	SubscribeConfirmedEvent() *feed.TypeMuxSubscription
 }
@@ -19,6 +19,7 @@ package rpcapi
 import (
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -32,6 +33,9 @@ import (
 	"github.com/UranusBlockStack/uranus/core/types"
 )
 
+// errNoBlockChain is returned by the methods needing the full chain on a light node.
+var errNoBlockChain = errors.New("full block chain not available on a light node")
+
 // BlockChainAPI exposes methods for the RPC interface
 type BlockChainAPI struct {
 	b Backend
@@ -191,6 +195,10 @@ type ExportBlocksArgs struct {
 
 // ExportBlocks export block
 func (s *BlockChainAPI) ExportBlocks(args ExportBlocksArgs, reply *map[string]interface{}) error {
+	bc := s.b.BlockChain()
+	if bc == nil {
+		return errNoBlockChain
+	}
 
 	fh, err := os.OpenFile(args.FileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
 	if err != nil {
@@ -212,12 +220,16 @@ func (s *BlockChainAPI) ExportBlocks(args ExportBlocksArgs, reply *map[string]in
 		lastHeight := BlockHeight(s.b.CurrentBlock().Height().Int64())
 		args.LastHeight = &lastHeight
 	}
-	return s.b.BlockChain().ExportN(writer, uint64(args.FirstHeight.Int64()), uint64(args.LastHeight.Int64()))
+	return bc.ExportN(writer, uint64(args.FirstHeight.Int64()), uint64(args.LastHeight.Int64()))
 
 }
 
 // ImportBlocks import blocks
 func (s *BlockChainAPI) ImportBlocks(filename string, reply *map[string]interface{}) error {
+	bc := s.b.BlockChain()
+	if bc == nil {
+		return errNoBlockChain
+	}
 	interrupt := make(chan os.Signal, 1)
 	stop := make(chan struct{})
 	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -284,7 +296,7 @@ func (s *BlockChainAPI) ImportBlocks(filename string, reply *map[string]interfac
 		if checkInterrupt() {
 			return fmt.Errorf("interrupted")
 		}
-		if _, err := s.b.BlockChain().InsertChain(blocks); err != nil {
+		if _, err := bc.InsertChain(blocks); err != nil {
 			return fmt.Errorf("invalid block %d: %v", n, err)
 		}
 	}
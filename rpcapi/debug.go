@@ -0,0 +1,252 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/core/vm"
+)
+
+// DebugAPI offers the transaction tracing methods of the node.
+type DebugAPI struct {
+	b Backend
+}
+
+// NewDebugAPI creates a new API definition for the tracing methods of the node.
+func NewDebugAPI(b Backend) *DebugAPI {
+	return &DebugAPI{b}
+}
+
+// CallTracer is the name of the tracer building the tree of the internal calls,
+// the struct logger is used when no tracer is named.
+const CallTracer = "callTracer"
+
+// TraceTransactionArgs represents the arguments to trace a transaction.
+type TraceTransactionArgs struct {
+	TxHash utils.Hash
+	Tracer string
+	Config *vm.LogConfig
+}
+
+// TraceBlockArgs represents the arguments to trace all the transactions of a block,
+// the block is selected by hash if given, otherwise by height.
+type TraceBlockArgs struct {
+	BlockHash   *utils.Hash
+	BlockHeight *BlockHeight
+	Tracer      string
+	Config      *vm.LogConfig
+}
+
+// ExecutionResult groups the result of a traced transaction.
+type ExecutionResult struct {
+	TxHash      utils.Hash     `json:"txHash"`
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue utils.Bytes    `json:"returnValue"`
+	Error       string         `json:"error,omitempty"`
+	StructLogs  []StructLogRes `json:"structLogs,omitempty"`
+	Calls       *vm.CallFrame  `json:"calls,omitempty"`
+}
+
+// StructLogRes stores a structured log emitted by the EVM while replaying a
+// transaction in debug mode.
+type StructLogRes struct {
+	Pc      uint64             `json:"pc"`
+	Op      string             `json:"op"`
+	Gas     uint64             `json:"gas"`
+	GasCost uint64             `json:"gasCost"`
+	Depth   int                `json:"depth"`
+	Error   string             `json:"error,omitempty"`
+	Stack   *[]string          `json:"stack,omitempty"`
+	Memory  *[]string          `json:"memory,omitempty"`
+	Storage *map[string]string `json:"storage,omitempty"`
+}
+
+// TraceTransaction re-executes the transaction on the state it was mined on and
+// returns the struct logs of the execution.
+func (api *DebugAPI) TraceTransaction(args TraceTransactionArgs, reply *ExecutionResult) error {
+	stx := api.b.GetTransaction(args.TxHash)
+	if stx == nil {
+		return fmt.Errorf("transaction %v not found", args.TxHash.Hex())
+	}
+	block, err := api.b.BlockByHash(context.Background(), stx.BlockHash)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("block %v not found", stx.BlockHash.Hex())
+	}
+	results, err := api.traceBlock(block, int(stx.TxIndex), args.Tracer, args.Config)
+	if err != nil {
+		return err
+	}
+	*reply = *results[0]
+	return nil
+}
+
+// TraceBlock re-executes all the transactions of the block on the state of its
+// parent and returns the struct logs of each execution.
+func (api *DebugAPI) TraceBlock(args TraceBlockArgs, reply *[]*ExecutionResult) error {
+	var (
+		block *types.Block
+		err   error
+	)
+	switch {
+	case args.BlockHash != nil:
+		block, err = api.b.BlockByHash(context.Background(), *args.BlockHash)
+	case args.BlockHeight != nil:
+		block, err = api.b.BlockByHeight(context.Background(), *args.BlockHeight)
+	default:
+		block = api.b.CurrentBlock()
+	}
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("block not found")
+	}
+	if block.Height().Sign() == 0 {
+		return fmt.Errorf("genesis is not traceable")
+	}
+	results, err := api.traceBlock(block, -1, args.Tracer, args.Config)
+	if err != nil {
+		return err
+	}
+	*reply = results
+	return nil
+}
+
+// traceBlock replays the transactions of the block on the state of its parent,
+// tracing all of them if txIndex is negative, or only the one at txIndex.
+func (api *DebugAPI) traceBlock(block *types.Block, txIndex int, tracerName string, cfg *vm.LogConfig) ([]*ExecutionResult, error) {
+	if tracerName != "" && tracerName != CallTracer {
+		return nil, fmt.Errorf("unknown tracer %q", tracerName)
+	}
+	bc := api.b.BlockChain()
+	if bc == nil {
+		return nil, errNoBlockChain
+	}
+	parent := bc.GetBlockByHash(block.PreviousHash())
+	if parent == nil {
+		return nil, fmt.Errorf("parent %v not found", block.PreviousHash().Hex())
+	}
+	statedb, err := bc.StateAt(parent.StateRoot())
+	if err != nil {
+		return nil, fmt.Errorf("state of block %v not available: %v", parent.Height(), err)
+	}
+	dposContext, err := types.NewDposContextFromProto(statedb.Database().TrieDB(), parent.BlockHeader().DposContext)
+	if err != nil {
+		return nil, err
+	}
+	bc.ExecActions(statedb, block.Actions())
+
+	var (
+		header  = block.BlockHeader()
+		gp      = new(utils.GasPool).AddGas(block.GasLimit())
+		usedGas = new(uint64)
+		results []*ExecutionResult
+	)
+	for i, tx := range block.Transactions() {
+		if txIndex >= 0 && i > txIndex {
+			break
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		// replay the transactions preceding the traced one without tracer
+		if txIndex >= 0 && i < txIndex {
+			if _, _, _, err := bc.ExecTransaction(nil, dposContext, gp, statedb, header, tx, usedGas, vm.Config{}); err != nil {
+				return nil, fmt.Errorf("transaction %v failed: %v", tx.Hash().Hex(), err)
+			}
+			continue
+		}
+
+		var tracer vm.Tracer
+		if tracerName == CallTracer {
+			tracer = vm.NewCallTracer()
+		} else {
+			tracer = vm.NewStructLogger(cfg)
+		}
+		_, receipt, gas, err := bc.ExecTransaction(nil, dposContext, gp, statedb, header, tx, usedGas, vm.Config{Debug: true, Tracer: tracer})
+		if err != nil {
+			return nil, fmt.Errorf("transaction %v failed: %v", tx.Hash().Hex(), err)
+		}
+		result := &ExecutionResult{
+			TxHash: tx.Hash(),
+			Gas:    gas,
+			Failed: receipt.Status == types.ReceiptStatusFailed,
+		}
+		switch tracer := tracer.(type) {
+		case *vm.CallTracer:
+			if calls := tracer.Result(); calls != nil {
+				result.ReturnValue = calls.Output
+				result.Error = calls.Error
+				result.Calls = calls
+			}
+		case *vm.StructLogger:
+			result.ReturnValue = tracer.Output()
+			result.StructLogs = formatLogs(tracer.StructLogs())
+			if err := tracer.Error(); err != nil {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 && txIndex >= 0 {
+		return nil, fmt.Errorf("transaction index %v out of range", txIndex)
+	}
+	return results, nil
+}
+
+// formatLogs formats EVM returned structured logs for json output.
+func formatLogs(logs []vm.StructLog) []StructLogRes {
+	formatted := make([]StructLogRes, len(logs))
+	for index, trace := range logs {
+		formatted[index] = StructLogRes{
+			Pc:      trace.Pc,
+			Op:      trace.Op.String(),
+			Gas:     trace.Gas,
+			GasCost: trace.GasCost,
+			Depth:   trace.Depth,
+			Error:   trace.ErrorString(),
+		}
+		if trace.Stack != nil {
+			stack := make([]string, len(trace.Stack))
+			for i, value := range trace.Stack {
+				stack[i] = fmt.Sprintf("%x", utils.LeftPadBytes(value.Bytes(), 32))
+			}
+			formatted[index].Stack = &stack
+		}
+		if trace.Memory != nil {
+			memory := make([]string, 0, (len(trace.Memory)+31)/32)
+			for i := 0; i+32 <= len(trace.Memory); i += 32 {
+				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
+			}
+			formatted[index].Memory = &memory
+		}
+		if trace.Storage != nil {
+			storage := make(map[string]string)
+			for i, storageValue := range trace.Storage {
+				storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
+			}
+			formatted[index].Storage = &storage
+		}
+	}
+	return formatted
+}
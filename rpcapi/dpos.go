@@ -54,7 +54,7 @@ func (api *DposAPI) GetValidators(number *BlockHeight, reply *[]utils.Address) e
 	}
 	header := block.BlockHeader()
 
-	statedb, err := api.b.BlockChain().State()
+	statedb, err := api.b.StateAt(context.Background(), block.StateRoot())
 	if err != nil {
 		return err
 	}
@@ -116,7 +116,7 @@ func (api *DposAPI) GetVoter(args *VoterInfoArgs, reply *VoterInfo) error {
 
 	header := block.BlockHeader()
 
-	statedb, err := api.b.BlockChain().StateAt(block.StateRoot())
+	statedb, err := api.b.StateAt(context.Background(), block.StateRoot())
 	if err != nil {
 		return err
 	}
@@ -154,7 +154,7 @@ func (api *DposAPI) GetVoters(number *BlockHeight, reply *[]*VoterInfo) error {
 	}
 	header := block.BlockHeader()
 
-	statedb, err := api.b.BlockChain().State()
+	statedb, err := api.b.StateAt(context.Background(), block.StateRoot())
 	if err != nil {
 		return err
 	}
@@ -199,7 +199,7 @@ func (api *DposAPI) GetCandidates(number *BlockHeight, reply *[]*CandidateInfo)
 	}
 	header := block.BlockHeader()
 
-	statedb, err := api.b.BlockChain().State()
+	statedb, err := api.b.StateAt(context.Background(), block.StateRoot())
 	if err != nil {
 		return err
 	}
@@ -207,7 +207,7 @@ func (api *DposAPI) GetCandidates(number *BlockHeight, reply *[]*CandidateInfo)
 	if err != nil {
 		return err
 	}
-	epochContext := &dpos.EpochContext{DposContext: dposContext, Statedb: statedb, Config: api.b.BlockChain().Config()}
+	epochContext := &dpos.EpochContext{DposContext: dposContext, Statedb: statedb, Config: api.b.ChainConfig()}
 	candidates, err := epochContext.DposContext.GetCandidates()
 	if err != nil {
 		return err
@@ -249,7 +249,7 @@ func (api *DposAPI) GetDelegators(args *CandidateArgs, reply *[]*VoterInfo) erro
 	}
 	header := block.BlockHeader()
 
-	statedb, err := api.b.BlockChain().State()
+	statedb, err := api.b.StateAt(context.Background(), block.StateRoot())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,245 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/bloom"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+// maxFilterBlockRange is the maximum number of blocks a single log query may scan.
+const maxFilterBlockRange = 10000
+
+// FilterCriteria represents a request to search logs.
+type FilterCriteria struct {
+	BlockHash  *utils.Hash
+	FromHeight *BlockHeight
+	ToHeight   *BlockHeight
+	Addresses  []utils.Address
+	Topics     [][]utils.Hash
+}
+
+// Filter can be used to retrieve and filter logs.
+type Filter struct {
+	b         Backend
+	addresses []utils.Address
+	topics    [][]utils.Hash
+
+	block      utils.Hash // Block hash if filtering a single block
+	begin, end int64      // Range interval if filtering multiple blocks
+}
+
+// NewRangeFilter creates a new filter which uses the header blooms to skip blocks
+// that can't contain matching logs.
+func NewRangeFilter(b Backend, begin, end int64, addresses []utils.Address, topics [][]utils.Hash) *Filter {
+	return &Filter{
+		b:         b,
+		addresses: addresses,
+		topics:    topics,
+		begin:     begin,
+		end:       end,
+	}
+}
+
+// NewBlockFilter creates a new filter which directly inspects the contents of
+// a block to figure out whether it is interesting or not.
+func NewBlockFilter(b Backend, block utils.Hash, addresses []utils.Address, topics [][]utils.Hash) *Filter {
+	return &Filter{
+		b:         b,
+		block:     block,
+		addresses: addresses,
+		topics:    topics,
+	}
+}
+
+// newFilterFromCriteria creates a block or range filter from the rpc filter criteria.
+func newFilterFromCriteria(b Backend, crit FilterCriteria) (*Filter, error) {
+	if crit.BlockHash != nil {
+		if crit.FromHeight != nil || crit.ToHeight != nil {
+			return nil, errors.New("cannot specify both blockHash and fromHeight/toHeight")
+		}
+		return NewBlockFilter(b, *crit.BlockHash, crit.Addresses, crit.Topics), nil
+	}
+	begin, end := LatestBlockHeight.Int64(), LatestBlockHeight.Int64()
+	if crit.FromHeight != nil {
+		begin = crit.FromHeight.Int64()
+	}
+	if crit.ToHeight != nil {
+		end = crit.ToHeight.Int64()
+	}
+	return NewRangeFilter(b, begin, end, crit.Addresses, crit.Topics), nil
+}
+
+// Logs searches the blockchain for matching log entries.
+func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	// If we're doing singleton block filtering, execute and return
+	if f.block != (utils.Hash{}) {
+		header, err := f.b.HeaderByHash(ctx, f.block)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("unknown block %v", f.block.Hex())
+		}
+		return f.blockLogs(ctx, header)
+	}
+
+	head := f.b.CurrentBlock().Height().Int64()
+	begin, end := f.begin, f.end
+	if begin == LatestBlockHeight.Int64() {
+		begin = head
+	}
+	if end == LatestBlockHeight.Int64() {
+		end = head
+	}
+	if begin < 0 || end < 0 {
+		return nil, errors.New("pending logs are not supported")
+	}
+	if end > head {
+		end = head
+	}
+	if begin > end {
+		return []*types.Log{}, nil
+	}
+	if end-begin >= maxFilterBlockRange {
+		return nil, fmt.Errorf("block range too large, %d > %d", end-begin+1, maxFilterBlockRange)
+	}
+
+	logs := []*types.Log{}
+	for height := begin; height <= end; height++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		header, err := f.b.HeaderByHeight(ctx, BlockHeight(height))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("unknown block %d", height)
+		}
+		found, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, found...)
+	}
+	return logs, nil
+}
+
+// blockLogs returns the logs matching the filter criteria within a single block.
+func (f *Filter) blockLogs(ctx context.Context, header *types.BlockHeader) ([]*types.Log, error) {
+	if !bloomFilter(header.LogsBloom, f.addresses, f.topics) {
+		return []*types.Log{}, nil
+	}
+	return f.checkMatches(ctx, header)
+}
+
+// checkMatches checks if the receipts belonging to the given header contain any log events that
+// match the filter criteria. This function is called when the bloom filter signals a potential match.
+func (f *Filter) checkMatches(ctx context.Context, header *types.BlockHeader) ([]*types.Log, error) {
+	logsList, err := f.b.GetLogs(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	var unfiltered []*types.Log
+	for _, logs := range logsList {
+		for _, log := range logs {
+			// The derived fields are not guaranteed to be persisted, fill them in from
+			// the header on a copy so the logs held by the backend are left untouched.
+			cpy := *log
+			cpy.BlockHash = header.Hash()
+			cpy.BlockHeight = header.Height.Uint64()
+			unfiltered = append(unfiltered, &cpy)
+		}
+	}
+	return filterLogs(unfiltered, f.addresses, f.topics), nil
+}
+
+func includes(addresses []utils.Address, a utils.Address) bool {
+	for _, addr := range addresses {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLogs creates a slice of logs matching the given criteria.
+func filterLogs(logs []*types.Log, addresses []utils.Address, topics [][]utils.Hash) []*types.Log {
+	ret := []*types.Log{}
+Logs:
+	for _, log := range logs {
+		if len(addresses) > 0 && !includes(addresses, log.Address) {
+			continue
+		}
+		// If the to filtered topics is greater than the amount of topics in logs, skip.
+		if len(topics) > len(log.Topics) {
+			continue Logs
+		}
+		for i, sub := range topics {
+			match := len(sub) == 0 // empty rule set == wildcard
+			for _, topic := range sub {
+				if log.Topics[i] == topic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, log)
+	}
+	return ret
+}
+
+// bloomFilter reports whether the bloom may contain logs matching the given criteria.
+func bloomFilter(b bloom.Bloom, addresses []utils.Address, topics [][]utils.Hash) bool {
+	if len(addresses) > 0 {
+		var included bool
+		for _, addr := range addresses {
+			if b.Test(addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, sub := range topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if b.Test(topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,271 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+var (
+	// filterDeadline is the duration after which an idle filter is uninstalled.
+	filterDeadline = 5 * time.Minute
+
+	errFilterNotFound = errors.New("filter not found")
+)
+
+// filter is a helper struct that holds meta information over the filter type
+// and associated subscription in the event system.
+type filter struct {
+	typ      Type
+	deadline *time.Timer // filter is inactive when deadline triggers
+	hashes   []utils.Hash
+	crit     FilterCriteria
+	logs     []*types.Log
+	s        *Subscription // associated subscription in event system
+}
+
+// FilterAPI offers support to create and manage filters. This will allow external clients to retrieve various
+// information related to the uranus protocol such as blocks, transactions and logs.
+type FilterAPI struct {
+	b         Backend
+	events    *EventSystem
+	filtersMu sync.Mutex
+	filters   map[string]*filter
+	quit      chan struct{}
+}
+
+// NewFilterAPI returns a new FilterAPI instance.
+func NewFilterAPI(b Backend, events *EventSystem) *FilterAPI {
+	api := &FilterAPI{
+		b:       b,
+		events:  events,
+		filters: make(map[string]*filter),
+		quit:    make(chan struct{}),
+	}
+	go api.timeoutLoop()
+	return api
+}
+
+// Stop terminates the timeout loop and uninstalls all the filters.
+func (api *FilterAPI) Stop() {
+	close(api.quit)
+	api.filtersMu.Lock()
+	filters := api.filters
+	api.filters = make(map[string]*filter)
+	api.filtersMu.Unlock()
+	for _, f := range filters {
+		f.s.Unsubscribe()
+	}
+}
+
+// timeoutLoop runs every filterDeadline and deletes filters that have not been recently used.
+func (api *FilterAPI) timeoutLoop() {
+	ticker := time.NewTicker(filterDeadline)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-api.quit:
+			return
+		}
+		api.filtersMu.Lock()
+		for id, f := range api.filters {
+			select {
+			case <-f.deadline.C:
+				delete(api.filters, id)
+				go f.s.Unsubscribe()
+			default:
+				continue
+			}
+		}
+		api.filtersMu.Unlock()
+	}
+}
+
+// NewPendingTransactionFilter creates a filter that fetches pending transaction hashes
+// as transactions enter the pending state.
+func (api *FilterAPI) NewPendingTransactionFilter(ignore string, reply *string) error {
+	var (
+		pendingTxs   = make(chan []utils.Hash)
+		pendingTxSub = api.events.SubscribePendingTxs(pendingTxs)
+	)
+
+	api.filtersMu.Lock()
+	api.filters[pendingTxSub.ID] = &filter{typ: PendingTransactionsSubscription, deadline: time.NewTimer(filterDeadline), hashes: make([]utils.Hash, 0), s: pendingTxSub}
+	api.filtersMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case ph := <-pendingTxs:
+				api.filtersMu.Lock()
+				if f, found := api.filters[pendingTxSub.ID]; found {
+					f.hashes = append(f.hashes, ph...)
+				}
+				api.filtersMu.Unlock()
+			case <-pendingTxSub.Err():
+				return
+			}
+		}
+	}()
+
+	*reply = pendingTxSub.ID
+	return nil
+}
+
+// NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
+func (api *FilterAPI) NewBlockFilter(ignore string, reply *string) error {
+	var (
+		headers   = make(chan *types.BlockHeader)
+		headerSub = api.events.SubscribeNewHeads(headers)
+	)
+
+	api.filtersMu.Lock()
+	api.filters[headerSub.ID] = &filter{typ: BlocksSubscription, deadline: time.NewTimer(filterDeadline), hashes: make([]utils.Hash, 0), s: headerSub}
+	api.filtersMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case h := <-headers:
+				api.filtersMu.Lock()
+				if f, found := api.filters[headerSub.ID]; found {
+					f.hashes = append(f.hashes, h.Hash())
+				}
+				api.filtersMu.Unlock()
+			case <-headerSub.Err():
+				return
+			}
+		}
+	}()
+
+	*reply = headerSub.ID
+	return nil
+}
+
+// NewFilter creates a new filter and returns the filter id. It can be
+// used to retrieve logs when the state changes.
+func (api *FilterAPI) NewFilter(crit FilterCriteria, reply *string) error {
+	if crit.BlockHash != nil {
+		return errors.New("blockHash is not supported by log filters")
+	}
+	logs := make(chan []*types.Log)
+	logsSub := api.events.SubscribeLogs(crit, logs)
+
+	api.filtersMu.Lock()
+	api.filters[logsSub.ID] = &filter{typ: LogsSubscription, crit: crit, deadline: time.NewTimer(filterDeadline), logs: make([]*types.Log, 0), s: logsSub}
+	api.filtersMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case l := <-logs:
+				api.filtersMu.Lock()
+				if f, found := api.filters[logsSub.ID]; found {
+					f.logs = append(f.logs, l...)
+				}
+				api.filtersMu.Unlock()
+			case <-logsSub.Err():
+				return
+			}
+		}
+	}()
+
+	*reply = logsSub.ID
+	return nil
+}
+
+// GetFilterLogs returns all logs in the chain matching the criteria of the log filter with the given id.
+func (api *FilterAPI) GetFilterLogs(id string, reply *[]*types.Log) error {
+	api.filtersMu.Lock()
+	f, found := api.filters[id]
+	api.filtersMu.Unlock()
+
+	if !found || f.typ != LogsSubscription {
+		return errFilterNotFound
+	}
+
+	filter, err := newFilterFromCriteria(api.b, f.crit)
+	if err != nil {
+		return err
+	}
+	logs, err := filter.Logs(context.Background())
+	if err != nil {
+		return err
+	}
+	*reply = logs
+	return nil
+}
+
+// GetFilterChanges returns the logs for the filter with the given id since
+// last time it was called. This can be used for polling.
+//
+// For pending transaction and block filters the result is []utils.Hash.
+// For log filters the result is []*types.Log.
+func (api *FilterAPI) GetFilterChanges(id string, reply *interface{}) error {
+	api.filtersMu.Lock()
+	defer api.filtersMu.Unlock()
+
+	f, found := api.filters[id]
+	if !found {
+		return errFilterNotFound
+	}
+
+	if !f.deadline.Stop() {
+		// timer expired but filter is not yet removed in timeout loop,
+		// drain the timer value before resetting it
+		select {
+		case <-f.deadline.C:
+		default:
+		}
+	}
+	f.deadline.Reset(filterDeadline)
+
+	switch f.typ {
+	case PendingTransactionsSubscription, BlocksSubscription:
+		hashes := f.hashes
+		f.hashes = make([]utils.Hash, 0)
+		*reply = hashes
+	case LogsSubscription:
+		logs := f.logs
+		f.logs = make([]*types.Log, 0)
+		*reply = logs
+	}
+	return nil
+}
+
+// UninstallFilter removes the filter with the given filter id.
+func (api *FilterAPI) UninstallFilter(id string, reply *bool) error {
+	api.filtersMu.Lock()
+	f, found := api.filters[id]
+	if found {
+		delete(api.filters, id)
+	}
+	api.filtersMu.Unlock()
+	if found {
+		f.s.Unsubscribe()
+	}
+
+	*reply = found
+	return nil
+}
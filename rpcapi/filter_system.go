@@ -0,0 +1,405 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/feed"
+)
+
+// Type determines the kind of filter and is used to put the filter in to
+// the correct bucket when added.
+type Type byte
+
+const (
+	// UnknownSubscription indicates an unknown subscription type
+	UnknownSubscription Type = iota
+	// LogsSubscription queries for new or removed (chain reorg) logs
+	LogsSubscription
+	// PendingTransactionsSubscription queries tx hashes for pending transactions entering the pool
+	PendingTransactionsSubscription
+	// BlocksSubscription queries hashes for blocks that are imported
+	BlocksSubscription
+	// ConfirmedSubscription queries block confirmations signed by the validators
+	ConfirmedSubscription
+	// LastIndexSubscription keeps track of the last index
+	LastIndexSubscription
+)
+
+const (
+	// txChanSize is the size of channel listening to NewTxsEvent.
+	txChanSize = 4096
+	// chainEvChanSize is the size of channel listening to BlockAndLogsEvent.
+	chainEvChanSize = 10
+	// rmLogsChanSize is the size of channel listening to RemovedLogsEvent.
+	rmLogsChanSize = 10
+)
+
+type subscription struct {
+	id        string
+	typ       Type
+	created   time.Time
+	logsCrit  FilterCriteria
+	logs      chan []*types.Log
+	hashes    chan []utils.Hash
+	headers   chan *types.BlockHeader
+	confirms  chan *types.Confirmed
+	installed chan struct{} // closed when the filter is installed
+	err       chan error    // closed when the filter is uninstalled
+}
+
+// EventSystem creates subscriptions, processes events and broadcasts them to the
+// subscription which match the subscription criteria.
+type EventSystem struct {
+	backend Backend
+
+	// Subscriptions
+	txsSub       feed.Subscription         // Subscription for new transaction event
+	chainSub     feed.Subscription         // Subscription for new chain event
+	rmLogsSub    feed.Subscription         // Subscription for removed log event
+	confirmedSub *feed.TypeMuxSubscription // Subscription for block confirmation event
+
+	// Channels
+	install   chan *subscription          // install filter for event notification
+	uninstall chan *subscription          // remove filter for event notification
+	txsCh     chan feed.NewTxsEvent       // Channel to receive new transactions event
+	chainCh   chan feed.BlockAndLogsEvent // Channel to receive new chain event
+	rmLogsCh  chan feed.RemovedLogsEvent  // Channel to receive removed log event
+
+	quit     chan struct{} // closed by Stop to terminate the event loop
+	done     chan struct{} // closed when the event loop has returned
+	stopOnce sync.Once
+}
+
+// NewEventSystem creates a new manager that listens for event on the given backend,
+// parses and filters them.
+func NewEventSystem(b Backend) *EventSystem {
+	es := &EventSystem{
+		backend:   b,
+		install:   make(chan *subscription),
+		uninstall: make(chan *subscription),
+		txsCh:     make(chan feed.NewTxsEvent, txChanSize),
+		chainCh:   make(chan feed.BlockAndLogsEvent, chainEvChanSize),
+		rmLogsCh:  make(chan feed.RemovedLogsEvent, rmLogsChanSize),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	// Subscribe events
+	es.txsSub = b.SubscribeNewTxsEvent(es.txsCh)
+	es.chainSub = b.SubscribeChainBlockEvent(es.chainCh)
+	es.rmLogsSub = b.SubscribeRemovedLogsEvent(es.rmLogsCh)
+	es.confirmedSub = b.SubscribeConfirmedEvent()
+
+	go es.eventLoop()
+	return es
+}
+
+// Stop terminates the event loop and releases the backend subscriptions, all the
+// installed subscriptions are closed.
+func (es *EventSystem) Stop() {
+	es.stopOnce.Do(func() {
+		close(es.quit)
+		<-es.done
+	})
+}
+
+// Subscription is created when the client registers itself for a particular event.
+type Subscription struct {
+	ID        string
+	f         *subscription
+	es        *EventSystem
+	unsubOnce sync.Once
+}
+
+// Err returns a channel that is closed when unsubscribed.
+func (sub *Subscription) Err() <-chan error {
+	return sub.f.err
+}
+
+// Unsubscribe uninstalls the subscription from the event broadcast loop.
+func (sub *Subscription) Unsubscribe() {
+	sub.unsubOnce.Do(func() {
+	uninstallLoop:
+		for {
+			// write uninstall request and consume logs/hashes. This prevents
+			// the eventLoop broadcast method to deadlock when writing to the
+			// filter event channel while the subscription loop is waiting for
+			// this method to return (and thus not reading these events).
+			select {
+			case sub.es.uninstall <- sub.f:
+				break uninstallLoop
+			case <-sub.es.done:
+				// the event loop closed all the subscriptions on exit
+				break uninstallLoop
+			case <-sub.f.logs:
+			case <-sub.f.hashes:
+			case <-sub.f.headers:
+			case <-sub.f.confirms:
+			}
+		}
+
+		// wait for filter to be uninstalled in work loop before returning
+		// this ensures that the manager won't use the event channel which
+		// will probably be closed by the client asap after this method returns.
+		<-sub.Err()
+	})
+}
+
+// subscribe installs the subscription in the event broadcast loop.
+func (es *EventSystem) subscribe(sub *subscription) *Subscription {
+	select {
+	case es.install <- sub:
+		<-sub.installed
+	case <-es.done:
+		// the event system is stopped, hand out a closed subscription
+		close(sub.installed)
+		close(sub.err)
+	}
+	return &Subscription{ID: sub.id, f: sub, es: es}
+}
+
+// SubscribeLogs creates a subscription that will write all logs matching the
+// given criteria to the given logs channel.
+func (es *EventSystem) SubscribeLogs(crit FilterCriteria, logs chan []*types.Log) *Subscription {
+	sub := &subscription{
+		id:        newFilterID(),
+		typ:       LogsSubscription,
+		logsCrit:  crit,
+		created:   time.Now(),
+		logs:      logs,
+		hashes:    make(chan []utils.Hash),
+		headers:   make(chan *types.BlockHeader),
+		confirms:  make(chan *types.Confirmed),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeNewHeads creates a subscription that writes the header of a block that is
+// imported in the chain.
+func (es *EventSystem) SubscribeNewHeads(headers chan *types.BlockHeader) *Subscription {
+	sub := &subscription{
+		id:        newFilterID(),
+		typ:       BlocksSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []utils.Hash),
+		headers:   headers,
+		confirms:  make(chan *types.Confirmed),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribePendingTxs creates a subscription that writes transaction hashes for
+// transactions that enter the transaction pool.
+func (es *EventSystem) SubscribePendingTxs(hashes chan []utils.Hash) *Subscription {
+	sub := &subscription{
+		id:        newFilterID(),
+		typ:       PendingTransactionsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    hashes,
+		headers:   make(chan *types.BlockHeader),
+		confirms:  make(chan *types.Confirmed),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeConfirmed creates a subscription that writes the block confirmations
+// of the validators, both the ones of this node and the ones received from peers.
+func (es *EventSystem) SubscribeConfirmed(confirms chan *types.Confirmed) *Subscription {
+	sub := &subscription{
+		id:        newFilterID(),
+		typ:       ConfirmedSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []utils.Hash),
+		headers:   make(chan *types.BlockHeader),
+		confirms:  confirms,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+type filterIndex map[Type]map[string]*subscription
+
+// broadcast event to filters that match criteria.
+func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
+	if ev == nil {
+		return
+	}
+
+	switch e := ev.(type) {
+	case feed.NewTxsEvent:
+		hashes := make([]utils.Hash, 0, len(e.Txs))
+		for _, tx := range e.Txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		for _, f := range filters[PendingTransactionsSubscription] {
+			f.hashes <- hashes
+		}
+	case feed.BlockAndLogsEvent:
+		header := e.Block.BlockHeader()
+		for _, f := range filters[BlocksSubscription] {
+			f.headers <- header
+		}
+		if len(e.Logs) > 0 && len(filters[LogsSubscription]) > 0 {
+			// the event is shared with the other subscribers, the block fields are
+			// filled in on copies of the logs
+			logs := make([]*types.Log, len(e.Logs))
+			for i, l := range e.Logs {
+				cpy := *l
+				cpy.BlockHash = e.Block.Hash()
+				cpy.BlockHeight = header.Height.Uint64()
+				logs[i] = &cpy
+			}
+			for _, f := range filters[LogsSubscription] {
+				if matched := filterLogs(filterLogsRange(logs, f.logsCrit), f.logsCrit.Addresses, f.logsCrit.Topics); len(matched) > 0 {
+					f.logs <- matched
+				}
+			}
+		}
+	case feed.RemovedLogsEvent:
+		for _, f := range filters[LogsSubscription] {
+			if matched := filterLogs(filterLogsRange(e.Logs, f.logsCrit), f.logsCrit.Addresses, f.logsCrit.Topics); len(matched) > 0 {
+				f.logs <- matched
+			}
+		}
+	case feed.NewConfirmedEvent:
+		for _, f := range filters[ConfirmedSubscription] {
+			f.confirms <- e.Confirmed
+		}
+	case types.Confirmed:
+		// confirmations received from peers are forwarded only if correctly signed
+		if !e.IsValidate() {
+			return
+		}
+		for _, f := range filters[ConfirmedSubscription] {
+			f.confirms <- &e
+		}
+	}
+}
+
+// eventLoop (un)installs filters and processes mux events.
+func (es *EventSystem) eventLoop() {
+	index := make(filterIndex)
+	for i := UnknownSubscription; i < LastIndexSubscription; i++ {
+		index[i] = make(map[string]*subscription)
+	}
+
+	// Ensure all subscriptions get cleaned up
+	defer func() {
+		es.txsSub.Unsubscribe()
+		es.chainSub.Unsubscribe()
+		es.rmLogsSub.Unsubscribe()
+		es.confirmedSub.Unsubscribe()
+		for _, filters := range index {
+			for _, f := range filters {
+				close(f.err)
+			}
+		}
+		close(es.done)
+	}()
+
+	for {
+		select {
+		// Handle subscribed events
+		case ev := <-es.txsCh:
+			es.broadcast(index, ev)
+		case ev := <-es.chainCh:
+			es.broadcast(index, ev)
+		case ev := <-es.rmLogsCh:
+			es.broadcast(index, ev)
+		case ev, ok := <-es.confirmedSub.Chan():
+			if !ok {
+				log.Debug("Event system stopped, confirmed subscription closed")
+				return
+			}
+			es.broadcast(index, ev.Data)
+
+		case f := <-es.install:
+			index[f.typ][f.id] = f
+			close(f.installed)
+
+		case f := <-es.uninstall:
+			delete(index[f.typ], f.id)
+			close(f.err)
+
+		// System stopped
+		case <-es.quit:
+			log.Debug("Event system stopped")
+			return
+		case err := <-es.txsSub.Err():
+			log.Debugf("Event system stopped, tx subscription err: %v", err)
+			return
+		case err := <-es.chainSub.Err():
+			log.Debugf("Event system stopped, chain subscription err: %v", err)
+			return
+		case err := <-es.rmLogsSub.Err():
+			log.Debugf("Event system stopped, removed logs subscription err: %v", err)
+			return
+		}
+	}
+}
+
+// filterLogsRange drops the logs outside of the explicit height range of the criteria.
+func filterLogsRange(logs []*types.Log, crit FilterCriteria) []*types.Log {
+	from, to := int64(-1), int64(-1)
+	if crit.FromHeight != nil && crit.FromHeight.Int64() >= 0 {
+		from = crit.FromHeight.Int64()
+	}
+	if crit.ToHeight != nil && crit.ToHeight.Int64() >= 0 {
+		to = crit.ToHeight.Int64()
+	}
+	if from < 0 && to < 0 {
+		return logs
+	}
+	ret := []*types.Log{}
+	for _, l := range logs {
+		if from >= 0 && int64(l.BlockHeight) < from {
+			continue
+		}
+		if to >= 0 && int64(l.BlockHeight) > to {
+			continue
+		}
+		ret = append(ret, l)
+	}
+	return ret
+}
+
+// newFilterID returns a new, random identifier for filters and subscriptions.
+func newFilterID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		log.Errorf("Failed to generate filter id: %v", err)
+	}
+	return "0x" + hex.EncodeToString(id)
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testAddr1  = utils.HexToAddress("0x970e8128ab834e8eac17ab8e3812f010678cf791")
+	testAddr2  = utils.HexToAddress("0x3a5d1a6d4a1ce1ad5bc58b5b87ab6a8a7f3e6b12")
+	testTopic1 = utils.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000001")
+	testTopic2 = utils.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000002")
+	testTopic3 = utils.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000003")
+)
+
+func testLogs() []*types.Log {
+	return []*types.Log{
+		{Address: testAddr1, Topics: []utils.Hash{testTopic1}},
+		{Address: testAddr1, Topics: []utils.Hash{testTopic1, testTopic2}},
+		{Address: testAddr2, Topics: []utils.Hash{testTopic2, testTopic3}},
+	}
+}
+
+func TestFilterLogs(t *testing.T) {
+	logs := testLogs()
+
+	assert.Equal(t, 3, len(filterLogs(logs, nil, nil)))
+	assert.Equal(t, 2, len(filterLogs(logs, []utils.Address{testAddr1}, nil)))
+	assert.Equal(t, 2, len(filterLogs(logs, nil, [][]utils.Hash{{}, {testTopic2, testTopic3}})))
+	assert.Equal(t, 1, len(filterLogs(logs, []utils.Address{testAddr1}, [][]utils.Hash{{testTopic1}, {testTopic2}})))
+	assert.Equal(t, 0, len(filterLogs(logs, []utils.Address{testAddr2}, [][]utils.Hash{{testTopic1}})))
+}
+
+func TestBloomFilter(t *testing.T) {
+	bloom := types.CreateBloom(types.Receipts{{Logs: testLogs()[:2]}})
+
+	assert.True(t, bloomFilter(bloom, nil, nil))
+	assert.True(t, bloomFilter(bloom, []utils.Address{testAddr1}, [][]utils.Hash{{testTopic1}, {testTopic2}}))
+	assert.True(t, bloomFilter(bloom, []utils.Address{testAddr1, testAddr2}, nil))
+	assert.False(t, bloomFilter(bloom, []utils.Address{testAddr2}, nil))
+	assert.False(t, bloomFilter(bloom, nil, [][]utils.Hash{{testTopic3}}))
+}
+
+func TestFilterLogsRange(t *testing.T) {
+	logs := testLogs()
+	for i, l := range logs {
+		l.BlockHeight = uint64(i + 1)
+	}
+	from, to, latest := BlockHeight(2), BlockHeight(2), LatestBlockHeight
+
+	assert.Equal(t, 3, len(filterLogsRange(logs, FilterCriteria{})))
+	assert.Equal(t, 3, len(filterLogsRange(logs, FilterCriteria{FromHeight: &latest})))
+	assert.Equal(t, 2, len(filterLogsRange(logs, FilterCriteria{FromHeight: &from})))
+	assert.Equal(t, 1, len(filterLogsRange(logs, FilterCriteria{FromHeight: &from, ToHeight: &to})))
+}
+
+func TestBroadcastLogsCopy(t *testing.T) {
+	var (
+		logs  = testLogs()
+		block = types.NewBlockWithBlockHeader(&types.BlockHeader{Height: big.NewInt(5)})
+		sub   = &subscription{typ: LogsSubscription, logs: make(chan []*types.Log, 1)}
+	)
+	filters := filterIndex{LogsSubscription: {"logs": sub}}
+	new(EventSystem).broadcast(filters, feed.BlockAndLogsEvent{Block: block, Logs: logs})
+
+	matched := <-sub.logs
+	assert.Equal(t, len(logs), len(matched))
+	for i, l := range matched {
+		assert.Equal(t, block.Hash(), l.BlockHash)
+		assert.Equal(t, uint64(5), l.BlockHeight)
+		// the logs of the event are shared with the other subscribers
+		assert.Equal(t, utils.Hash{}, logs[i].BlockHash)
+		assert.Equal(t, uint64(0), logs[i].BlockHeight)
+	}
+}
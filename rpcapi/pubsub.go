@@ -0,0 +1,212 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/rpc"
+)
+
+// Subscription kinds accepted by PubSub.Subscribe.
+const (
+	NewHeadsKind               = "newHeads"
+	LogsKind                   = "logs"
+	NewPendingTransactionsKind = "newPendingTransactions"
+	ConfirmedKind              = "confirmed"
+)
+
+// notificationBufferSize is the number of notifications queued for a connection
+// before the subscription is dropped as too slow.
+const notificationBufferSize = 1024
+
+// SubscribeArgs represents the arguments of a subscription.
+type SubscribeArgs struct {
+	Kind     string
+	Criteria *FilterCriteria // only used by logs subscriptions
+}
+
+// PubSubAPI offers push notifications over connections that support them (websocket).
+// Notifications are sent as {"method":"PubSub.Subscription","params":{"subscription":id,"result":...}}.
+type PubSubAPI struct {
+	events *EventSystem
+	mu     sync.Mutex
+	subs   map[string]*pubsubEntry
+}
+
+// pubsubEntry is a subscription with the connection it was created on.
+type pubsubEntry struct {
+	sub  *Subscription
+	conn <-chan struct{} // closed channel of the connection, identifies it
+}
+
+// NewPubSubAPI returns a new PubSubAPI instance.
+func NewPubSubAPI(events *EventSystem) *PubSubAPI {
+	return &PubSubAPI{
+		events: events,
+		subs:   make(map[string]*pubsubEntry),
+	}
+}
+
+// Subscribe creates a subscription of the given kind and returns its id.
+func (api *PubSubAPI) Subscribe(ctx context.Context, args SubscribeArgs, reply *string) error {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return rpc.ErrNotificationsUnsupported
+	}
+
+	var (
+		sub     *Subscription
+		forward func() (interface{}, bool)
+	)
+	switch args.Kind {
+	case NewHeadsKind:
+		headers := make(chan *types.BlockHeader)
+		sub = api.events.SubscribeNewHeads(headers)
+		forward = func() (interface{}, bool) {
+			select {
+			case h := <-headers:
+				return h, true
+			case <-sub.Err():
+				return nil, false
+			}
+		}
+	case LogsKind:
+		crit := FilterCriteria{}
+		if args.Criteria != nil {
+			crit = *args.Criteria
+		}
+		if crit.BlockHash != nil {
+			return fmt.Errorf("blockHash is not supported by log subscriptions")
+		}
+		logs := make(chan []*types.Log)
+		sub = api.events.SubscribeLogs(crit, logs)
+		forward = func() (interface{}, bool) {
+			select {
+			case l := <-logs:
+				return l, true
+			case <-sub.Err():
+				return nil, false
+			}
+		}
+	case NewPendingTransactionsKind:
+		hashes := make(chan []utils.Hash)
+		sub = api.events.SubscribePendingTxs(hashes)
+		forward = func() (interface{}, bool) {
+			select {
+			case h := <-hashes:
+				return h, true
+			case <-sub.Err():
+				return nil, false
+			}
+		}
+	case ConfirmedKind:
+		confirms := make(chan *types.Confirmed)
+		sub = api.events.SubscribeConfirmed(confirms)
+		forward = func() (interface{}, bool) {
+			select {
+			case c := <-confirms:
+				return c, true
+			case <-sub.Err():
+				return nil, false
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported subscription kind %q", args.Kind)
+	}
+
+	api.mu.Lock()
+	api.subs[sub.ID] = &pubsubEntry{sub: sub, conn: notifier.Closed()}
+	api.mu.Unlock()
+
+	// read events into a bounded buffer so the event system is not blocked while
+	// the notifications are written to the connection, a client which falls
+	// behind by more than the buffer loses its subscription.
+	events := make(chan interface{}, notificationBufferSize)
+	go func() {
+		defer close(events)
+		for {
+			data, ok := forward()
+			if !ok {
+				return
+			}
+			select {
+			case events <- data:
+			default:
+				log.Warnf("PubSub subscription %v dropped, client too slow", sub.ID)
+				sub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	go func() {
+		defer api.remove(sub.ID)
+		for {
+			select {
+			case data, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := notifier.Notify(sub.ID, data); err != nil {
+					log.Debugf("PubSub notify subscription %v failed: %v", sub.ID, err)
+					return
+				}
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	*reply = sub.ID
+	return nil
+}
+
+// Unsubscribe cancels the subscription with the given id, only subscriptions
+// created on the same connection can be cancelled.
+func (api *PubSubAPI) Unsubscribe(ctx context.Context, id string, reply *bool) error {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return rpc.ErrNotificationsUnsupported
+	}
+
+	api.mu.Lock()
+	entry, found := api.subs[id]
+	api.mu.Unlock()
+	if !found || entry.conn != notifier.Closed() {
+		*reply = false
+		return nil
+	}
+	*reply = api.remove(id)
+	return nil
+}
+
+// remove uninstalls the subscription from the event system.
+func (api *PubSubAPI) remove(id string) bool {
+	api.mu.Lock()
+	entry, found := api.subs[id]
+	delete(api.subs, id)
+	api.mu.Unlock()
+	if found {
+		entry.sub.Unsubscribe()
+	}
+	return found
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/txpool"
 )
 
 // TransactionPoolAPI exposes methods for the RPC interface
@@ -70,3 +71,25 @@ func (s *TransactionPoolAPI) Status(ignore string, reply *map[string]utils.Uint)
 	}
 	return nil
 }
+
+// TxStatus returns the status (pending/queued/included/unknown) of each of the
+// transactions, a transaction out of the pool is looked up in the chain.
+func (s *TransactionPoolAPI) TxStatus(hashes []utils.Hash, reply *[]string) error {
+	statuses := make([]string, len(hashes))
+	for i, status := range s.b.GetPoolTxStatus(hashes) {
+		switch status {
+		case txpool.TxStatusPending:
+			statuses[i] = "pending"
+		case txpool.TxStatusQueued:
+			statuses[i] = "queued"
+		default:
+			if s.b.GetTransaction(hashes[i]) != nil {
+				statuses[i] = "included"
+			} else {
+				statuses[i] = "unknown"
+			}
+		}
+	}
+	*reply = statuses
+	return nil
+}
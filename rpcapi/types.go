@@ -178,6 +178,10 @@ func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]inter
 		"receiptsRoot":     head.ReceiptsRoot,
 	}
 
+	if head.MinGasPrice != nil {
+		fields["minGasPrice"] = (*utils.Big)(head.MinGasPrice)
+	}
+
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
 			return tx.Hash(), nil
@@ -19,6 +19,7 @@ package rpcapi
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"time"
@@ -28,8 +29,10 @@ import (
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/executor"
 	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/txpool"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/params"
 )
 
 // UranusAPI exposes methods for the RPC interface
@@ -115,6 +118,20 @@ func (u *UranusAPI) GetUnDelegateTimestamp(args GetBalanceArgs, reply *utils.Big
 	return nil
 }
 
+// GetMultisig returns the owners and the threshold of the multisig account in the state of the given block number
+func (u *UranusAPI) GetMultisig(args GetBalanceArgs, reply *state.Multisig) error {
+	statedb, err := u.getState(args.getBlockHeight())
+	if err != nil {
+		return err
+	}
+	multisig := statedb.GetMultisig(args.Address)
+	if multisig == nil {
+		return fmt.Errorf("%v isn't a multisig account", args.Address.Hex())
+	}
+	*reply = *multisig
+	return nil
+}
+
 type GetNonceArgs struct {
 	GetBalanceArgs
 }
@@ -155,7 +172,9 @@ type SendTxArgs struct {
 	Nonce      *utils.Uint64
 	Data       *utils.Bytes
 	TxType     *utils.Uint64
-	Passphrase string
+	Amounts    []*utils.Big    // amount of each recipient of a batch payment, empty to split Value evenly
+	Multisig   *state.Multisig // owners and threshold of a Multisig transaction
+	Passphrase string          // empty to sign with the unlocked account
 }
 
 // check is a helper function that fills in default values for unspecified tx fields.
@@ -165,9 +184,55 @@ func (args *SendTxArgs) check(ctx context.Context, b Backend) error {
 		*(*uint64)(args.TxType) = uint64(types.Binary)
 	}
 
+	if len(args.Amounts) > 0 {
+		if len(args.Amounts) != len(args.Tos) {
+			return errors.New(`"amounts" and "tos" length mismatch`)
+		}
+		if args.Data != nil && len(*args.Data) > 0 {
+			return errors.New(`both "data" and "amounts" specified`)
+		}
+		value, amounts := new(big.Int), make([]*big.Int, len(args.Amounts))
+		for i, amount := range args.Amounts {
+			amounts[i] = (*big.Int)(amount)
+			value.Add(value, amounts[i])
+		}
+		payload, err := types.EncodePaymentAmounts(amounts)
+		if err != nil {
+			return err
+		}
+		args.Data = (*utils.Bytes)(&payload)
+		args.Value = (*utils.Big)(value)
+	}
+
+	if args.Multisig != nil {
+		if types.TxType(*args.TxType) != types.Multisig {
+			return errors.New(`"multisig" specified for another transaction type`)
+		}
+		if err := args.Multisig.Validate(); err != nil {
+			return err
+		}
+		payload, err := args.Multisig.Encode()
+		if err != nil {
+			return err
+		}
+		args.Data = (*utils.Bytes)(&payload)
+	}
+
 	if args.Gas == nil {
 		args.Gas = new(utils.Uint64)
 		*(*uint64)(args.Gas) = 90000
+		// batch payments keep the same allowance over their intrinsic gas
+		if txType := types.TxType(*args.TxType); txType == types.Binary && len(args.Tos) > 1 {
+			var input []byte
+			if args.Data != nil {
+				input = *args.Data
+			}
+			intrinsic, err := txpool.IntrinsicGas(input, txType, false, len(args.Tos), 0)
+			if err != nil {
+				return err
+			}
+			*(*uint64)(args.Gas) += intrinsic - params.TxGas
+		}
 	}
 	if args.GasPrice == nil {
 		price, err := b.SuggestGasPrice(ctx)
@@ -245,6 +310,96 @@ func (u *UranusAPI) SendRawTransaction(encodedTx utils.Bytes, reply *utils.Hash)
 	return nil
 }
 
+// ReplaceTxArgs represents the arguments to replace a pooled transaction.
+type ReplaceTxArgs struct {
+	Hash       utils.Hash
+	GasPrice   *utils.Big    // empty to pay the minimum price bump
+	Gas        *utils.Uint64 // empty to keep the gas limit
+	Passphrase string        // empty to sign with the unlocked account
+}
+
+// CancelTxArgs represents the arguments to cancel a pooled transaction.
+type CancelTxArgs struct {
+	Hash       utils.Hash
+	Passphrase string // empty to sign with the unlocked account
+}
+
+// ReplacedTransaction is the result of a replacement, it reports the gas price
+// paid along with the minimum one the pool accepts.
+type ReplacedTransaction struct {
+	Hash        utils.Hash `json:"hash"`
+	GasPrice    *utils.Big `json:"gasPrice"`
+	MinGasPrice *utils.Big `json:"minGasPrice"`
+}
+
+// ReplaceTransaction re-signs the pooled transaction with a higher gas price and
+// optionally another gas limit, and submits it in place of the original one.
+func (u *UranusAPI) ReplaceTransaction(args ReplaceTxArgs, reply *ReplacedTransaction) error {
+	old, from, minPrice, err := u.replaceable(args.Hash)
+	if err != nil {
+		return err
+	}
+	price := minPrice
+	if args.GasPrice != nil {
+		if price = (*big.Int)(args.GasPrice); price.Cmp(minPrice) < 0 {
+			return fmt.Errorf("gas price %v under the minimum replacement price %v", price, minPrice)
+		}
+	}
+	gas := old.Gas()
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	}
+	var tx *types.Transaction
+	if old.Tos() == nil {
+		tx = types.NewTransaction(old.Type(), old.Nonce(), old.Value(), gas, price, old.Payload())
+	} else {
+		tx = types.NewTransaction(old.Type(), old.Nonce(), old.Value(), gas, price, old.Payload(), old.Tos()...)
+	}
+	return u.submitReplacement(from, tx, args.Passphrase, minPrice, reply)
+}
+
+// CancelTransaction replaces the pooled transaction with a transfer of nothing
+// to its sender, paying the minimum price bump.
+func (u *UranusAPI) CancelTransaction(args CancelTxArgs, reply *ReplacedTransaction) error {
+	old, from, minPrice, err := u.replaceable(args.Hash)
+	if err != nil {
+		return err
+	}
+	tx := types.NewTransaction(types.Binary, old.Nonce(), new(big.Int), params.TxGas, minPrice, nil, &from)
+	return u.submitReplacement(from, tx, args.Passphrase, minPrice, reply)
+}
+
+// replaceable looks the transaction up in the pool, returning it with its sender
+// and the minimum gas price of a replacement.
+func (u *UranusAPI) replaceable(hash utils.Hash) (*types.Transaction, utils.Address, *big.Int, error) {
+	tx := u.b.GetPoolTransaction(hash)
+	if tx == nil {
+		return nil, utils.Address{}, nil, fmt.Errorf("transaction %v not found in the pool", hash.Hex())
+	}
+	from, err := tx.Sender(types.NewSigner(u.b.ChainConfig().ChainID))
+	if err != nil {
+		return nil, utils.Address{}, nil, err
+	}
+	return tx, from, txpool.MinReplacementPrice(tx.GasPrice(), u.b.TxPoolPriceBump()), nil
+}
+
+func (u *UranusAPI) submitReplacement(from utils.Address, tx *types.Transaction, passphrase string, minPrice *big.Int, reply *ReplacedTransaction) error {
+	signed, err := u.b.SignTx(from, tx, passphrase)
+	if err != nil {
+		return err
+	}
+	hash, err := submitTransaction(context.Background(), u.b, signed)
+	if err != nil {
+		return err
+	}
+	*reply = ReplacedTransaction{
+		Hash:        hash,
+		GasPrice:    (*utils.Big)(signed.GasPrice()),
+		MinGasPrice: (*utils.Big)(minPrice),
+	}
+	return nil
+}
+
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
 	From        utils.Address
@@ -259,33 +414,45 @@ type CallArgs struct {
 
 // Call executes the given transaction on the state for the given block number.
 func (u *UranusAPI) Call(args CallArgs, reply *map[string]interface{}) error {
+	// Set default gas & gas price if none were set
+	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
+	if gas == 0 {
+		gas = math.MaxUint64 / 2
+	}
+	if gasPrice.Sign() == 0 {
+		gasPrice = new(big.Int).SetUint64(1e9)
+	}
+
+	res, gasused, failed, err := u.doCall(args, gas, gasPrice, 5*time.Second)
+
+	ret := map[string]interface{}{}
+	ret["result"] = res
+	ret["gasUsed"] = gasused
+	ret["failed"] = failed
+	ret["error"] = err
+
+	*reply = ret
+	return err
+}
+
+func (u *UranusAPI) doCall(args CallArgs, gas uint64, gasPrice *big.Int, timeout time.Duration) ([]byte, uint64, bool, error) {
 	blockheight := LatestBlockHeight
 	if args.BlockHeight != nil {
 		blockheight = *args.BlockHeight
 	}
-	timeout := 5 * time.Second
 	defer func(start time.Time) { log.Debugf("Executing EVM call finished runtime: %v", time.Since(start)) }(time.Now())
 	block, err := u.b.BlockByHeight(context.Background(), blockheight)
 	if err != nil {
-		return err
+		return nil, 0, false, err
 	}
-	state, err := u.b.BlockChain().StateAt(block.StateRoot())
+	state, err := u.b.StateAt(context.Background(), block.StateRoot())
 	if err != nil {
-		return err
-	}
-
-	// Set default gas & gas price if none were set
-	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
-	if gas == 0 {
-		gas = math.MaxUint64 / 2
-	}
-	if gasPrice.Sign() == 0 {
-		gasPrice = new(big.Int).SetUint64(1e9)
+		return nil, 0, false, err
 	}
 
 	nonce, err := u.b.GetPoolNonce(context.Background(), args.From)
 	if err != nil {
-		return err
+		return nil, 0, false, err
 	}
 
 	tx := types.NewTransaction(types.TxType(args.TxType), nonce, args.Value.ToInt(), gas, gasPrice, args.Data, args.Tos...)
@@ -309,7 +476,7 @@ func (u *UranusAPI) Call(args CallArgs, reply *map[string]interface{}) error {
 	// Get a new instance of the EVM.
 	evm, vmError, err := u.b.GetEVM(ctx, args.From, tx, state, block.BlockHeader(), vm.Config{})
 	if err != nil {
-		return err
+		return nil, 0, false, err
 	}
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -324,17 +491,206 @@ func (u *UranusAPI) Call(args CallArgs, reply *map[string]interface{}) error {
 
 	res, gasused, failed, err := stx.TransitionDb()
 	if err := vmError(); err != nil {
+		return nil, 0, false, err
+	}
+	return res, gasused, failed, err
+}
+
+// EstimateGas returns the lowest gas limit that allows the transaction to run successfully
+// against the state of the given block number.
+func (u *UranusAPI) EstimateGas(args CallArgs, reply *utils.Uint64) error {
+	txType := types.TxType(args.TxType)
+	if txType > types.Multisig {
+		return types.ErrInvalidType
+	}
+	blockheight := LatestBlockHeight
+	if args.BlockHeight != nil {
+		blockheight = *args.BlockHeight
+	}
+	block, err := u.b.BlockByHeight(context.Background(), blockheight)
+	if err != nil {
+		return err
+	}
+	statedb, err := u.b.StateAt(context.Background(), block.StateRoot())
+	if err != nil {
 		return err
 	}
+	// A multisig account sends the signatures of at least threshold owners, the
+	// call below runs without them so their gas is added to the estimate.
+	var signatures int
+	if multisig := statedb.GetMultisig(args.From); multisig != nil {
+		signatures = int(multisig.Threshold)
+	}
+	signatureGas := uint64(signatures) * params.TxSignatureGas
+	intrinsic, err := txpool.IntrinsicGas(args.Data, txType, len(args.Tos) == 0 && txType == types.Binary, len(args.Tos), signatures)
+	if err != nil {
+		return err
+	}
+	// DPoS transactions don't run in the EVM, they always cost their intrinsic gas (see Executor.applyDposMessage).
+	if txType != types.Binary {
+		*reply = utils.Uint64(intrinsic)
+		return nil
+	}
 
-	ret := map[string]interface{}{}
-	ret["result"] = res
-	ret["gasUsed"] = gasused
-	ret["failed"] = failed
-	ret["error"] = err
+	hi := block.GasLimit()
+	if uint64(args.Gas) >= intrinsic && uint64(args.Gas) < hi {
+		hi = uint64(args.Gas)
+	}
+	// The sender can't pay for more gas than its balance left after the transfer.
+	gasPrice := args.GasPrice.ToInt()
+	if gasPrice.Sign() > 0 {
+		if hi, err = gasAllowance(hi, statedb.GetBalance(args.From), args.Value.ToInt(), gasPrice); err != nil {
+			return err
+		}
+	}
 
-	*reply = ret
-	return err
+	if hi < intrinsic {
+		return fmt.Errorf("gas required exceeds allowance (%d) or always failing transaction", hi)
+	}
+
+	// A failing execution means the gas is too low, the last failure is reported
+	// if the transaction fails even at the highest allowance.
+	var lastErr error
+	executable := func(gas uint64) bool {
+		_, _, failed, err := u.doCall(args, gas, gasPrice, 0)
+		if err != nil {
+			lastErr = err
+			return false
+		}
+		return !failed
+	}
+	gas, ok := searchGas(intrinsic-signatureGas-1, hi-signatureGas, executable)
+	if !ok {
+		if lastErr != nil {
+			return fmt.Errorf("gas required exceeds allowance (%d) or always failing transaction: %v", hi, lastErr)
+		}
+		return fmt.Errorf("gas required exceeds allowance (%d) or always failing transaction", hi)
+	}
+	*reply = utils.Uint64(gas + signatureGas)
+	return nil
+}
+
+// gasAllowance caps the gas limit hi by the gas the balance can pay for at the
+// given price once the value is transferred.
+func gasAllowance(hi uint64, balance, value, gasPrice *big.Int) (uint64, error) {
+	available := new(big.Int).Set(balance)
+	if value != nil {
+		if value.Cmp(available) > 0 {
+			return 0, errors.New("insufficient funds for transfer")
+		}
+		available.Sub(available, value)
+	}
+	allowance := available.Div(available, gasPrice)
+	if allowance.IsUint64() && allowance.Uint64() < hi {
+		return allowance.Uint64(), nil
+	}
+	return hi, nil
+}
+
+// searchGas binary searches the lowest gas in (lo, hi] for which executable
+// succeeds, it reports false if the execution fails even with hi.
+func searchGas(lo, hi uint64, executable func(gas uint64) bool) (uint64, bool) {
+	if hi <= lo || !executable(hi) {
+		return 0, false
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		if executable(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, true
+}
+
+// GetLogs returns logs matching the given argument that are stored within the state.
+func (u *UranusAPI) GetLogs(args FilterCriteria, reply *[]*types.Log) error {
+	filter, err := newFilterFromCriteria(u.b, args)
+	if err != nil {
+		return err
+	}
+	logs, err := filter.Logs(context.Background())
+	if err != nil {
+		return err
+	}
+	*reply = logs
+	return nil
+}
+
+// GetProofArgs represents the arguments to get the merkle proof of an account.
+type GetProofArgs struct {
+	Address     utils.Address
+	StorageKeys []utils.Hash
+	BlockHeight *BlockHeight
+}
+
+// AccountResult is the account with its merkle proof in the state trie.
+type AccountResult struct {
+	Address         utils.Address   `json:"address"`
+	AccountProof    []utils.Bytes   `json:"accountProof"`
+	Balance         *utils.Big      `json:"balance"`
+	LockedBalance   *utils.Big      `json:"lockedBalance"`
+	UnLockedBalance *utils.Big      `json:"unlockedBalance"`
+	Nonce           utils.Uint64    `json:"nonce"`
+	CodeHash        utils.Hash      `json:"codeHash"`
+	StorageRoot     utils.Hash      `json:"storageRoot"`
+	StorageProof    []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is a storage slot with its merkle proof in the storage trie of the account.
+type StorageResult struct {
+	Key   utils.Hash    `json:"key"`
+	Value utils.Hash    `json:"value"`
+	Proof []utils.Bytes `json:"proof"`
+}
+
+// GetProof returns the account and the storage slots for the given keys with
+// their merkle proofs, which can be verified against the state root of the block.
+func (u *UranusAPI) GetProof(args GetProofArgs, reply *AccountResult) error {
+	blockheight := LatestBlockHeight
+	if args.BlockHeight != nil {
+		blockheight = *args.BlockHeight
+	}
+	state, err := u.getState(blockheight)
+	if err != nil {
+		return err
+	}
+
+	storageProof := make([]StorageResult, len(args.StorageKeys))
+	for i, key := range args.StorageKeys {
+		proof, err := state.GetStorageProof(args.Address, key)
+		if err != nil {
+			return err
+		}
+		storageProof[i] = StorageResult{key, state.GetState(args.Address, key), toBytesList(proof)}
+	}
+	accountProof, err := state.GetProof(args.Address)
+	if err != nil {
+		return err
+	}
+
+	*reply = AccountResult{
+		Address:         args.Address,
+		AccountProof:    toBytesList(accountProof),
+		Balance:         (*utils.Big)(state.GetBalance(args.Address)),
+		LockedBalance:   (*utils.Big)(state.GetLockedBalance(args.Address)),
+		UnLockedBalance: (*utils.Big)(state.GetUnLockedBalance(args.Address)),
+		Nonce:           utils.Uint64(state.GetNonce(args.Address)),
+		CodeHash:        state.GetCodeHash(args.Address),
+		StorageRoot:     state.GetStorageRoot(args.Address),
+		StorageProof:    storageProof,
+	}
+	return nil
+}
+
+// toBytesList converts the proof nodes for json output.
+func toBytesList(list [][]byte) []utils.Bytes {
+	result := make([]utils.Bytes, len(list))
+	for i, b := range list {
+		result[i] = b
+	}
+	return result
 }
 
 func (u *UranusAPI) getState(height BlockHeight) (*state.StateDB, error) {
@@ -342,5 +698,5 @@ func (u *UranusAPI) getState(height BlockHeight) (*state.StateDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return u.b.BlockChain().StateAt(block.StateRoot())
+	return u.b.StateAt(context.Background(), block.StateRoot())
 }
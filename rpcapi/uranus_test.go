@@ -0,0 +1,65 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchGas(t *testing.T) {
+	// any execution below the requirement fails, whatever the reason
+	need := uint64(53000)
+	executable := func(gas uint64) bool { return gas >= need }
+
+	gas, ok := searchGas(20999, 8000000, executable)
+	assert.True(t, ok)
+	assert.Equal(t, need, gas)
+
+	gas, ok = searchGas(20999, need, executable)
+	assert.True(t, ok)
+	assert.Equal(t, need, gas)
+
+	_, ok = searchGas(20999, need-1, executable)
+	assert.False(t, ok)
+
+	// the intrinsic gas is enough
+	gas, ok = searchGas(20999, 8000000, func(uint64) bool { return true })
+	assert.True(t, ok)
+	assert.Equal(t, uint64(21000), gas)
+}
+
+func TestGasAllowance(t *testing.T) {
+	price := big.NewInt(10)
+
+	hi, err := gasAllowance(8000000, big.NewInt(1000000), big.NewInt(0), price)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100000), hi)
+
+	hi, err = gasAllowance(8000000, big.NewInt(1000000), big.NewInt(500000), price)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(50000), hi)
+
+	hi, err = gasAllowance(30000, big.NewInt(1000000), nil, price)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(30000), hi)
+
+	_, err = gasAllowance(8000000, big.NewInt(1000), big.NewInt(1001), price)
+	assert.Error(t, err)
+}
@@ -17,9 +17,13 @@
 package rpcapi
 
 import (
+	"context"
 	"errors"
+	"time"
 
+	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/wallet"
 )
 
@@ -106,6 +110,34 @@ type ExportRawKeyArgs struct {
 	Passphrase string
 }
 
+// UnlockArgs represents the arguments to unlock an account.
+type UnlockArgs struct {
+	Address    utils.Address
+	Passphrase string
+	Duration   *uint64 // seconds, 0 unlocks until locked, default 300
+}
+
+// Unlock unlocks the account for the given duration, so that it signs transactions
+// without passphrase.
+func (w *WalletAPI) Unlock(args UnlockArgs, reply *bool) error {
+	duration := uint64(300)
+	if args.Duration != nil {
+		duration = *args.Duration
+	}
+	if err := w.b.Unlock(args.Address, args.Passphrase, time.Duration(duration)*time.Second); err != nil {
+		return err
+	}
+	*reply = true
+	return nil
+}
+
+// Lock locks the account, removing its decrypted key from memory.
+func (w *WalletAPI) Lock(address utils.Address, reply *bool) error {
+	w.b.Lock(address)
+	*reply = true
+	return nil
+}
+
 // ExportRawKey  returns key hex.
 func (w *WalletAPI) ExportRawKey(args ExportRawKeyArgs, reply *string) error {
 	hex, err := w.b.ExportRawKey(args.Address, args.Passphrase)
@@ -116,3 +148,77 @@ func (w *WalletAPI) ExportRawKey(args ExportRawKeyArgs, reply *string) error {
 	*reply = hex
 	return nil
 }
+
+// MultisigTxArgs represents the arguments to create a transaction sent from the
+// multisig account From, signed by one of its owners.
+type MultisigTxArgs struct {
+	SendTxArgs
+	Owner utils.Address
+}
+
+// SignMultisigTransaction creates the transaction of the multisig account and
+// returns it encoded with the signature of the owner, the other owners add
+// theirs before it's sent by Uranus.SendRawTransaction.
+func (w *WalletAPI) SignMultisigTransaction(args MultisigTxArgs, reply *utils.Bytes) error {
+	if err := args.check(context.Background(), w.b); err != nil {
+		return err
+	}
+	tx := args.toTransaction()
+	tx.WithMultisigAccount(args.From)
+	return w.signMultisig(tx, args.Owner, args.Passphrase, reply)
+}
+
+// AddSignatureArgs represents the arguments to add the signature of an owner
+// to a multisig transaction.
+type AddSignatureArgs struct {
+	Tx         utils.Bytes
+	Owner      utils.Address
+	Passphrase string // empty to sign with the unlocked account
+}
+
+// AddMultisigSignature adds the signature of the owner to the encoded multisig transaction.
+func (w *WalletAPI) AddMultisigSignature(args AddSignatureArgs, reply *utils.Bytes) error {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(args.Tx, tx); err != nil {
+		return err
+	}
+	if tx.MultiSignature() == nil {
+		return errors.New("not a multisig transaction")
+	}
+	return w.signMultisig(tx, args.Owner, args.Passphrase, reply)
+}
+
+// CombineMultisigSignatures merges the signatures the owners added separately to
+// copies of the same encoded multisig transaction.
+func (w *WalletAPI) CombineMultisigSignatures(encodedTxs []utils.Bytes, reply *utils.Bytes) error {
+	txs := make([]*types.Transaction, len(encodedTxs))
+	for i, encoded := range encodedTxs {
+		txs[i] = new(types.Transaction)
+		if err := rlp.DecodeBytes(encoded, txs[i]); err != nil {
+			return err
+		}
+	}
+	tx, err := types.CombineMultiSignatures(types.NewSigner(w.b.ChainConfig().ChainID), txs...)
+	if err != nil {
+		return err
+	}
+	encoded, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return err
+	}
+	*reply = encoded
+	return nil
+}
+
+func (w *WalletAPI) signMultisig(tx *types.Transaction, owner utils.Address, passphrase string, reply *utils.Bytes) error {
+	signed, err := w.b.SignTx(owner, tx, passphrase)
+	if err != nil {
+		return err
+	}
+	encoded, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return err
+	}
+	*reply = encoded
+	return nil
+}
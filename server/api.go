@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/UranusBlockStack/uranus/common/math"
 	"github.com/UranusBlockStack/uranus/common/utils"
@@ -28,10 +29,13 @@ import (
 	"github.com/UranusBlockStack/uranus/core"
 	"github.com/UranusBlockStack/uranus/core/executor"
 	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/txpool"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/feed"
 	"github.com/UranusBlockStack/uranus/p2p"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/params"
 	"github.com/UranusBlockStack/uranus/rpcapi"
 	"github.com/UranusBlockStack/uranus/server/forecast"
 	"github.com/UranusBlockStack/uranus/wallet"
@@ -49,6 +53,11 @@ func (api *APIBackend) BlockChain() *core.BlockChain {
 	return api.u.BlockChain()
 }
 
+// ChainConfig returns the chain configuration.
+func (api *APIBackend) ChainConfig() *params.ChainConfig {
+	return api.u.chainConfig
+}
+
 // CurrentBlock returns blockchain current block.
 func (api *APIBackend) CurrentBlock() *types.Block {
 	return api.u.blockchain.CurrentBlock()
@@ -81,6 +90,20 @@ func (api *APIBackend) BlockByHash(ctx context.Context, blockHash utils.Hash) (*
 	return api.u.blockchain.GetBlockByHash(blockHash), nil
 }
 
+// HeaderByHeight returns block header by block height.
+func (api *APIBackend) HeaderByHeight(ctx context.Context, height rpcapi.BlockHeight) (*types.BlockHeader, error) {
+	block, err := api.BlockByHeight(ctx, height)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return block.BlockHeader(), nil
+}
+
+// HeaderByHash returns block header by block hash.
+func (api *APIBackend) HeaderByHash(ctx context.Context, blockHash utils.Hash) (*types.BlockHeader, error) {
+	return api.u.blockchain.GetHeader(blockHash), nil
+}
+
 // GetReceipts returns receipte by block hash.
 func (api *APIBackend) GetReceipts(ctx context.Context, blockHash utils.Hash) (types.Receipts, error) {
 	return api.u.blockchain.GetReceipts(blockHash), nil
@@ -109,9 +132,19 @@ func (api *APIBackend) GetTd(blockHash utils.Hash) *big.Int {
 	return api.u.blockchain.GetTd(blockHash)
 }
 
-// SendTx send signed transaction to txpool.
+// SubscribeChainBlockEvent registers a subscription of chain block event.
+func (api *APIBackend) SubscribeChainBlockEvent(ch chan<- feed.BlockAndLogsEvent) feed.Subscription {
+	return api.u.blockchain.SubscribeChainBlockEvent(ch)
+}
+
+// SubscribeRemovedLogsEvent registers a subscription of logs removed by chain reorgs.
+func (api *APIBackend) SubscribeRemovedLogsEvent(ch chan<- feed.RemovedLogsEvent) feed.Subscription {
+	return api.u.blockchain.SubscribeRemovedLogsEvent(ch)
+}
+
+// SendTx send signed transaction to txpool as a local one.
 func (api *APIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	return api.u.txPool.AddTx(signedTx)
+	return api.u.txPool.AddLocal(signedTx)
 }
 
 // GetPoolTransactions get txpool pending transactions.
@@ -137,11 +170,26 @@ func (api *APIBackend) GetTransaction(txHash utils.Hash) *types.StorageTx {
 	return api.u.blockchain.GetTransactionByHash(txHash)
 }
 
+// StateAt returns the state of the given root.
+func (api *APIBackend) StateAt(ctx context.Context, root utils.Hash) (*state.StateDB, error) {
+	return api.u.blockchain.StateAt(root)
+}
+
 // GetPoolNonce get txpool nonce by address.
 func (api *APIBackend) GetPoolNonce(ctx context.Context, addr utils.Address) (uint64, error) {
 	return api.u.txPool.State().GetNonce(addr), nil
 }
 
+// GetPoolTxStatus returns the txpool status of the transactions.
+func (api *APIBackend) GetPoolTxStatus(hashes []utils.Hash) []txpool.TxStatus {
+	return api.u.txPool.Status(hashes)
+}
+
+// TxPoolPriceBump returns the price bump percentage to replace a pooled transaction.
+func (api *APIBackend) TxPoolPriceBump() uint64 {
+	return api.u.config.TxPoolConfig.PriceBump
+}
+
 // TxPoolStats get transaction pool stats.
 func (api *APIBackend) TxPoolStats() (pending int, queued int) {
 	return api.u.txPool.Stats()
@@ -152,6 +200,11 @@ func (api *APIBackend) TxPoolContent() (map[utils.Address]types.Transactions, ma
 	return api.u.txPool.Content()
 }
 
+// SubscribeNewTxsEvent registers a subscription of new transactions event.
+func (api *APIBackend) SubscribeNewTxsEvent(ch chan<- feed.NewTxsEvent) feed.Subscription {
+	return api.u.txPool.SubscribeNewTxsEvent(ch)
+}
+
 // NewAccount creates a new account
 func (api *APIBackend) NewAccount(passphrase string) (wallet.Account, error) {
 	return api.u.wallet.NewAccount(passphrase)
@@ -177,7 +230,7 @@ func (api *APIBackend) Update(address utils.Address, passphrase, newPassphrase s
 
 // SignTx sign the specified transaction
 func (api *APIBackend) SignTx(addr utils.Address, tx *types.Transaction, passphrase string) (*types.Transaction, error) {
-	return api.u.wallet.SignTx(addr, tx, passphrase)
+	return api.u.wallet.SignTx(addr, tx, passphrase, api.u.chainConfig.ChainID)
 }
 
 // Accounts list all wallet accounts.
@@ -195,6 +248,16 @@ func (api *APIBackend) ExportRawKey(addr utils.Address, passphrase string) (stri
 	return api.u.wallet.ExportRawKey(addr, passphrase)
 }
 
+// Unlock holds the decrypted key of the account in memory for the given duration.
+func (api *APIBackend) Unlock(addr utils.Address, passphrase string, duration time.Duration) error {
+	return api.u.wallet.Unlock(addr, passphrase, duration)
+}
+
+// Lock removes the decrypted key of the account from memory.
+func (api *APIBackend) Lock(addr utils.Address) {
+	api.u.wallet.Lock(addr)
+}
+
 // SuggestGasPrice suggest gas price
 func (api *APIBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return api.gp.SuggestPrice(ctx)
@@ -245,6 +308,44 @@ func (api *APIBackend) RemovePeer(url string) error {
 	return nil
 }
 
+func (api *APIBackend) AddTrustedPeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	api.srv.AddTrustedPeer(node)
+	return nil
+}
+
+func (api *APIBackend) RemoveTrustedPeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	api.srv.RemoveTrustedPeer(node)
+	return nil
+}
+
+func (api *APIBackend) BanPeer(url string, duration time.Duration) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	return api.srv.Ban(node.ID, duration)
+}
+
+func (api *APIBackend) UnbanPeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	return api.srv.Unban(node.ID)
+}
+
+func (api *APIBackend) Bans() ([]*p2p.BanInfo, error) {
+	return api.srv.Bans(), nil
+}
+
 func (api *APIBackend) Peers() ([]*p2p.PeerInfo, error) {
 	return api.srv.PeersInfo(), nil
 }
@@ -254,6 +355,9 @@ func (api *APIBackend) NodeInfo() (*p2p.NodeInfo, error) {
 }
 
 func (api *APIBackend) Start(threads int32) error {
+	if coinbase := api.u.miner.GetCoinBase(); !api.u.wallet.IsUnlocked(coinbase) {
+		return fmt.Errorf("coinbase %v: %v", coinbase.Hex(), wallet.ErrLocked)
+	}
 	return api.u.miner.Start()
 }
 func (api *APIBackend) Stop() error {
@@ -270,3 +374,8 @@ func (api *APIBackend) GetConfirmedBlockNumber() (*big.Int, error) {
 func (api *APIBackend) GetBFTConfirmedBlockNumber() (*big.Int, error) {
 	return api.u.engine.(*dpos.Dpos).GetBFTConfirmedBlockNumber()
 }
+
+// SubscribeConfirmedEvent registers a subscription of local and received block confirmations.
+func (api *APIBackend) SubscribeConfirmedEvent() *feed.TypeMuxSubscription {
+	return api.u.eventMux.Subscribe(feed.NewConfirmedEvent{}, types.Confirmed{})
+}
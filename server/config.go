@@ -32,11 +32,18 @@ type UranusConfig struct {
 
 	DBHandles   int
 	DBCache     int
-	TrieCache   int
+	TrieCache   int `mapstructure:"trie-cache"`
 	TrieTimeout time.Duration
 
+	// Trie pruning options
+	TrieArchive       bool   `mapstructure:"trie-archive"`
+	TrieFlushInterval uint64 `mapstructure:"trie-flushinterval"`
+
 	StartMiner bool `mapstructure:"miner-start"`
 
+	// Synchronisation mode of the downloader, "full" or "fast"
+	SyncMode string `mapstructure:"sync-mode"`
+
 	// Ledger config
 	LedgerConfig *ledger.Config
 
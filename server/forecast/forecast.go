@@ -25,6 +25,7 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/params"
 	"github.com/UranusBlockStack/uranus/rpcapi"
 )
 
@@ -47,6 +48,7 @@ type GetBlock func(ctx context.Context, height rpcapi.BlockHeight) (*types.Block
 // Forecast gas prices based on the content of recent blocks.
 type Forecast struct {
 	cfg                 *Config
+	chainConfig         *params.ChainConfig
 	getBlockFunc        GetBlock
 	lastBlockHash       atomic.Value
 	lastPrice           atomic.Value
@@ -56,9 +58,10 @@ type Forecast struct {
 }
 
 // NewForecast returns a new Forecast.
-func NewForecast(f GetBlock, cfg *Config) *Forecast {
+func NewForecast(f GetBlock, chainConfig *params.ChainConfig, cfg *Config) *Forecast {
 	forecast := &Forecast{
 		cfg:          cfg.check(),
+		chainConfig:  chainConfig,
 		getBlockFunc: f,
 		maxEmpty:     cfg.BlockNum / 2,
 		maxBlocks:    cfg.BlockNum * 5,
@@ -145,6 +148,10 @@ func (gpf *Forecast) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	if price.Cmp(maxPrice) > 0 {
 		price = new(big.Int).Set(maxPrice)
 	}
+	// the next block doesn't include transactions under its minimum gas price
+	if min := types.CalcMinGasPrice(gpf.chainConfig, block.BlockHeader()); min != nil && price.Cmp(min) < 0 {
+		price = min
+	}
 
 	gpf.lastBlockHash.Store(blockHash)
 	gpf.lastPrice.Store(price)
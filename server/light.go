@@ -0,0 +1,170 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/consensus"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/UranusBlockStack/uranus/light"
+	"github.com/UranusBlockStack/uranus/node"
+	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/UranusBlockStack/uranus/rpc"
+	"github.com/UranusBlockStack/uranus/rpcapi"
+	"github.com/UranusBlockStack/uranus/server/forecast"
+	"github.com/UranusBlockStack/uranus/wallet"
+)
+
+// LightUranus implements the service of a light node, it syncs the verified
+// block headers only and retrieves the rest from the full peers on demand.
+type LightUranus struct {
+	config      *UranusConfig
+	chainConfig *params.ChainConfig
+
+	engine   *dpos.Dpos
+	manager  *light.ClientManager
+	chainDb  db.Database // Header chain database
+	wallet   *wallet.Wallet
+	eventMux *feed.TypeMux
+
+	uranusAPI   *LightAPIBackend
+	eventSystem *rpcapi.EventSystem
+	filterAPI   *rpcapi.FilterAPI
+}
+
+// NewLight creates a new LightUranus object
+func NewLight(ctx *node.Context, config *UranusConfig) (*LightUranus, error) {
+	log.Debugf("load uranus light config: %s", config)
+	chainDb, err := CreateDB(ctx, config, "lightchaindata")
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup genesis block
+	chainCfg, _, _, err := ledger.SetupGenesis(config.Genesis, ledger.NewChain(chainDb))
+	if err != nil {
+		return nil, err
+	}
+
+	cjson, _ := json.Marshal(chainCfg)
+	log.Infof("chain config %v", string(cjson))
+
+	mux := &feed.TypeMux{}
+	lu := &LightUranus{
+		config:      config,
+		chainDb:     chainDb,
+		chainConfig: chainCfg,
+		eventMux:    mux,
+	}
+
+	lu.wallet = wallet.NewWallet(ctx.ResolvePath("keystore"))
+
+	// engine, the validators are checked against the state retrieved on demand
+	setupDposOption(chainCfg)
+	lu.manager, err = light.NewClientManager(config.LedgerConfig, chainCfg, chainDb, func(db state.Database) consensus.Engine {
+		lu.engine = dpos.NewDpos(mux, chainDb, db, lu.wallet.SignHashUnlocked)
+		return lu.engine
+	})
+	if err != nil {
+		return nil, err
+	}
+	lu.engine.Init(lu.manager.HeaderChain())
+
+	// api
+	lu.uranusAPI = &LightAPIBackend{lu: lu}
+	lu.uranusAPI.gp = forecast.NewForecast(lu.uranusAPI.BlockByHeight, chainCfg, forecast.DefaultConfig)
+	lu.eventSystem = rpcapi.NewEventSystem(lu.uranusAPI)
+	lu.filterAPI = rpcapi.NewFilterAPI(lu.uranusAPI, lu.eventSystem)
+
+	return lu, nil
+}
+
+// Protocols implements node.Service.
+func (lu *LightUranus) Protocols() []*p2p.Protocol {
+	return lu.manager.SubProtocols
+}
+
+// APIs return the collection of RPC services the light node offers, the mining
+// and tracing services need the full chain.
+func (lu *LightUranus) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "Admin",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewAdminAPI(lu.uranusAPI),
+		},
+		{
+			Namespace: "Uranus",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewUranusAPI(lu.uranusAPI),
+		},
+		{
+			Namespace: "Wallet",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewWalletAPI(lu.uranusAPI),
+		},
+		{
+			Namespace: "TxPool",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewTransactionPoolAPI(lu.uranusAPI),
+		},
+		{
+			Namespace: "BlockChain",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewBlockChainAPI(lu.uranusAPI),
+		},
+		{
+			Namespace: "Filter",
+			Version:   "0.0.1",
+			Service:   lu.filterAPI,
+		},
+		{
+			Namespace: "PubSub",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewPubSubAPI(lu.eventSystem),
+		},
+		{
+			Namespace: "Dpos",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewDposAPI(lu.uranusAPI),
+		},
+	}
+}
+
+// Start implements node.Service, starting the header sync.
+func (lu *LightUranus) Start(p2p *p2p.Server) error {
+	log.Info("start uranus light service...")
+	lu.manager.Start(p2p.MaxPeers)
+	lu.uranusAPI.srv = p2p
+	return nil
+}
+
+// Stop implements node.Service, terminating all internal goroutine
+func (lu *LightUranus) Stop() error {
+	lu.filterAPI.Stop()
+	lu.eventSystem.Stop()
+	lu.manager.Stop()
+	lu.chainDb.Close()
+	return nil
+}
@@ -0,0 +1,401 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/math"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core"
+	"github.com/UranusBlockStack/uranus/core/executor"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/txpool"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/UranusBlockStack/uranus/rpcapi"
+	"github.com/UranusBlockStack/uranus/server/forecast"
+	"github.com/UranusBlockStack/uranus/wallet"
+)
+
+// lightRetrievalTimeout bounds the retrievals of the backend methods without context.
+const lightRetrievalTimeout = 10 * time.Second
+
+var errLightNotSupported = errors.New("not supported on a light node")
+
+// LightAPIBackend implements the rpc apis on top of the light client.
+type LightAPIBackend struct {
+	lu     *LightUranus
+	gp     *forecast.Forecast
+	srv    *p2p.Server
+	txFeed feed.Feed
+}
+
+// BlockChain returns nil, a light node has no full block chain.
+func (api *LightAPIBackend) BlockChain() *core.BlockChain {
+	return nil
+}
+
+// ChainConfig returns the chain configuration.
+func (api *LightAPIBackend) ChainConfig() *params.ChainConfig {
+	return api.lu.chainConfig
+}
+
+// CurrentBlock returns the head header as a block without body.
+func (api *LightAPIBackend) CurrentBlock() *types.Block {
+	return api.lu.manager.HeaderChain().CurrentBlock()
+}
+
+// BlockByHeight returns block by block height, the body is retrieved on demand.
+func (api *LightAPIBackend) BlockByHeight(ctx context.Context, height rpcapi.BlockHeight) (*types.Block, error) {
+	header, err := api.HeaderByHeight(ctx, height)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	return api.lu.manager.GetBlock(ctx, header)
+}
+
+// BlockByHash returns Block by block hash, the body is retrieved on demand.
+func (api *LightAPIBackend) BlockByHash(ctx context.Context, blockHash utils.Hash) (*types.Block, error) {
+	header := api.lu.manager.HeaderChain().GetHeader(blockHash)
+	if header == nil {
+		return nil, nil
+	}
+	return api.lu.manager.GetBlock(ctx, header)
+}
+
+// HeaderByHeight returns block header by block height.
+func (api *LightAPIBackend) HeaderByHeight(ctx context.Context, height rpcapi.BlockHeight) (*types.BlockHeader, error) {
+	if height == rpcapi.PendingBlockHeight {
+		return nil, errors.New("light node no have pending block")
+	}
+	if height == rpcapi.LatestBlockHeight {
+		return api.lu.manager.HeaderChain().CurrentHeader(), nil
+	}
+	if height < -2 {
+		return nil, errors.New("block height must >= -2")
+	}
+	return api.lu.manager.HeaderChain().GetHeaderByHeight(uint64(height)), nil
+}
+
+// HeaderByHash returns block header by block hash.
+func (api *LightAPIBackend) HeaderByHash(ctx context.Context, blockHash utils.Hash) (*types.BlockHeader, error) {
+	return api.lu.manager.HeaderChain().GetHeader(blockHash), nil
+}
+
+// GetReceipts returns receipte by block hash, they are retrieved on demand.
+func (api *LightAPIBackend) GetReceipts(ctx context.Context, blockHash utils.Hash) (types.Receipts, error) {
+	header := api.lu.manager.HeaderChain().GetHeader(blockHash)
+	if header == nil {
+		return nil, nil
+	}
+	return api.lu.manager.GetReceipts(ctx, header)
+}
+
+// GetReceipt returns receipte by tx hash, it is retrieved with the receipts of its block.
+func (api *LightAPIBackend) GetReceipt(ctx context.Context, txHash utils.Hash) (*types.Receipt, error) {
+	stx, err := api.lu.manager.GetTransaction(ctx, txHash)
+	if stx == nil || err != nil {
+		return nil, err
+	}
+	receipts, err := api.GetReceipts(ctx, stx.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if stx.TxIndex >= uint64(len(receipts)) {
+		return nil, fmt.Errorf("receipt index %v out of range", stx.TxIndex)
+	}
+	return receipts[stx.TxIndex], nil
+}
+
+// GetLogs return Logs by block hash.
+func (api *LightAPIBackend) GetLogs(ctx context.Context, blockHash utils.Hash) ([][]*types.Log, error) {
+	receipts, err := api.GetReceipts(ctx, blockHash)
+	if receipts == nil || err != nil {
+		return nil, err
+	}
+	logs := make([][]*types.Log, len(receipts))
+	for i, receipt := range receipts {
+		logs[i] = receipt.Logs
+	}
+	return logs, nil
+}
+
+// GetTd get total difficulty by block hash.
+func (api *LightAPIBackend) GetTd(blockHash utils.Hash) *big.Int {
+	return api.lu.manager.HeaderChain().GetTd(blockHash)
+}
+
+// SubscribeChainBlockEvent registers a subscription of chain block event.
+func (api *LightAPIBackend) SubscribeChainBlockEvent(ch chan<- feed.BlockAndLogsEvent) feed.Subscription {
+	return api.lu.manager.HeaderChain().SubscribeChainBlockEvent(ch)
+}
+
+// SubscribeRemovedLogsEvent registers a subscription of logs removed by chain reorgs.
+func (api *LightAPIBackend) SubscribeRemovedLogsEvent(ch chan<- feed.RemovedLogsEvent) feed.Subscription {
+	return api.lu.manager.HeaderChain().SubscribeRemovedLogsEvent(ch)
+}
+
+// SendTx relays signed transaction to the light servers.
+func (api *LightAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if err := api.lu.manager.SendTxs(types.Transactions{signedTx}); err != nil {
+		return err
+	}
+	api.txFeed.Send(feed.NewTxsEvent{Txs: []*types.Transaction{signedTx}})
+	return nil
+}
+
+// GetPoolTransactions returns nothing, a light node has no txpool.
+func (api *LightAPIBackend) GetPoolTransactions() (types.Transactions, error) {
+	return nil, nil
+}
+
+// GetPoolTransaction returns nil, a light node has no txpool.
+func (api *LightAPIBackend) GetPoolTransaction(txHash utils.Hash) *types.Transaction {
+	return nil
+}
+
+// GetTransaction get tansaction by hash from the light servers.
+func (api *LightAPIBackend) GetTransaction(txHash utils.Hash) *types.StorageTx {
+	ctx, cancel := context.WithTimeout(context.Background(), lightRetrievalTimeout)
+	defer cancel()
+	stx, err := api.lu.manager.GetTransaction(ctx, txHash)
+	if err != nil {
+		log.Debugf("Failed to retrieve transaction hash: %v, err: %v", txHash.Hex(), err)
+		return nil
+	}
+	return stx
+}
+
+// StateAt returns the state of the given root, the trie nodes are retrieved on demand.
+func (api *LightAPIBackend) StateAt(ctx context.Context, root utils.Hash) (*state.StateDB, error) {
+	return state.New(root, api.lu.manager.StateDatabase())
+}
+
+// GetPoolNonce returns the nonce of the address in the head state.
+func (api *LightAPIBackend) GetPoolNonce(ctx context.Context, addr utils.Address) (uint64, error) {
+	statedb, err := api.StateAt(ctx, api.lu.manager.HeaderChain().CurrentHeader().StateRoot)
+	if err != nil {
+		return 0, err
+	}
+	return statedb.GetNonce(addr), nil
+}
+
+// GetPoolTxStatus reports the transactions unknown, a light node has no txpool.
+func (api *LightAPIBackend) GetPoolTxStatus(hashes []utils.Hash) []txpool.TxStatus {
+	return make([]txpool.TxStatus, len(hashes))
+}
+
+// TxPoolPriceBump returns the default price bump of the servers txpool.
+func (api *LightAPIBackend) TxPoolPriceBump() uint64 {
+	return txpool.DefaultTxPoolConfig.PriceBump
+}
+
+// TxPoolStats returns nothing, a light node has no txpool.
+func (api *LightAPIBackend) TxPoolStats() (pending int, queued int) {
+	return 0, 0
+}
+
+// TxPoolContent returns nothing, a light node has no txpool.
+func (api *LightAPIBackend) TxPoolContent() (map[utils.Address]types.Transactions, map[utils.Address]types.Transactions) {
+	return make(map[utils.Address]types.Transactions), make(map[utils.Address]types.Transactions)
+}
+
+// SubscribeNewTxsEvent registers a subscription of the transactions sent by the node.
+func (api *LightAPIBackend) SubscribeNewTxsEvent(ch chan<- feed.NewTxsEvent) feed.Subscription {
+	return api.txFeed.Subscribe(ch)
+}
+
+// NewAccount creates a new account
+func (api *LightAPIBackend) NewAccount(passphrase string) (wallet.Account, error) {
+	return api.lu.wallet.NewAccount(passphrase)
+}
+
+// Delete removes the speciified account
+func (api *LightAPIBackend) Delete(address utils.Address, passphrase string) error {
+	acc, err := api.lu.wallet.Find(address, passphrase)
+	if err != nil {
+		return err
+	}
+	return api.lu.wallet.Delete(acc, passphrase)
+}
+
+// Update update the specified account
+func (api *LightAPIBackend) Update(address utils.Address, passphrase, newPassphrase string) error {
+	acc, err := api.lu.wallet.Find(address, passphrase)
+	if err != nil {
+		return err
+	}
+	return api.lu.wallet.Update(acc, passphrase, newPassphrase)
+}
+
+// SignTx sign the specified transaction
+func (api *LightAPIBackend) SignTx(addr utils.Address, tx *types.Transaction, passphrase string) (*types.Transaction, error) {
+	return api.lu.wallet.SignTx(addr, tx, passphrase, api.lu.chainConfig.ChainID)
+}
+
+// Accounts list all wallet accounts.
+func (api *LightAPIBackend) Accounts() (wallet.Accounts, error) {
+	return api.lu.wallet.Accounts()
+}
+
+// ImportRawKey import raw key intfo wallet.
+func (api *LightAPIBackend) ImportRawKey(privkey string, passphrase string) (utils.Address, error) {
+	return api.lu.wallet.ImportRawKey(privkey, passphrase)
+}
+
+// ExportRawKey return key hex.
+func (api *LightAPIBackend) ExportRawKey(addr utils.Address, passphrase string) (string, error) {
+	return api.lu.wallet.ExportRawKey(addr, passphrase)
+}
+
+// Unlock holds the decrypted key of the account in memory for the given duration.
+func (api *LightAPIBackend) Unlock(addr utils.Address, passphrase string, duration time.Duration) error {
+	return api.lu.wallet.Unlock(addr, passphrase, duration)
+}
+
+// Lock removes the decrypted key of the account from memory.
+func (api *LightAPIBackend) Lock(addr utils.Address) {
+	api.lu.wallet.Lock(addr)
+}
+
+// SuggestGasPrice suggest gas price
+func (api *LightAPIBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return api.gp.SuggestPrice(ctx)
+}
+
+func (api *LightAPIBackend) GetEVM(ctx context.Context, from utils.Address, tx *types.Transaction, state *state.StateDB, bheader *types.BlockHeader, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+
+	state.SetBalance(from, math.MaxBig256)
+	vmError := func() error { return nil }
+
+	context := vm.Context{
+		CanTransfer: executor.CanTransfer,
+		Transfer:    executor.Transfer,
+		Origin:      from,
+		Coinbase:    utils.Address{},
+		BlockNumber: new(big.Int).Set(bheader.Height),
+		Time:        new(big.Int).Set(bheader.TimeStamp),
+		Difficulty:  new(big.Int).Set(bheader.Difficulty),
+		GasLimit:    bheader.GasLimit,
+		GasPrice:    new(big.Int).Set(tx.GasPrice()),
+	}
+	chain := api.lu.manager.HeaderChain()
+	context.GetHash = func(n uint64) utils.Hash {
+		for header := chain.GetHeader(bheader.PreviousHash); header != nil; header = chain.GetHeader(header.PreviousHash) {
+			if n == header.Height.Uint64()-1 {
+				return header.PreviousHash
+			}
+		}
+		return utils.Hash{}
+	}
+
+	return vm.NewEVM(context, state, api.lu.chainConfig, vmCfg), vmError, nil
+}
+
+func (api *LightAPIBackend) AddPeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	api.srv.AddPeer(node)
+	return nil
+}
+func (api *LightAPIBackend) RemovePeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	api.srv.RemovePeer(node)
+	return nil
+}
+
+func (api *LightAPIBackend) AddTrustedPeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	api.srv.AddTrustedPeer(node)
+	return nil
+}
+
+func (api *LightAPIBackend) RemoveTrustedPeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	api.srv.RemoveTrustedPeer(node)
+	return nil
+}
+
+func (api *LightAPIBackend) BanPeer(url string, duration time.Duration) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	return api.srv.Ban(node.ID, duration)
+}
+
+func (api *LightAPIBackend) UnbanPeer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid enode: %v", err)
+	}
+	return api.srv.Unban(node.ID)
+}
+
+func (api *LightAPIBackend) Bans() ([]*p2p.BanInfo, error) {
+	return api.srv.Bans(), nil
+}
+
+func (api *LightAPIBackend) Peers() ([]*p2p.PeerInfo, error) {
+	return api.srv.PeersInfo(), nil
+}
+
+func (api *LightAPIBackend) NodeInfo() (*p2p.NodeInfo, error) {
+	return api.srv.NodeInfo(), nil
+}
+
+func (api *LightAPIBackend) Start(threads int32) error {
+	return errLightNotSupported
+}
+func (api *LightAPIBackend) Stop() error {
+	return errLightNotSupported
+}
+func (api *LightAPIBackend) SetCoinbase(address utils.Address) error {
+	return errLightNotSupported
+}
+func (api *LightAPIBackend) GetConfirmedBlockNumber() (*big.Int, error) {
+	return api.lu.engine.GetConfirmedBlockNumber()
+}
+func (api *LightAPIBackend) GetBFTConfirmedBlockNumber() (*big.Int, error) {
+	return api.lu.engine.GetBFTConfirmedBlockNumber()
+}
+
+// SubscribeConfirmedEvent registers a subscription of received block confirmations.
+func (api *LightAPIBackend) SubscribeConfirmedEvent() *feed.TypeMuxSubscription {
+	return api.lu.eventMux.Subscribe(feed.NewConfirmedEvent{}, types.Confirmed{})
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/metrics"
+)
+
+// registerMetrics exports the chain, txpool and consensus metrics of the node.
+func (u *Uranus) registerMetrics() {
+	metrics.RegisterGaugeFunc("uranus_chain_head_height", "Height of the current head block.", func() float64 {
+		return float64(u.blockchain.CurrentBlock().Height().Uint64())
+	})
+	metrics.RegisterGaugeFunc("uranus_chain_head_age_seconds", "Seconds elapsed since the timestamp of the current head block.", func() float64 {
+		return time.Since(time.Unix(0, u.blockchain.CurrentBlock().Time().Int64())).Seconds()
+	})
+	metrics.RegisterGaugeFunc("uranus_chain_bft_confirmed_height", "Height of the last block confirmed by the validators.", func() float64 {
+		height, err := u.uranusAPI.GetBFTConfirmedBlockNumber()
+		if err != nil || height == nil {
+			return 0
+		}
+		return float64(height.Uint64())
+	})
+	metrics.RegisterGaugeFunc("uranus_txpool_pending", "Number of executable transactions in the pool.", func() float64 {
+		pending, _ := u.txPool.Stats()
+		return float64(pending)
+	})
+	metrics.RegisterGaugeFunc("uranus_txpool_queued", "Number of non executable transactions in the pool.", func() float64 {
+		_, queued := u.txPool.Stats()
+		return float64(queued)
+	})
+	metrics.RegisterCollector("uranus_dpos_minted_slots", "Blocks minted by each validator in the current epoch.", []string{"validator"}, func(emit func(v float64, values ...string)) {
+		for validator, stat := range u.slotStats() {
+			emit(float64(stat.Minted), validator.Hex())
+		}
+	})
+	metrics.RegisterCollector("uranus_dpos_missed_slots", "Elapsed slots without a block of each validator in the current epoch.", []string{"validator"}, func(emit func(v float64, values ...string)) {
+		for validator, stat := range u.slotStats() {
+			emit(float64(stat.Missed), validator.Hex())
+		}
+	})
+}
+
+// slotStats counts the slots of the validators in the current epoch, the
+// validators and their mint counts are read from the head block.
+func (u *Uranus) slotStats() map[utils.Address]*dpos.SlotStats {
+	block := u.blockchain.CurrentBlock()
+	statedb, err := u.blockchain.StateAt(block.StateRoot())
+	if err != nil {
+		log.Debugf("Failed to collect slot metrics: %v", err)
+		return nil
+	}
+	dposContext, err := types.NewDposContextFromProto(statedb.Database().TrieDB(), block.BlockHeader().DposContext)
+	if err != nil {
+		log.Debugf("Failed to collect slot metrics: %v", err)
+		return nil
+	}
+	epochContext := &dpos.EpochContext{TimeStamp: time.Now().UnixNano(), DposContext: dposContext, Statedb: statedb, Config: u.chainConfig}
+	stats, err := epochContext.SlotStats()
+	if err != nil {
+		log.Debugf("Failed to collect slot metrics: %v", err)
+		return nil
+	}
+	return stats
+}
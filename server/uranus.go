@@ -18,6 +18,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"sync"
 
 	"github.com/UranusBlockStack/uranus/common/db"
@@ -32,7 +33,9 @@ import (
 	"github.com/UranusBlockStack/uranus/core/vm"
 	"github.com/UranusBlockStack/uranus/debug"
 	"github.com/UranusBlockStack/uranus/feed"
+	"github.com/UranusBlockStack/uranus/light"
 	"github.com/UranusBlockStack/uranus/node"
+	"github.com/UranusBlockStack/uranus/node/protocols"
 	"github.com/UranusBlockStack/uranus/p2p"
 	"github.com/UranusBlockStack/uranus/params"
 	"github.com/UranusBlockStack/uranus/rpc"
@@ -52,10 +55,14 @@ type Uranus struct {
 	txPool     *txpool.TxPool
 	chainDb    db.Database // Block chain database
 	wallet     *wallet.Wallet
+	eventMux   *feed.TypeMux
 
 	protocolManager *node.ProtocolManager
+	lightServer     *light.Server
 
-	uranusAPI *APIBackend
+	uranusAPI   *APIBackend
+	eventSystem *rpcapi.EventSystem
+	filterAPI   *rpcapi.FilterAPI
 
 	shutdownChan chan bool // Channel for shutting down
 	lock         sync.RWMutex
@@ -83,6 +90,7 @@ func New(ctx *node.Context, config *UranusConfig) (*Uranus, error) {
 		config:       config,
 		chainDb:      chainDb,
 		chainConfig:  chainCfg,
+		eventMux:     mux,
 		shutdownChan: make(chan bool),
 	}
 
@@ -91,47 +99,66 @@ func New(ctx *node.Context, config *UranusConfig) (*Uranus, error) {
 	// engine
 	cpu := cpuminer.NewCpuMiner()
 	_ = cpu
-	dpos.Option.BlockInterval = chainCfg.BlockInterval
-	dpos.Option.BlockRepeat = chainCfg.BlockRepeat
-	dpos.Option.MaxValidatorSize = chainCfg.MaxValidatorSize
-	dpos.Option.MinStartQuantity = chainCfg.MinStartQuantity
-	if chainCfg.DelayEpcho > 0 {
-		dpos.Option.DelayEpcho = chainCfg.DelayEpcho
-	}
-	if chainCfg.MaxConfirmedNum > 0 {
-		dpos.Option.MaxConfirmedNum = chainCfg.MaxConfirmedNum
-	}
-	dpos := dpos.NewDpos(mux, chainDb, statedb, uranus.wallet.SignHash, "coinbase")
+	setupDposOption(chainCfg)
+	dpos := dpos.NewDpos(mux, chainDb, statedb, uranus.wallet.SignHashUnlocked)
 
 	// blockchain
 	log.Debugf("Initialised chain configuration: %v", chainCfg)
-	uranus.blockchain, err = core.NewBlockChain(config.LedgerConfig, uranus.chainConfig, statedb, chainDb, dpos, &vm.Config{})
+	cacheConfig := &core.CacheConfig{
+		Archive:       config.TrieArchive,
+		TrieNodeLimit: config.TrieCache,
+		FlushInterval: config.TrieFlushInterval,
+	}
+	uranus.blockchain, err = core.NewBlockChain(config.LedgerConfig, cacheConfig, uranus.chainConfig, statedb, chainDb, dpos, &vm.Config{})
 	if err != nil {
 		return nil, err
 	}
-	// txpool
+	// txpool, the local transactions are journaled in the data dir
+	if config.TxPoolConfig.Journal != "" {
+		config.TxPoolConfig.Journal = ctx.ResolvePath(config.TxPoolConfig.Journal)
+	}
 	uranus.txPool = txpool.New(config.TxPoolConfig, uranus.chainConfig, uranus.blockchain)
 
 	uranus.blockchain.SetAddActionInterface(uranus.txPool)
 
 	dpos.Init(uranus.blockchain)
 	// miner
-	uranus.miner = miner.NewUranusMiner(mux, uranus.chainConfig, checkMinerConfig(uranus.config.MinerConfig, uranus.wallet), &MinerBakend{u: uranus}, dpos, uranus.chainDb)
+	minerCfg, err := checkMinerConfig(uranus.config.MinerConfig, uranus.wallet, uranus.config.StartMiner)
+	if err != nil {
+		return nil, err
+	}
+	uranus.miner = miner.NewUranusMiner(mux, uranus.chainConfig, minerCfg, &MinerBakend{u: uranus}, dpos, uranus.chainDb)
 	uranus.engine = dpos
 	//dpos.MintLoop(uranus.miner, uranus.blockchain)
 
 	// api
 	uranus.uranusAPI = &APIBackend{u: uranus}
-	uranus.uranusAPI.gp = forecast.NewForecast(uranus.uranusAPI.BlockByHeight, forecast.DefaultConfig)
+	uranus.uranusAPI.gp = forecast.NewForecast(uranus.uranusAPI.BlockByHeight, chainCfg, forecast.DefaultConfig)
+	uranus.eventSystem = rpcapi.NewEventSystem(uranus.uranusAPI)
+	uranus.filterAPI = rpcapi.NewFilterAPI(uranus.uranusAPI, uranus.eventSystem)
 
-	uranus.protocolManager, _ = node.NewProtocolManager(mux, uranus.chainConfig, uranus.txPool, uranus.blockchain, uranus.chainDb, uranus.engine)
+	syncMode, err := protocols.ParseSyncMode(config.SyncMode)
+	if err != nil {
+		return nil, err
+	}
+	if syncMode == protocols.LightSync {
+		return nil, errors.New("light sync mode is run by the light service")
+	}
+	uranus.protocolManager, _ = node.NewProtocolManager(mux, uranus.chainConfig, syncMode, uranus.txPool, uranus.blockchain, uranus.chainDb, uranus.engine)
+	uranus.lightServer = light.NewServer(uranus.blockchain, uranus.txPool)
 
 	return uranus, nil
 }
 
 // Protocols implements node.Service.
 func (u *Uranus) Protocols() []*p2p.Protocol {
-	return u.protocolManager.SubProtocols
+	return append(u.protocolManager.SubProtocols, u.lightServer.SubProtocols...)
+}
+
+// debugAPI serves the runtime debugging methods together with the tracing methods.
+type debugAPI struct {
+	*debug.HandlerT
+	*rpcapi.DebugAPI
 }
 
 // APIs return the collection of RPC services the Uranus package offers.
@@ -167,6 +194,16 @@ func (u *Uranus) APIs() []rpc.API {
 			Version:   "0.0.1",
 			Service:   rpcapi.NewBlockChainAPI(u.uranusAPI),
 		},
+		{
+			Namespace: "Filter",
+			Version:   "0.0.1",
+			Service:   u.filterAPI,
+		},
+		{
+			Namespace: "PubSub",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewPubSubAPI(u.eventSystem),
+		},
 		{
 			Namespace: "Dpos",
 			Version:   "0.0.1",
@@ -175,7 +212,7 @@ func (u *Uranus) APIs() []rpc.API {
 		{
 			Namespace: "Debug",
 			Version:   "0.0.1",
-			Service:   debug.Handler,
+			Service:   &debugAPI{debug.Handler, rpcapi.NewDebugAPI(u.uranusAPI)},
 		},
 	}
 }
@@ -185,20 +222,26 @@ func (u *Uranus) Start(p2p *p2p.Server) error {
 	log.Info("start uranus service...")
 	// start p2p
 	u.protocolManager.Start(p2p.MaxPeers)
+	u.lightServer.Start(p2p.MaxPeers)
 	// start miner
 	if u.config.StartMiner {
 		u.miner.Start()
 	}
 	u.uranusAPI.srv = p2p
+	u.registerMetrics()
 	return nil
 }
 
 // Stop implements node.Service, terminating all internal goroutine
 func (u *Uranus) Stop() error {
+	u.filterAPI.Stop()
+	u.eventSystem.Stop()
+	u.protocolManager.Stop()
+	u.lightServer.Stop()
 	u.miner.Stop()
 	u.txPool.Stop()
+	u.blockchain.Stop()
 	u.chainDb.Close()
-	u.protocolManager.Stop()
 	close(u.shutdownChan)
 	return nil
 }
@@ -17,11 +17,15 @@
 package server
 
 import (
+	"fmt"
+	"io/ioutil"
 	"runtime"
+	"strings"
 
 	"github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
 	"github.com/UranusBlockStack/uranus/consensus/miner"
 	"github.com/UranusBlockStack/uranus/node"
 	"github.com/UranusBlockStack/uranus/params"
@@ -37,7 +41,11 @@ func CreateDB(ctx *node.Context, config *UranusConfig, name string) (db.Database
 	return db, nil
 }
 
-func checkMinerConfig(cfg *miner.Config, wallet *wallet.Wallet) *miner.Config {
+// defaultCoinbasePassphrase is the passphrase of the coinbase created on the first
+// start when no password file is configured.
+const defaultCoinbasePassphrase = "coinbase"
+
+func checkMinerConfig(cfg *miner.Config, wallet *wallet.Wallet, startMiner bool) (*miner.Config, error) {
 	// extra data
 	if uint64(len([]byte(cfg.ExtraData))) > params.MaxExtraDataSize {
 		log.Warnf("Miner extra data exceed limit extra: %v, limit:%v", cfg.ExtraData, params.MaxExtraDataSize)
@@ -51,25 +59,72 @@ func checkMinerConfig(cfg *miner.Config, wallet *wallet.Wallet) *miner.Config {
 		cfg.MinerThreads = runtime.NumCPU()
 	}
 
+	passphrase, err := readPassphrase(cfg.PasswordFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coinbase password file: %v", err)
+	}
+	if cfg.PasswordFile == "" {
+		log.Warnf("No coinbase password file configured, the default passphrase %q is deprecated", defaultCoinbasePassphrase)
+		passphrase = defaultCoinbasePassphrase
+	}
+
 	accounts, err := wallet.Accounts()
 	if len(accounts) == 0 {
 		if err != nil {
 			log.Error(err)
 		}
 		if !utils.IsHexAddr(cfg.CoinBaseAddr) || (utils.HexToAddress(cfg.CoinBaseAddr) == (utils.Address{})) {
-			account, err := wallet.NewAccount("coinbase")
+			account, err := wallet.NewAccount(passphrase)
 			if err != nil {
 				log.Warnf("generate conbase account failed: %v", err)
-				return cfg
+				return cfg, nil
+			}
+			if cfg.PasswordFile == "" {
+				log.Warnf("CoinBase automatically configured address: %v, passphrase: %v", account.Address, passphrase)
+			} else {
+				log.Warnf("CoinBase automatically configured address: %v", account.Address)
 			}
-			log.Warnf("CoinBase automatically configured address: %v, passphrase: %v", account.Address, "coinbase")
 			cfg.CoinBaseAddr = account.Address.Hex()
 		}
-		return cfg
+	} else {
+		cfg.CoinBaseAddr = accounts[0].Address.Hex()
+		log.Infof("Coinbase addr: %v", cfg.CoinBaseAddr)
+	}
+
+	// blocks are signed with the unlocked key of the coinbase
+	coinbase := utils.HexToAddress(cfg.CoinBaseAddr)
+	if err := wallet.Unlock(coinbase, passphrase, 0); err != nil {
+		if startMiner {
+			return nil, fmt.Errorf("failed to unlock coinbase %v: %v", coinbase, err)
+		}
+		log.Warnf("Coinbase %v is locked, unlock it to sign blocks: %v", coinbase, err)
 	}
+	return cfg, nil
+}
 
-	cfg.CoinBaseAddr = accounts[0].Address.Hex()
+// readPassphrase returns the passphrase on the first line of the given file.
+func readPassphrase(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(text), "\n")
+	return strings.TrimRight(lines[0], "\r"), nil
+}
 
-	log.Infof("Coinbase addr: %v", cfg.CoinBaseAddr)
-	return cfg
+// setupDposOption applies the dpos parameters of the chain configuration.
+func setupDposOption(chainCfg *params.ChainConfig) {
+	dpos.Option.BlockInterval = chainCfg.BlockInterval
+	dpos.Option.BlockRepeat = chainCfg.BlockRepeat
+	dpos.Option.MaxValidatorSize = chainCfg.MaxValidatorSize
+	dpos.Option.MinStartQuantity = chainCfg.MinStartQuantity
+	if chainCfg.DelayEpcho > 0 {
+		dpos.Option.DelayEpcho = chainCfg.DelayEpcho
+	}
+	if chainCfg.MaxConfirmedNum > 0 {
+		dpos.Option.MaxConfirmedNum = chainCfg.MaxConfirmedNum
+	}
 }
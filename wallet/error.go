@@ -21,4 +21,5 @@ import "errors"
 var (
 	ErrNoMatch = errors.New("no key for given address or file")
 	ErrDecrypt = errors.New("could not decrypt key with given passphrase")
+	ErrLocked  = errors.New("account is locked, unlock it or provide the passphrase")
 )
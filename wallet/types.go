@@ -42,6 +42,12 @@ type lockAccount struct {
 	passphrase string
 }
 
+// unlocked is an account whose decrypted key is held in memory until locked.
+type unlocked struct {
+	account *Account
+	abort   chan struct{} // closed to stop the timer of a timed unlock
+}
+
 // Account represents an uranus account.
 type Account struct {
 	Address    utils.Address     `json:"address"`
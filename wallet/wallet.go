@@ -18,10 +18,13 @@ package wallet
 
 import (
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	lockcache "github.com/UranusBlockStack/uranus/common/cache"
 	"github.com/UranusBlockStack/uranus/common/crypto"
@@ -38,6 +41,9 @@ const (
 type Wallet struct {
 	ks           *KeyStore
 	accountCache *lockcache.Cache
+
+	mu       sync.RWMutex
+	unlocked map[utils.Address]*unlocked // Accounts signing without passphrase
 }
 
 // NewWallet initialize wallet.
@@ -47,6 +53,7 @@ func NewWallet(ksdir string) *Wallet {
 	wallet := &Wallet{
 		ks:           NewKeyStore(ksdir),
 		accountCache: accountCache,
+		unlocked:     make(map[utils.Address]*unlocked),
 	}
 	return wallet
 }
@@ -165,6 +172,7 @@ func (w *Wallet) Delete(account Account, passphrase string) error {
 		return nil
 	}
 	w.accountCache.Remove(account.Address)
+	w.Lock(account.Address)
 	return os.Remove(path)
 }
 
@@ -183,14 +191,92 @@ func (w *Wallet) Update(account Account, passphrase, newPassphrase string) error
 	return w.ks.PutKey(*newaccount, path, newPassphrase)
 }
 
-// SignTx sign the specified transaction
-func (w *Wallet) SignTx(addr utils.Address, tx *types.Transaction, passphrase string) (*types.Transaction, error) {
+// Unlock decrypts the key of the given address and holds it in memory, so the account
+// signs without passphrase. The account is locked again after the given duration,
+// a zero duration unlocks it until Lock is called. Unlocking an unlocked account
+// replaces its duration.
+func (w *Wallet) Unlock(addr utils.Address, passphrase string, duration time.Duration) error {
 	account, err := w.Find(addr, passphrase)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if u, ok := w.unlocked[addr]; ok && u.abort != nil {
+		close(u.abort)
+	}
+	u := &unlocked{account: &account}
+	if duration > 0 {
+		u.abort = make(chan struct{})
+		go w.expire(addr, u, duration)
+	}
+	w.unlocked[addr] = u
+	return nil
+}
+
+// expire locks the account once the duration of its unlock elapsed.
+func (w *Wallet) expire(addr utils.Address, u *unlocked, duration time.Duration) {
+	t := time.NewTimer(duration)
+	defer t.Stop()
+	select {
+	case <-u.abort:
+	case <-t.C:
+		w.mu.Lock()
+		// only drop if it's still the same unlock, not a newer one
+		if w.unlocked[addr] == u {
+			delete(w.unlocked, addr)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// Lock removes the decrypted key of the given address from memory.
+func (w *Wallet) Lock(addr utils.Address) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if u, ok := w.unlocked[addr]; ok {
+		if u.abort != nil {
+			close(u.abort)
+		}
+		delete(w.unlocked, addr)
+	}
+}
+
+// IsUnlocked returns whether the given address signs without passphrase.
+func (w *Wallet) IsUnlocked(addr utils.Address) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, ok := w.unlocked[addr]
+	return ok
+}
+
+// signer returns the account signing for the given address, the unlocked one
+// if the passphrase is empty.
+func (w *Wallet) signer(addr utils.Address, passphrase string) (Account, error) {
+	if passphrase != "" {
+		return w.Find(addr, passphrase)
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if u, ok := w.unlocked[addr]; ok {
+		return *u.account, nil
+	}
+	return Account{}, ErrLocked
+}
+
+// SignTx sign the specified transaction for the chain with the given id,
+// with the unlocked key of the account if the passphrase is empty.
+func (w *Wallet) SignTx(addr utils.Address, tx *types.Transaction, passphrase string, chainID *big.Int) (*types.Transaction, error) {
+	if chainID == nil {
+		return nil, types.ErrInvalidChainId
+	}
+	account, err := w.signer(addr, passphrase)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := tx.SignTx(types.Signer{}, account.PrivateKey); err != nil {
+	if err := tx.SignTx(types.NewSigner(chainID), account.PrivateKey); err != nil {
 		return nil, err
 	}
 
@@ -198,11 +284,17 @@ func (w *Wallet) SignTx(addr utils.Address, tx *types.Transaction, passphrase st
 }
 
 // SignHash signs hash if the private key matching the given address
-// can be decrypted with the given passphrase.
+// can be decrypted with the given passphrase, or is unlocked if the
+// passphrase is empty.
 func (w *Wallet) SignHash(addr utils.Address, passphrase string, hash []byte) ([]byte, error) {
-	account, err := w.Find(addr, passphrase)
+	account, err := w.signer(addr, passphrase)
 	if err != nil {
 		return nil, err
 	}
 	return crypto.Sign(hash[:], account.PrivateKey)
 }
+
+// SignHashUnlocked signs hash with the unlocked private key of the given address.
+func (w *Wallet) SignHashUnlocked(addr utils.Address, hash []byte) ([]byte, error) {
+	return w.SignHash(addr, "", hash)
+}
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/UranusBlockStack/uranus/common/crypto"
 	"github.com/UranusBlockStack/uranus/common/utils"
@@ -154,15 +155,49 @@ func TestSignTx(t *testing.T) {
 	to := utils.Address{}
 	tx := types.NewTransaction(types.Binary, 0, big.NewInt(100), 1000, big.NewInt(100), nil, &to)
 
-	signTx, err := w.SignTx(account.Address, tx, "test")
+	signTx, err := w.SignTx(account.Address, tx, "test", big.NewInt(1))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	from, err := signTx.Sender(types.Signer{})
+	from, err := signTx.Sender(types.NewSigner(big.NewInt(1)))
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	assert.Equal(t, from, account.Address)
+
+	_, err = signTx.Sender(types.NewSigner(big.NewInt(2)))
+	assert.Equal(t, types.ErrInvalidChainId, err)
+}
+
+func TestUnlock(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "test_keystoredir")
+	w := NewWallet(dir)
+	account, err := w.NewAccount("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := crypto.Keccak256([]byte("hash"))
+
+	_, err = w.SignHashUnlocked(account.Address, hash)
+	assert.Equal(t, ErrLocked, err)
+	assert.Equal(t, ErrDecrypt, w.Unlock(account.Address, "wrong", 0))
+
+	assert.NoError(t, w.Unlock(account.Address, "test", 0))
+	sig, err := w.SignHashUnlocked(account.Address, hash)
+	assert.NoError(t, err)
+	pub, err := crypto.EcrecoverToPub(hash, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, account.Address, crypto.PubkeyToAddress(*pub))
+
+	w.Lock(account.Address)
+	_, err = w.SignHash(account.Address, "", hash)
+	assert.Equal(t, ErrLocked, err)
+
+	// timed unlock
+	assert.NoError(t, w.Unlock(account.Address, "test", 50*time.Millisecond))
+	assert.True(t, w.IsUnlocked(account.Address))
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, w.IsUnlocked(account.Address))
 }